@@ -0,0 +1,170 @@
+package lostdogs
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AgeClass is a coarse life stage derived from the age range.
+type AgeClass string
+
+const (
+	AgeBaby   AgeClass = "baby"   // under 6 months: kittens, puppies
+	AgeYoung  AgeClass = "young"  // 6 months to 2 years
+	AgeAdult  AgeClass = "adult"  // 2 to 8 years
+	AgeSenior AgeClass = "senior" // 8 years and older
+)
+
+// ageClassOf classifies an age in months.
+func ageClassOf(months float64) AgeClass {
+	switch {
+	case months < 6:
+		return AgeBaby
+	case months < 24:
+		return AgeYoung
+	case months < 96:
+		return AgeAdult
+	default:
+		return AgeSenior
+	}
+}
+
+var (
+	// Worded amounts: "полгода", "полтора года", "около года", "пару месяцев".
+	reAgeWords = regexp.MustCompile(`(?i)(?:^|[^\p{L}])((?:около\s+|примерно\s+|где-?то\s+)?(полгода|полугода|полтора\s+(?:года|месяца)|пару\s+(?:лет|месяцев)|несколько\s+(?:лет|месяцев)|(?:около|примерно|где-?то)\s+(?:года|месяца)|годик\p{L}*|месяц\p{L}*\s+от\s+роду))(?:[^\p{L}]|$)`)
+
+	// Life-stage nouns, or an age adjective next to an animal noun ("пожилая
+	// собака", not "пожилая женщина"), when no amount is given.
+	reAgeStage = regexp.MustCompile(`(?i)(?:^|[^\p{L}])(щен(?:ок|ки|ков|ка|ята|яток|очек|очки|уля)|кот[её]н(?:ок|ка|очек|очка)|котят\p{L}*|новорожд[её]нн\p{L}*|подрост(?:ок|ки|ка)|(?:молод|взросл|пожил|старень)\p{L}*\s+(?:п[её]с|собак|собачк|кот|кошк|кобел|сучк|девочк|мальчик)\p{L}*)(?:[^\p{L}]|$)`)
+
+	// Run on the text after an amount: "5 лет назад" says when, not how old.
+	reAgeAgo = regexp.MustCompile(`(?i)^\p{L}*\s+(?:тому\s+)?назад`)
+)
+
+// maxAgeMonths caps amounts taken as ages; larger ones are years ("в 2024
+// году") or other numbers.
+const maxAgeMonths = 360
+
+// Months for worded amounts, keyed by the lowercase phrase without
+// "около"/"примерно".
+var ageWordMonths = map[string][2]float64{
+	"полгода":           {6, 6},
+	"полугода":          {6, 6},
+	"полтора года":      {18, 18},
+	"полтора месяца":    {1.5, 1.5},
+	"пару лет":          {24, 24},
+	"пару месяцев":      {2, 2},
+	"несколько лет":     {24, 60},
+	"несколько месяцев": {2, 5},
+	"около года":        {10, 14},
+	"примерно года":     {10, 14},
+	"около месяца":      {1, 1},
+	"примерно месяца":   {1, 1},
+}
+
+// Ranges for stage words, by lowercase prefix.
+var ageStageMonths = []struct {
+	prefix string
+	r      [2]float64
+}{
+	{"новорожд", [2]float64{0, 1}},
+	{"щен", [2]float64{0, 6}},
+	{"котен", [2]float64{0, 6}},
+	{"котён", [2]float64{0, 6}},
+	{"котят", [2]float64{0, 6}},
+	{"подрост", [2]float64{6, 12}},
+	{"молод", [2]float64{6, 24}},
+	{"взросл", [2]float64{24, 96}},
+	{"пожил", [2]float64{96, 240}},
+	{"старень", [2]float64{96, 240}},
+}
+
+// extractAge returns the age phrase for display plus the range in months and
+// its class. Amounts ("2,5 года", "3-4 мес", "полгода") take precedence over
+// life-stage words ("щенок", "пожилой"); amounts followed by "назад" or above
+// maxAgeMonths are skipped.
+func (sc *scan) extractAge() (string, int, int, AgeClass) {
+	for _, loc := range sc.indexAll(reAge) {
+		if reAgeAgo.MatchString(sc.s[loc[1]:]) {
+			continue
+		}
+		m := reAge.FindStringSubmatch(sc.s[loc[0]:loc[1]])
+		val := strings.ReplaceAll(m[1], ",", ".")
+		unit := m[2]
+		mult := 12.0
+		if strings.HasPrefix(strings.ToLower(unit), "мес") {
+			mult = 1
+		}
+		lo, hi, ok := parseAgeAmount(val)
+		if ok && hi*mult > maxAgeMonths {
+			continue
+		}
+		sc.record("age", "age", loc[0], loc[1])
+		sc.decide("age", "age", 0.8)
+		if !ok {
+			return normalizeSpace(val + " " + unit), 0, 0, ""
+		}
+		return ageResult(normalizeSpace(val+" "+unit), lo*mult, hi*mult)
+	}
+	for _, m := range sc.submatchAll(reAgeWords) {
+		if reAgeAgo.MatchString(sc.s[m[3]:]) {
+			continue
+		}
+		sc.record("age", "age_words", m[2], m[3])
+		sc.decide("age", "age_words", 0.7)
+		phrase := normalizeSpace(sc.s[m[2]:m[3]])
+		key := strings.ToLower(normalizeSpace(sc.s[m[4]:m[5]]))
+		switch {
+		case strings.HasPrefix(key, "годик"):
+			return ageResult(phrase, 12, 12)
+		case strings.HasPrefix(key, "месяц"):
+			return ageResult(phrase, 1, 1)
+		}
+		key = strings.NewReplacer("где-то ", "около ", "гдето ", "около ").Replace(key)
+		r, ok := ageWordMonths[key]
+		if !ok {
+			return phrase, 0, 0, ""
+		}
+		return ageResult(phrase, r[0], r[1])
+	}
+	if m := sc.submatch(reAgeStage); m != nil {
+		sc.record("age", "age_stage", m[2], m[3])
+		sc.decide("age", "age_stage", 0.5)
+		phrase := normalizeSpace(sc.s[m[2]:m[3]])
+		low := strings.ToLower(phrase)
+		for _, st := range ageStageMonths {
+			if strings.HasPrefix(low, st.prefix) {
+				return ageResult(phrase, st.r[0], st.r[1])
+			}
+		}
+	}
+	return "", 0, 0, ""
+}
+
+// parseAgeAmount parses "2.5" or "3-4".
+func parseAgeAmount(v string) (lo, hi float64, ok bool) {
+	a, b, isRange := strings.Cut(v, "-")
+	lo, err := strconv.ParseFloat(strings.TrimSpace(a), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	hi = lo
+	if isRange {
+		if hi, err = strconv.ParseFloat(strings.TrimSpace(b), 64); err != nil || hi < lo {
+			return 0, 0, false
+		}
+	}
+	return lo, hi, true
+}
+
+// ageResult rounds a range to whole months (at least 1 for the upper bound)
+// and classifies it by its midpoint.
+func ageResult(phrase string, lo, hi float64) (string, int, int, AgeClass) {
+	from, to := int(math.Round(lo)), int(math.Round(hi))
+	if to < 1 {
+		to = 1
+	}
+	return phrase, from, to, ageClassOf((lo + hi) / 2)
+}
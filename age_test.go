@@ -0,0 +1,39 @@
+package lostdogs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_AgeRange(t *testing.T) {
+	cases := []struct {
+		text     string
+		age      string
+		min, max int
+		class    AgeClass
+	}{
+		{"Ищет дом кошечка, 2,5 года, стерилизована, лоток на отлично", "2.5 год", 30, 30, AgeAdult},
+		{"Отдадим котят 3-4 мес, к лотку приучены, звоните", "3-4 мес", 3, 4, AgeBaby},
+		{"Малышу около 2х месяцев, лотком пользуется, пишите", "2 мес", 2, 2, AgeBaby},
+		{"Найдена собака, на вид полгода, очень ласковая девочка", "полгода", 6, 6, AgeYoung},
+		{"Пропал пёс, ему около года, рыжий, отзывается на Рекса", "около года", 10, 14, AgeYoung},
+		{"Ищем хозяев, полтора года кобелю, привит и чипирован", "полтора года", 18, 18, AgeYoung},
+		{"Найден щенок у остановки, замерзает, кто заберет", "щенок", 0, 6, AgeBaby},
+		{"Потерялась пожилая собака, плохо видит, помогите найти", "пожилая собака", 96, 240, AgeSenior},
+		{"Пожилая женщина потеряла кошку в районе рынка вчера", "", 0, 0, ""},
+		{"Пропала собака в 2024 году, рыжая, отзывается на Рекса", "", 0, 0, ""},
+		{"Пропал пёс 5 лет назад, до сих пор ищем", "", 0, 0, ""},
+		{"Пару лет назад пропал наш кот, вдруг кто видел", "", 0, 0, ""},
+		{"Пропал 5 лет назад, сейчас ему 7 лет, рыжий", "7 лет", 84, 84, AgeAdult},
+	}
+	for _, tc := range cases {
+		t.Run(tc.text, func(t *testing.T) {
+			p := Parse(1, tc.text)
+			assert.Equal(t, tc.age, p.Age)
+			assert.Equal(t, tc.min, p.AgeMinMonths)
+			assert.Equal(t, tc.max, p.AgeMaxMonths)
+			assert.Equal(t, tc.class, p.AgeClass)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package lostdogs
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Appearance is what the animal looks like, for lost/found matching.
+type Appearance struct {
+	Colors []string   // canonical colors, e.g. "рыжий", "черно-белый"
+	Coat   CoatLength // empty when not mentioned
+	Gear   []string   // what it wears, as written, e.g. "красный ошейник"
+	Marks  []string   // distinctive marks, e.g. "one_eye", "cropped_tail"
+}
+
+type CoatLength string
+
+const (
+	CoatShort    CoatLength = "short"
+	CoatLong     CoatLength = "long"
+	CoatWire     CoatLength = "wire"
+	CoatCurly    CoatLength = "curly"
+	CoatHairless CoatLength = "hairless"
+)
+
+var coatLabels = []string{string(CoatShort), string(CoatLong), string(CoatWire), string(CoatCurly), string(CoatHairless)}
+
+// AppearanceSpec is the optional `appearance` section of a rule set.
+type AppearanceSpec struct {
+	Colors []NamedPatterns `yaml:"colors"`
+	Coat   []NamedPatterns `yaml:"coat"`
+	Gear   []NamedPatterns `yaml:"gear"`
+	Marks  []NamedPatterns `yaml:"marks"`
+}
+
+// NamedPatterns maps any of Patterns to the canonical value Name.
+type NamedPatterns struct {
+	Name     string   `yaml:"name"`
+	Patterns []string `yaml:"patterns"`
+}
+
+type appearanceRules struct {
+	colors, coat, gear, marks []namedRule
+}
+
+type namedRule struct {
+	name string
+	re   *regexp.Regexp // whole words; group 1 is the mention
+}
+
+func (c *compiler) appearance(path string, spec AppearanceSpec) appearanceRules {
+	return appearanceRules{
+		colors: c.named(path+".colors", spec.Colors, nil),
+		coat:   c.named(path+".coat", spec.Coat, coatLabels),
+		gear:   c.named(path+".gear", spec.Gear, nil),
+		marks:  c.named(path+".marks", spec.Marks, nil),
+	}
+}
+
+// named compiles a list of canonical values; allowed restricts the names when
+// not nil.
+func (c *compiler) named(path string, specs []NamedPatterns, allowed []string) []namedRule {
+	var out []namedRule
+	seen := map[string]bool{}
+	for i, ns := range specs {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case ns.Name == "":
+			c.errorf(p+".name", "must be set")
+		case seen[ns.Name]:
+			c.errorf(p+".name", "duplicate name %q", ns.Name)
+		case allowed != nil && !slices.Contains(allowed, ns.Name):
+			c.errorf(p+".name", "unknown value %q (allowed: %s)", ns.Name, strings.Join(allowed, ", "))
+		}
+		seen[ns.Name] = true
+		out = append(out, namedRule{name: ns.Name, re: c.words(p+".patterns", ns.Patterns)})
+	}
+	return out
+}
+
+// reAdjBefore matches up to two adjectives right before a noun, so that
+// "в красном ошейнике" yields "красном ошейнике".
+var reAdjBefore = regexp.MustCompile(`(?i)(?:^|[^\p{L}-])((?:[\p{L}-]+(?:ый|ий|ой|ая|яя|ое|ее|ые|ие|ом|ем|ым|им|ую|юю|ей|ых|их)\s+){1,2})$`)
+
+func (sc *scan) extractAppearance() Appearance {
+	ar := sc.rules.appearance
+	var a Appearance
+
+	// Colors: earlier entries win over later ones on the same words.
+	var taken [][2]int
+	for _, r := range ar.colors {
+		for _, m := range sc.submatchAll(r.re) {
+			if overlaps(taken, m[2], m[3]) {
+				continue
+			}
+			taken = append(taken, [2]int{m[2], m[3]})
+			sc.record("colors", r.name, m[2], m[3])
+			if !slices.Contains(a.Colors, r.name) {
+				a.Colors = append(a.Colors, r.name)
+			}
+		}
+	}
+
+	// Coat: the first mention in the text.
+	first := -1
+	for _, r := range ar.coat {
+		if m := sc.submatch(r.re); m != nil {
+			sc.record("coat", r.name, m[2], m[3])
+			if first < 0 || m[2] < first {
+				first, a.Coat = m[2], CoatLength(r.name)
+			}
+		}
+	}
+
+	// Gear: the phrase with its adjectives, in text order.
+	type phrase struct {
+		at   int
+		text string
+	}
+	var gear []phrase
+	for _, r := range ar.gear {
+		for _, m := range sc.submatchAll(r.re) {
+			start := m[2]
+			if am := reAdjBefore.FindStringSubmatchIndex(sc.s[:start]); am != nil {
+				start = am[2]
+			}
+			if f := strings.Fields(sc.s[:start]); len(f) > 0 && strings.EqualFold(f[len(f)-1], "без") {
+				continue // "без ошейника"
+			}
+			sc.record("gear", r.name, start, m[3])
+			gear = append(gear, phrase{start, strings.ToLower(normalizeSpace(sc.s[start:m[3]]))})
+		}
+	}
+	sort.SliceStable(gear, func(i, j int) bool { return gear[i].at < gear[j].at })
+	for _, g := range gear {
+		if !slices.Contains(a.Gear, g.text) {
+			a.Gear = append(a.Gear, g.text)
+		}
+	}
+
+	// Marks: negated mentions ("не купированный хвост") do not count.
+	for _, r := range ar.marks {
+		if m := sc.firstAffirmed(r.re); m != nil {
+			sc.record("marks", r.name, m[2], m[3])
+			a.Marks = append(a.Marks, r.name)
+		}
+	}
+	return a
+}
+
+func overlaps(spans [][2]int, start, end int) bool {
+	for _, sp := range spans {
+		if start < sp[1] && sp[0] < end {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package lostdogs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_Appearance(t *testing.T) {
+	cases := []struct {
+		text string
+		want Appearance
+	}{
+		{
+			text: "Пропал рыжий пёс в красном ошейнике, хвост бубликом, пушистый",
+			want: Appearance{Colors: []string{"рыжий"}, Coat: CoatLong, Gear: []string{"красном ошейнике"}, Marks: []string{"curled_tail"}},
+		},
+		{
+			text: "Найдена черно-белая кошка без ошейника, нет одного глаза, ушко с надрезом",
+			want: Appearance{Colors: []string{"черно-белый"}, Marks: []string{"one_eye", "ear_notch"}},
+		},
+		{
+			text: "Потерялась трехцветная кошечка в синей шлейке и комбинезоне, короткошерстная",
+			want: Appearance{Colors: []string{"трехцветный"}, Coat: CoatShort, Gear: []string{"синей шлейке", "комбинезоне"}},
+		},
+		{
+			text: "Сбежал пёс черный с подпалом, купированный хвост, прихрамывает на заднюю лапу",
+			want: Appearance{Colors: []string{"черно-подпалый"}, Marks: []string{"cropped_tail"}},
+		},
+		{
+			text: "Найдена собака, не купированный хвост, здорова",
+			want: Appearance{},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.text, func(t *testing.T) {
+			p, ex := ParseExplain(1, tc.text)
+			assert.Equal(t, tc.want, p.Appearance)
+			if len(tc.want.Colors) > 0 {
+				assert.NotEmpty(t, ex.Field("colors").Matches)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package lostdogs
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	yaml "github.com/goccy/go-yaml"
+)
+
+//go:embed resources/breeds/breeds.yml
+var defaultBreedsYAML []byte
+
+// BreedCatalogSpec is the YAML form of a breed catalogue; see
+// resources/breeds/breeds.yml.
+type BreedCatalogSpec struct {
+	Version string      `yaml:"version"`
+	Mixed   []string    `yaml:"mixed"`
+	Breeds  []BreedSpec `yaml:"breeds"`
+}
+
+type BreedSpec struct {
+	ID       string   `yaml:"id"`
+	Animal   string   `yaml:"animal"`
+	Name     string   `yaml:"name"`
+	En       string   `yaml:"en"`
+	Patterns []string `yaml:"patterns"`
+}
+
+// Breed is a catalogue entry.
+type Breed struct {
+	ID     string // stable key, e.g. "labrador_retriever"
+	Animal AnimalType
+	Name   string // canonical Russian name
+	En     string
+}
+
+// BreedCatalog is a compiled list of known breeds. It is immutable and safe
+// for concurrent use.
+type BreedCatalog struct {
+	version string
+	mixed   *regexp.Regexp
+	breeds  []breedEntry
+	byID    map[string]Breed
+	regexps []*regexp.Regexp // for the keyword index
+}
+
+type breedEntry struct {
+	Breed
+	re *regexp.Regexp // whole words; group 1 is the mention
+}
+
+// BreedMixedName is Post.Breed for a mixed-breed animal with no breed named.
+const BreedMixedName = "Метис"
+
+var defaultBreeds = MustLoadBreeds(defaultBreedsYAML)
+
+// DefaultBreeds returns the catalogue embedded from resources/breeds.
+func DefaultBreeds() *BreedCatalog { return defaultBreeds }
+
+// MustLoadBreeds is LoadBreeds that panics on error.
+func MustLoadBreeds(b []byte) *BreedCatalog {
+	c, err := LoadBreeds(b)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+var reBreedID = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// LoadBreeds parses and validates a YAML breed catalogue, reporting all
+// problems at once like LoadRules.
+func LoadBreeds(b []byte) (*BreedCatalog, error) {
+	var spec BreedCatalogSpec
+	if err := yaml.UnmarshalWithOptions(b, &spec, yaml.DisallowUnknownField()); err != nil {
+		return nil, fmt.Errorf("breeds: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	c := &compiler{}
+	if strings.TrimSpace(spec.Version) == "" {
+		c.errorf("version", "must be set")
+	}
+	bc := &BreedCatalog{
+		version: spec.Version + "-" + hex.EncodeToString(sum[:4]),
+		mixed:   c.words("mixed", spec.Mixed),
+		byID:    map[string]Breed{},
+	}
+	animals := []string{string(AnimalCat), string(AnimalDog), string(AnimalOther)}
+	for i, bs := range spec.Breeds {
+		p := fmt.Sprintf("breeds[%d]", i)
+		switch _, dup := bc.byID[bs.ID]; {
+		case !reBreedID.MatchString(bs.ID):
+			c.errorf(p+".id", "must be snake_case, got %q", bs.ID)
+		case dup:
+			c.errorf(p+".id", "duplicate id %q", bs.ID)
+		}
+		if !slices.Contains(animals, bs.Animal) {
+			c.errorf(p+".animal", "unknown animal %q (allowed: %s)", bs.Animal, strings.Join(animals, ", "))
+		}
+		if bs.Name == "" {
+			c.errorf(p+".name", "must be set")
+		}
+		br := Breed{ID: bs.ID, Animal: AnimalType(bs.Animal), Name: bs.Name, En: bs.En}
+		bc.byID[bs.ID] = br
+		bc.breeds = append(bc.breeds, breedEntry{Breed: br, re: c.words(p+".patterns", bs.Patterns)})
+	}
+	if len(c.errs) > 0 {
+		return nil, fmt.Errorf("breeds: %w", errors.Join(c.errs...))
+	}
+	bc.regexps = c.regexps
+	return bc, nil
+}
+
+// Version identifies the catalogue contents.
+func (bc *BreedCatalog) Version() string { return bc.version }
+
+// Lookup returns the breed with the given ID.
+func (bc *BreedCatalog) Lookup(id string) (Breed, bool) {
+	b, ok := bc.byID[id]
+	return b, ok
+}
+
+// Breeds lists the catalogue in file order.
+func (bc *BreedCatalog) Breeds() []Breed {
+	out := make([]Breed, len(bc.breeds))
+	for i, e := range bc.breeds {
+		out[i] = e.Breed
+	}
+	return out
+}
+
+// resolveBreed records every catalogue hit and returns the breed the post is
+// about: the earliest mention of a breed of animal (any animal when unknown),
+// the longest one among mentions starting at the same place. mixed reports a
+// mixed-breed marker; the named breed, if any, is then the dominant one.
+func (sc *scan) resolveBreed(bc *BreedCatalog, animal AnimalType) (b Breed, found, mixed bool) {
+	if m := sc.submatch(bc.mixed); m != nil {
+		sc.record("breed_mixed", "mixed", m[2], m[3])
+		mixed = true
+	}
+	known := animal == AnimalCat || animal == AnimalDog
+	bestStart, bestLen, bestFits := 0, 0, false
+	for i := range bc.breeds {
+		e := &bc.breeds[i]
+		m := sc.submatch(e.re)
+		if m == nil {
+			continue
+		}
+		sc.record("breed", e.ID, m[2], m[3])
+		fits := !known || e.Animal == animal
+		start, n := m[2], m[3]-m[2]
+		better := !found ||
+			(fits && !bestFits) ||
+			(fits == bestFits && (start < bestStart || (start == bestStart && n > bestLen)))
+		if better {
+			b, found, bestStart, bestLen, bestFits = e.Breed, true, start, n, fits
+		}
+	}
+	switch {
+	case found && bestFits:
+		sc.decide("breed", b.ID, 0.8)
+	case found:
+		sc.decide("breed", b.ID, 0.5) // a dog breed in a post about a cat
+	case mixed:
+		sc.decide("breed", "mixed", 0.7)
+	}
+	return b, found, mixed
+}
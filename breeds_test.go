@@ -0,0 +1,70 @@
+package lostdogs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveBreed(t *testing.T) {
+	cases := []struct {
+		text   string
+		id     string
+		name   string
+		mixed  bool
+		animal AnimalType
+	}{
+		{"Пропал йорик, девочка, откликается на Бусю", "yorkshire_terrier", "Йоркширский терьер", false, AnimalDog},
+		{"Найдена собака, метис лабрадора, очень ласковая", "labrador_retriever", "Лабрадор-ретривер", true, AnimalDog},
+		{"Потерялся британец, серый, в синем ошейнике", "british_shorthair", "Британская короткошёрстная", false, AnimalCat},
+		{"Пропала немецкая овчарка, кобель 3 года", "german_shepherd", "Немецкая овчарка", false, AnimalDog},
+		{"Убежал хаски, глаза голубые, помогите найти", "husky", "Сибирский хаски", false, AnimalDog},
+		{"Пропала кошка, окрас как у хаски, британка", "british_shorthair", "Британская короткошёрстная", false, AnimalCat},
+		{"Нашли беспородного пса возле остановки, ищем хозяев", "", BreedMixedName, true, AnimalDog},
+		{"Пропал кот, ставьте лайки и делайте репосты, сели в такси", "", "", false, AnimalCat},
+	}
+	for _, tc := range cases {
+		t.Run(tc.text, func(t *testing.T) {
+			p, ex := ParseExplain(1, tc.text)
+			assert.Equal(t, tc.id, p.BreedID)
+			assert.Equal(t, tc.name, p.Breed)
+			assert.Equal(t, tc.mixed, p.BreedMixed)
+			assert.Equal(t, tc.animal, p.Animal)
+			if tc.id != "" {
+				f := ex.Field("breed")
+				require.NotNil(t, f)
+				assert.Equal(t, tc.id, f.Rule)
+			}
+		})
+	}
+}
+
+func TestDefaultBreeds(t *testing.T) {
+	b, ok := DefaultBreeds().Lookup("labrador_retriever")
+	require.True(t, ok)
+	assert.Equal(t, AnimalDog, b.Animal)
+	assert.Equal(t, "Labrador Retriever", b.En)
+	assert.NotEmpty(t, DefaultBreeds().Version())
+}
+
+func TestLoadBreeds_Validation(t *testing.T) {
+	_, err := LoadBreeds([]byte(`
+version: "1"
+mixed: ['метис']
+breeds:
+  - {id: Lab, animal: dog, name: Лабрадор, patterns: ['лабрадор']}
+  - {id: pug, animal: horse, name: Мопс, patterns: ['мопс(']}
+  - {id: pug, animal: dog, patterns: ['мопс']}
+`))
+	require.Error(t, err)
+	for _, want := range []string{
+		`breeds[0].id: must be snake_case, got "Lab"`,
+		`breeds[1].animal: unknown animal "horse"`,
+		`breeds[1].patterns[0]:`,
+		`breeds[2].id: duplicate id "pug"`,
+		`breeds[2].name: must be set`,
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
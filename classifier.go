@@ -0,0 +1,63 @@
+package lostdogs
+
+import "slices"
+
+// Classifier is a statistical model consulted when the rules are unsure about
+// a post's type or animal. internal/nbayes provides one trained from the gold
+// labels.
+type Classifier interface {
+	// Classify returns the most likely label for field ("type" or "animal")
+	// and its probability. ok is false when the model has no opinion on field.
+	Classify(field, text string) (label string, prob float64, ok bool)
+	// Version identifies the trained model; it becomes part of Parser.Version.
+	Version() string
+}
+
+// Source tells which stage decided an enum field.
+type Source string
+
+const (
+	SourceRules Source = "rules"
+	SourceModel Source = "model"
+)
+
+const (
+	// Rule decisions below this confidence (weak-only votes, tie fallbacks)
+	// are open to the model.
+	modelMaxRuleConfidence = 0.6
+	// The model must be at least this sure to replace or fill in a value.
+	modelMinProbability = 0.8
+)
+
+// WithClassifier returns a copy of the parser that falls back to c when the
+// rules leave type or animal unknown or decide them with low confidence.
+func (ps *Parser) WithClassifier(c Classifier) *Parser {
+	cp := *ps
+	cp.model = c
+	return &cp
+}
+
+// Classifier returns the fallback model, or nil.
+func (ps *Parser) Classifier() Classifier { return ps.model }
+
+// fallback asks the model to confirm or replace a rule decision for field.
+// label is the rule result ("" when no rule fired); allowed are the values
+// the model may produce. It returns the final label and its source.
+func (sc *scan) fallback(field, label string, allowed []string) (string, Source) {
+	src := SourceRules
+	if label == "" {
+		src = ""
+	}
+	if sc.model == nil {
+		return label, src
+	}
+	if label != "" && sc.verdicts[field].conf >= modelMaxRuleConfidence {
+		return label, src
+	}
+	pred, prob, ok := sc.model.Classify(field, sc.s)
+	if !ok || prob < modelMinProbability || !slices.Contains(allowed, pred) {
+		return label, src
+	}
+	sc.decide(field, "model", prob)
+	return pred, SourceModel
+}
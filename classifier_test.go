@@ -0,0 +1,55 @@
+package lostdogs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubClassifier map[string]struct {
+	label string
+	prob  float64
+}
+
+func (c stubClassifier) Classify(field, _ string) (string, float64, bool) {
+	v, ok := c[field]
+	return v.label, v.prob, ok
+}
+
+func (stubClassifier) Version() string { return "stub" }
+
+func TestParserWithClassifier(t *testing.T) {
+	model := stubClassifier{
+		"type":   {"lost", 0.95},
+		"animal": {"cat", 0.7},
+	}
+	ps := DefaultParser().WithClassifier(model)
+	assert.Contains(t, ps.Version(), "+model-stub")
+	assert.Nil(t, DefaultParser().Classifier(), "WithClassifier must not modify the receiver")
+
+	// Rules find neither type nor animal: the model fills in type, but is
+	// not sure enough about the animal.
+	p, ex := ps.ParseExplain(1, "Ребята, помогите с Барсиком, он у третьего подъезда третий день")
+	assert.Equal(t, TypeLost, p.Type)
+	assert.Equal(t, SourceModel, p.TypeSource)
+	assert.Equal(t, AnimalUnknown, p.Animal)
+	assert.Empty(t, p.AnimalSource)
+	f := ex.Field("type")
+	require.NotNil(t, f)
+	assert.Equal(t, "model", f.Rule)
+	assert.InDelta(t, 0.95, f.Confidence, 1e-9)
+
+	// A confident rule decision is kept.
+	p = ps.Parse(2, "Найдена собака в районе рынка, ищем хозяев")
+	assert.Equal(t, TypeFound, p.Type)
+	assert.Equal(t, SourceRules, p.TypeSource)
+	assert.Equal(t, AnimalDog, p.Animal)
+	assert.Equal(t, SourceRules, p.AnimalSource)
+
+	// Labels outside the field's vocabulary are ignored.
+	p = DefaultParser().WithClassifier(stubClassifier{"type": {"unknown", 0.99}}).
+		Parse(3, "Ребята, помогите с Барсиком, он у третьего подъезда третий день")
+	assert.Equal(t, TypeUnknown, p.Type)
+	assert.Empty(t, p.TypeSource)
+}
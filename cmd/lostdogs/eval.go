@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/jehaby/lostdogs"
+	sqldb "github.com/jehaby/lostdogs/internal/db"
+	"github.com/jehaby/lostdogs/internal/eval"
+)
+
+// runEval implements `lostdogs eval`: run the parser over every gold-labeled
+// post and print per-field precision/recall and confusion matrices.
+func runEval(args []string) error {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	var (
+		rules  = fs.String("rules", "", "rules file to evaluate (default: RULES_FILE or the embedded rules)")
+		model  = fs.String("model", "", "fallback model file (default: MODEL_FILE; \"-\" disables it)")
+		misses = fs.Bool("errors", false, "list every mislabeled post")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := dbConfig{}
+	if err := env.Parse(&cfg); err != nil {
+		return fmt.Errorf("parse config: %w", err)
+	}
+	initLogger(cfg.LogLevel)
+	if *rules != "" {
+		cfg.RulesFile = *rules
+	}
+	switch *model {
+	case "":
+	case "-":
+		cfg.ModelFile = ""
+	default:
+		cfg.ModelFile = *model
+	}
+	parser, err := loadParser(cfg)
+	if err != nil {
+		return fmt.Errorf("load parser: %w", err)
+	}
+	svc := newDBService(cfg)
+	defer svc.db.Close()
+
+	labels, err := svc.queries.ListLabels(context.Background())
+	if err != nil {
+		return fmt.Errorf("list labels: %w", err)
+	}
+	if len(labels) == 0 {
+		return fmt.Errorf("no gold labels yet; run `lostdogs label` first")
+	}
+	rep := evaluate(parser, labels)
+	fmt.Printf("parser %s, %d labeled posts\n", parser.Version(), len(labels))
+	rep.Fprint(os.Stdout, *misses)
+	return nil
+}
+
+type evalReport struct {
+	fields   map[string]*eval.Confusion
+	mistakes []evalMistake
+}
+
+type evalMistake struct {
+	OwnerID, PostID int64
+	Field           string
+	Gold, Pred      string
+}
+
+// evaluate compares parser output with gold labels; unlabeled (NULL) fields
+// of a label row are ignored.
+func evaluate(parser *lostdogs.Parser, labels []sqldb.ListLabelsRow) *evalReport {
+	rep := &evalReport{fields: map[string]*eval.Confusion{}}
+	for _, f := range labelFields {
+		rep.fields[f] = eval.NewConfusion()
+	}
+	for _, l := range labels {
+		p := parser.Parse(int(l.PostID), l.Raw)
+		pred := labelValues(p)
+		gold := map[string]*string{
+			"type": l.Type, "animal": l.Animal, "sex": l.Sex,
+			"age_class": l.AgeClass, "size": l.Size, "urgency": l.Urgency, "role": l.Role,
+		}
+		for _, f := range labelFields {
+			if gold[f] == nil {
+				continue
+			}
+			rep.fields[f].Add(*gold[f], pred[f])
+			if *gold[f] != pred[f] {
+				rep.mistakes = append(rep.mistakes, evalMistake{OwnerID: l.OwnerID, PostID: l.PostID, Field: f, Gold: *gold[f], Pred: pred[f]})
+			}
+		}
+	}
+	return rep
+}
+
+func (r *evalReport) Fprint(w io.Writer, mistakes bool) {
+	for _, f := range labelFields {
+		c := r.fields[f]
+		if c.Total() == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n== %s\n", f)
+		c.Fprint(w)
+	}
+	if !mistakes {
+		return
+	}
+	fmt.Fprintf(w, "\n== errors (%d)\n", len(r.mistakes))
+	for _, m := range r.mistakes {
+		fmt.Fprintf(w, "%d_%d %-9s gold=%-12s pred=%s\n", m.OwnerID, m.PostID, m.Field, m.Gold, m.Pred)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/jehaby/lostdogs"
+	sqldb "github.com/jehaby/lostdogs/internal/db"
+)
+
+// runExplain implements `lostdogs explain`: show which rules produced the
+// fields of a stored post (or of an ad-hoc -text) so misclassifications can
+// be debugged without reading the regexes.
+func runExplain(args []string) error {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	var (
+		ownerID = fs.Int64("owner-id", 0, "VK owner id of the stored post")
+		postID  = fs.Int64("post-id", 0, "VK post id of the stored post")
+		text    = fs.String("text", "", "explain this text instead of a stored post")
+		fresh   = fs.Bool("fresh", false, "re-run the current parser on the stored raw text instead of showing the stored explanation")
+		rules   = fs.String("rules", "", "rules file to parse with (default: RULES_FILE or the embedded rules)")
+		model   = fs.String("model", "", "fallback model file (default: MODEL_FILE; \"-\" disables it)")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := dbConfig{}
+	if err := env.Parse(&cfg); err != nil {
+		return fmt.Errorf("parse config: %w", err)
+	}
+	if *rules != "" {
+		cfg.RulesFile = *rules
+	}
+	switch *model {
+	case "":
+	case "-":
+		cfg.ModelFile = ""
+	default:
+		cfg.ModelFile = *model
+	}
+	parser, err := loadParser(cfg)
+	if err != nil {
+		return fmt.Errorf("load parser: %w", err)
+	}
+
+	if *text != "" {
+		_, ex := parser.ParseExplainAt(0, *text, time.Now())
+		printExplanation(os.Stdout, ex)
+		return nil
+	}
+	if *postID == 0 {
+		return errors.New("either -text or -owner-id/-post-id is required")
+	}
+
+	initLogger(cfg.LogLevel)
+	svc := newDBService(cfg)
+	defer svc.db.Close()
+
+	row, err := svc.queries.GetPostExplain(context.Background(), sqldb.GetPostExplainParams{OwnerID: *ownerID, PostID: *postID})
+	if err != nil {
+		return fmt.Errorf("load post %d_%d: %w", *ownerID, *postID, err)
+	}
+	if *fresh || row.Explain == nil {
+		if !*fresh {
+			fmt.Println("no stored explanation; showing current parser output")
+		}
+		_, ex := parser.ParseExplainAt(int(*postID), row.Raw, time.Unix(row.Date, 0))
+		printExplanation(os.Stdout, ex)
+		return nil
+	}
+	var ex lostdogs.Explanation
+	if err := json.Unmarshal([]byte(*row.Explain), &ex); err != nil {
+		return fmt.Errorf("decode stored explanation: %w", err)
+	}
+	fmt.Printf("stored by parser version %s\n", strVal(row.ParserVersion))
+	printExplanation(os.Stdout, ex)
+	return nil
+}
+
+func printExplanation(w io.Writer, ex lostdogs.Explanation) {
+	fmt.Fprintf(w, "text: %s\n", ex.Text)
+	fmt.Fprintf(w, "confidence: %.2f\n\n", ex.Confidence)
+	for _, f := range ex.Fields {
+		if f.Rule == "" && len(f.Matches) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%-14s = %-12q rule=%s conf=%.2f\n", f.Field, f.Value, f.Rule, f.Confidence)
+		for _, m := range f.Matches {
+			fmt.Fprintf(w, "    %-22s [%d:%d] %s\n", m.Rule, m.Start, m.End, snippet(ex.Text, m, 20))
+		}
+	}
+}
+
+// snippet shows the matched text with up to pad runes of context on each side.
+func snippet(text string, m lostdogs.Match, pad int) string {
+	r := []rune(text)
+	if m.Start < 0 || m.End > len(r) || m.Start > m.End {
+		return fmt.Sprintf("%q", m.Text)
+	}
+	from, to := max(m.Start-pad, 0), min(m.End+pad, len(r))
+	out := string(r[from:m.Start]) + "«" + string(r[m.Start:m.End]) + "»" + string(r[m.End:to])
+	if from > 0 {
+		out = "…" + out
+	}
+	if to < len(r) {
+		out += "…"
+	}
+	return out
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/jehaby/lostdogs"
+	sqldb "github.com/jehaby/lostdogs/internal/db"
+)
+
+// fixturePost is one post of a JSON file produced by cmd/dump-wall.
+type fixturePost struct {
+	OwnerID int    `json:"owner_id"`
+	ID      int    `json:"id"`
+	Date    int    `json:"date"`
+	Text    string `json:"text"`
+}
+
+func loadFixture(path string) ([]fixturePost, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fps []fixturePost
+	if err := json.Unmarshal(b, &fps); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return fps, nil
+}
+
+// labelFields are the labeled fields, in prompt order.
+var labelFields = []string{"type", "animal", "sex", "age_class", "size", "urgency", "role"}
+
+// Values a gold label may take, in prompt order.
+var labelOptions = map[string][]string{
+	"type":      {"unknown", "lost", "found", "sighting", "adoption", "fundraising", "news", "link", "empty"},
+	"animal":    {"unknown", "cat", "dog", "other"},
+	"sex":       {"unknown", "m", "f"},
+	"age_class": {"unknown", "baby", "young", "adult", "senior"},
+	"size":      {"unknown", "small", "medium", "large", "giant"},
+	"urgency":   {"unknown", "normal", "high", "critical"},
+	"role":      {"unknown", "owner", "finder", "volunteer", "shelter"},
+}
+
+// labelValues returns the parser's answer for each labeled field.
+func labelValues(p lostdogs.Post) map[string]string {
+	return map[string]string{
+		"type":      enumOr(string(p.Type)),
+		"animal":    enumOr(string(p.Animal)),
+		"sex":       enumOr(string(p.Sex)),
+		"age_class": enumOr(string(p.AgeClass)),
+		"size":      enumOr(string(p.Size.Class)),
+		"urgency":   enumOr(string(p.Urgency)),
+		"role":      enumOr(string(p.Role)),
+	}
+}
+
+// labelColumns points at the label row's column of each labeled field.
+func labelColumns(c *sqldb.UpsertLabelParams) map[string]**string {
+	return map[string]**string{
+		"type": &c.Type, "animal": &c.Animal, "sex": &c.Sex,
+		"age_class": &c.AgeClass, "size": &c.Size, "urgency": &c.Urgency, "role": &c.Role,
+	}
+}
+
+// runLabel implements `lostdogs label`: walk unlabeled posts from the DB (or a
+// dump-wall fixture file) and ask for the correct value of each of
+// labelFields, offering the parser's answer as the default.
+func runLabel(args []string) error {
+	fs := flag.NewFlagSet("label", flag.ContinueOnError)
+	var (
+		fixture = fs.String("fixture", "", "label posts from this dump-wall JSON file instead of the DB")
+		limit   = fs.Int("limit", 50, "max posts to show")
+		ptype   = fs.String("type", "", "only DB posts currently stored with this type")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := dbConfig{}
+	if err := env.Parse(&cfg); err != nil {
+		return fmt.Errorf("parse config: %w", err)
+	}
+	initLogger(cfg.LogLevel)
+	parser, err := loadParser(cfg)
+	if err != nil {
+		return fmt.Errorf("load parser: %w", err)
+	}
+	svc := newDBService(cfg)
+	defer svc.db.Close()
+	ctx := context.Background()
+
+	var cands []sqldb.UpsertLabelParams
+	if *fixture != "" {
+		fps, err := loadFixture(*fixture)
+		if err != nil {
+			return err
+		}
+		for _, fp := range fps {
+			n, err := svc.queries.ExistsLabel(ctx, sqldb.ExistsLabelParams{OwnerID: int64(fp.OwnerID), PostID: int64(fp.ID)})
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				continue
+			}
+			cands = append(cands, sqldb.UpsertLabelParams{OwnerID: int64(fp.OwnerID), PostID: int64(fp.ID), Raw: fp.Text, Source: filepath.Base(*fixture)})
+			if len(cands) == *limit {
+				break
+			}
+		}
+	} else {
+		rows, err := svc.queries.ListUnlabeledPosts(ctx, sqldb.ListUnlabeledPostsParams{Type: strPtr(*ptype), Limit: int64(*limit)})
+		if err != nil {
+			return err
+		}
+		for _, r := range rows {
+			cands = append(cands, sqldb.UpsertLabelParams{OwnerID: r.OwnerID, PostID: r.PostID, Raw: r.Raw, Source: "db"})
+		}
+	}
+	if len(cands) == 0 {
+		fmt.Println("nothing to label")
+		return nil
+	}
+
+	l := &labeler{in: bufio.NewReader(os.Stdin), out: os.Stdout, parser: parser}
+	saved := 0
+	for i, c := range cands {
+		fmt.Fprintf(l.out, "\n--- [%d/%d] %d_%d (%s)\n", i+1, len(cands), c.OwnerID, c.PostID, c.Source)
+		lbl, err := l.ask(c)
+		if errors.Is(err, errSkip) {
+			continue
+		}
+		if errors.Is(err, errQuit) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := svc.queries.UpsertLabel(ctx, lbl); err != nil {
+			return fmt.Errorf("save label %d_%d: %w", c.OwnerID, c.PostID, err)
+		}
+		saved++
+	}
+	fmt.Printf("saved %d labels\n", saved)
+	return nil
+}
+
+var (
+	errSkip = errors.New("skip post")
+	errQuit = errors.New("quit labeling")
+)
+
+// labeler runs the prompt loop. Empty input accepts the parser's value, any
+// unique prefix selects an option, "!s" skips the post and "!q" quits.
+type labeler struct {
+	in     *bufio.Reader
+	out    io.Writer
+	parser *lostdogs.Parser
+}
+
+func (l *labeler) ask(c sqldb.UpsertLabelParams) (sqldb.UpsertLabelParams, error) {
+	p := l.parser.Parse(int(c.PostID), c.Raw)
+	def := labelValues(p)
+	fmt.Fprintln(l.out, truncateRunes(normalize(c.Raw), 1500))
+	fmt.Fprint(l.out, "parser:")
+	for _, f := range labelFields {
+		fmt.Fprintf(l.out, " %s=%s", f, def[f])
+	}
+	fmt.Fprintln(l.out, "   (enter=accept, !s=skip, !q=quit)")
+
+	cols := labelColumns(&c)
+	for _, f := range labelFields {
+		v, err := l.choose(f, def[f])
+		if err != nil {
+			return c, err
+		}
+		*cols[f] = &v
+	}
+	fmt.Fprint(l.out, "note []: ")
+	note, err := l.readLine()
+	if err != nil {
+		return c, err
+	}
+	c.Note = strPtr(note)
+	return c, nil
+}
+
+func (l *labeler) choose(field, def string) (string, error) {
+	opts := labelOptions[field]
+	for {
+		fmt.Fprintf(l.out, "%s [%s] (%s): ", field, def, strings.Join(opts, "/"))
+		in, err := l.readLine()
+		if err != nil {
+			return "", err
+		}
+		in = strings.ToLower(in)
+		if in == "" {
+			return def, nil
+		}
+		var hits []string
+		for _, o := range opts {
+			if o == in {
+				return o, nil
+			}
+			if strings.HasPrefix(o, in) {
+				hits = append(hits, o)
+			}
+		}
+		if len(hits) == 1 {
+			return hits[0], nil
+		}
+		fmt.Fprintf(l.out, "  %q matches %d options, try again\n", in, len(hits))
+	}
+}
+
+// readLine returns the trimmed next line; "!s"/"!q" and EOF map to errSkip/errQuit.
+func (l *labeler) readLine() (string, error) {
+	line, err := l.in.ReadString('\n')
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		if line == "" {
+			return "", errQuit
+		}
+	}
+	line = strings.TrimSpace(line)
+	switch strings.ToLower(line) {
+	case "!s", "!skip":
+		return "", errSkip
+	case "!q", "!quit":
+		return "", errQuit
+	}
+	return line, nil
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jehaby/lostdogs"
+	sqldb "github.com/jehaby/lostdogs/internal/db"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabeler_Ask(t *testing.T) {
+	// accept the parsed type, "C" selects cat, "x" matches nothing so sex is asked
+	// again; accept age class, size and urgency, "v" selects volunteer
+	in := "\nC\nx\nf\n\n\n\nv\nРыжий, ТЦ Италмас\n"
+	var out strings.Builder
+	l := &labeler{in: bufio.NewReader(strings.NewReader(in)), out: &out, parser: lostdogs.DefaultParser()}
+
+	got, err := l.ask(sqldb.UpsertLabelParams{OwnerID: -1, PostID: 7, Raw: "Пропала собака, рыжая, район Буммаш", Source: "db"})
+	require.NoError(t, err)
+	assert.Equal(t, "lost", *got.Type)
+	assert.Equal(t, "cat", *got.Animal)
+	assert.Equal(t, "f", *got.Sex)
+	assert.Equal(t, "unknown", *got.AgeClass)
+	assert.Equal(t, "normal", *got.Urgency)
+	assert.Equal(t, "volunteer", *got.Role)
+	assert.Equal(t, "Рыжий, ТЦ Италмас", *got.Note, "notes keep their case")
+	assert.Contains(t, out.String(), "parser: type=lost animal=dog")
+	assert.Contains(t, out.String(), `"x" matches 0 options`)
+
+	l.in = bufio.NewReader(strings.NewReader("!s\n"))
+	_, err = l.ask(sqldb.UpsertLabelParams{Raw: "Пропала собака"})
+	require.ErrorIs(t, err, errSkip)
+
+	l.in = bufio.NewReader(strings.NewReader("lost\n"))
+	_, err = l.ask(sqldb.UpsertLabelParams{Raw: "Пропала собака"})
+	require.ErrorIs(t, err, errQuit, "EOF ends the session")
+}
+
+func TestEvaluate_FromStoredLabels(t *testing.T) {
+	t.Parallel()
+
+	q := newTestService(t, "memdb_eval").queries
+	ctx := context.Background()
+
+	s := func(v string) *string { return &v }
+	for _, l := range []sqldb.UpsertLabelParams{
+		{OwnerID: -1, PostID: 1, Raw: "Пропала кошка на улице Ленина", Source: "db", Type: s("lost"), Animal: s("cat")},
+		{OwnerID: -1, PostID: 2, Raw: "Найден кот во дворе на Пушкинской", Source: "db", Type: s("found"), Animal: s("cat"), Sex: s("m")},
+		{OwnerID: -1, PostID: 3, Raw: "Потерялась рыжая собака? Она у нас, рыжий пёс", Source: "fixture.json", Type: s("found"), Animal: s("dog"), Role: s("finder")},
+	} {
+		require.NoError(t, q.UpsertLabel(ctx, l))
+	}
+	labels, err := q.ListLabels(ctx)
+	require.NoError(t, err)
+	require.Len(t, labels, 3)
+
+	rep := evaluate(lostdogs.DefaultParser(), labels)
+	typ := rep.fields["type"]
+	assert.Equal(t, 3, typ.Total())
+	assert.Equal(t, 1, typ.Count("found", "lost"))
+	assert.InDelta(t, 0.5, typ.Recall("found"), 1e-9)
+	assert.Equal(t, 3, rep.fields["animal"].Total())
+	assert.Equal(t, 1, rep.fields["sex"].Total(), "NULL labels are not scored")
+	assert.Equal(t, 1, rep.fields["role"].Total())
+	assert.Equal(t, 1, rep.fields["role"].Count("finder", "owner"))
+
+	var out strings.Builder
+	rep.Fprint(&out, true)
+	assert.Contains(t, out.String(), "== type")
+	assert.Contains(t, out.String(), "-1_3 type      gold=found        pred=lost")
+	assert.Contains(t, out.String(), "-1_3 role      gold=finder       pred=owner")
+}
+
+func TestTrainModel(t *testing.T) {
+	s := func(v string) *string { return &v }
+	labels := []sqldb.ListLabelsRow{
+		{PostID: 1, Raw: "Потерялась наша Муся, ушла из дома вечером", Type: s("lost"), Animal: s("cat")},
+		{PostID: 2, Raw: "Ушёл из дома и не вернулся Барсик, помогите", Type: s("lost"), Animal: s("cat")},
+		{PostID: 3, Raw: "Прибился пёс к остановке, ищем хозяина", Type: s("found"), Animal: s("dog")},
+		{PostID: 4, Raw: "Прибилась собачка у магазина, хозяева отзовитесь", Type: s("found"), Animal: s("dog")},
+		{PostID: 5, Raw: "vk.com/wall-1_2", Type: s("link")},
+		{PostID: 6, Raw: "Просто текст без разметки типа", Sex: s("f")},
+	}
+	m := trainModel(labels, 2, 4)
+	require.Contains(t, m.Fields, "type")
+	assert.Equal(t, []string{"found", "lost"}, m.Fields["type"].Labels(), "link posts are not trained on")
+	assert.Equal(t, 4, docCount(m.Fields["animal"]))
+
+	label, _, ok := m.Classify("type", "прибилась кошка к подъезду")
+	require.True(t, ok)
+	assert.Equal(t, "found", label)
+}
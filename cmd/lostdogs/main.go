@@ -10,6 +10,7 @@ import (
 
 	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	vkapi "github.com/SevereCloud/vksdk/v3/api"
@@ -18,7 +19,6 @@ import (
 	yaml "github.com/goccy/go-yaml"
 	"github.com/jehaby/lostdogs"
 	sqldb "github.com/jehaby/lostdogs/internal/db"
-	itypes "github.com/jehaby/lostdogs/internal/types"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/pressly/goose/v3"
 )
@@ -29,14 +29,30 @@ type Group struct {
 	LastTS     int64
 }
 
+// dbConfig is the subset of configuration needed by commands that only work
+// with the local database (see subcommands).
+type dbConfig struct {
+	LogLevel     slog.Level `env:"LOG_LEVEL" envDefault:"info"`
+	DBConnString string     `env:"DB_CONN_STRING" envDefault:"file:./resources/db/lostdogs.db?cache=shared&mode=rwc"`
+	// Parser rules; empty means the embedded resources/rules/default.yml
+	RulesFile           string        `env:"RULES_FILE"`
+	RulesReloadInterval time.Duration `env:"RULES_RELOAD_INTERVAL" envDefault:"30s"`
+	// Optional naive Bayes model (see `lostdogs train`) consulted when the
+	// rules are unsure about type or animal.
+	ModelFile string `env:"MODEL_FILE"`
+	// Animals broadcast to each destination: "dog", "cat", "other" or a
+	// species from the rules ("parrot", "rabbit").
+	TGAnimals    []string `env:"TG_ANIMALS" envDefault:"dog" envSeparator:","`
+	VKOutAnimals []string `env:"VK_OUT_ANIMALS" envDefault:"dog" envSeparator:","`
+}
+
 type config struct {
-	VKToken           string     `env:"VK_TOKEN,required"`
-	LogLevel          slog.Level `env:"LOG_LEVEL" envDefault:"info"`
-	TGBotDebugEnabled bool       `env:"TGBOT_DEBUG_ENABLED" envDefault:"false"`
-	DBConnString      string     `env:"DB_CONN_STRING" envDefault:"file:./resources/db/lostdogs.db?cache=shared&mode=rwc"`
-	TGEnabled         bool       `env:"TG_ENABLED" envDefault:"false"`
-	TGToken           string     `env:"TG_TOKEN"`
-	TGChat            int64      `env:"TG_CHAT"`
+	dbConfig
+	VKToken           string `env:"VK_TOKEN,required"`
+	TGBotDebugEnabled bool   `env:"TGBOT_DEBUG_ENABLED" envDefault:"false"`
+	TGEnabled         bool   `env:"TG_ENABLED" envDefault:"false"`
+	TGToken           string `env:"TG_TOKEN"`
+	TGChat            int64  `env:"TG_CHAT"`
 	// VK outbound (reposting) configuration
 	VKOutEnabled     bool          `env:"VK_OUT_ENABLED" envDefault:"false"`
 	VKOutToken       string        `env:"VK_OUT_TOKEN"`
@@ -50,10 +66,33 @@ type service struct {
 	db      *sql.DB
 	queries *sqldb.Queries
 	vk      *vkapi.VK
+	parser  atomic.Pointer[lostdogs.Parser] // swapped on rules reload
+	routes  routes
+}
+
+// currentParser returns the active parser, defaulting to the embedded rules.
+func (s *service) currentParser() *lostdogs.Parser {
+	if p := s.parser.Load(); p != nil {
+		return p
+	}
+	return lostdogs.DefaultParser()
 }
 
 func newService(cfg config) *service {
-	svc := &service{}
+	svc := newDBService(cfg.dbConfig)
+
+	// Initialize VK client
+	vk := vkapi.NewVK(cfg.VKToken)
+	client := &http.Client{Timeout: 10 * time.Second}
+	vk.Client = client
+	svc.vk = vk
+	slog.Info("VK client initialized", "timeout", client.Timeout)
+	return svc
+}
+
+// newDBService opens SQLite and applies migrations; the VK client is left nil.
+func newDBService(cfg dbConfig) *service {
+	svc := &service{routes: routes{tg: cfg.TGAnimals, vk: cfg.VKOutAnimals}}
 	// Open standard database/sql connection using sqlite3 driver
 	var err error
 	svc.db, err = sql.Open("sqlite3", cfg.DBConnString)
@@ -72,17 +111,31 @@ func newService(cfg config) *service {
 	}
 
 	svc.queries = sqldb.New(svc.db)
-
-	// Initialize VK client
-	vk := vkapi.NewVK(cfg.VKToken)
-	client := &http.Client{Timeout: 10 * time.Second}
-	vk.Client = client
-	svc.vk = vk
-	slog.Info("VK client initialized", "timeout", client.Timeout)
 	return svc
 }
 
+// subcommands are offline maintenance commands: `lostdogs <name> [flags]`.
+// Without a subcommand the binary runs the VK polling loop.
+var subcommands = map[string]func(args []string) error{
+	"reparse": runReparse,
+	"explain": runExplain,
+	"label":   runLabel,
+	"eval":    runEval,
+	"train":   runTrain,
+	"matches": runMatches,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				slog.Error(os.Args[1]+" failed", "err", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	// Parse config from environment
 	cfg := config{}
 	if err := env.Parse(&cfg); err != nil {
@@ -93,6 +146,11 @@ func main() {
 
 	svc := newService(cfg)
 
+	if err := rulesStart(svc, cfg.dbConfig); err != nil {
+		slog.Error("loading parser rules failed", "file", cfg.RulesFile, "err", err)
+		os.Exit(1)
+	}
+
 	// Optionally start Telegram worker
 	if cfg.TGEnabled {
 		slog.Info("starting tg worker")
@@ -234,7 +292,8 @@ func (svc *service) processPosts(ctx context.Context, posts []object.WallWallpos
 		}
 		slog.Debug("got msg", "owner_id", post.OwnerID, "post_id", post.ID, "date", post.Date, "text", text, "link", link)
 		// Persist new message in SQLite (best-effort)
-		if err := svc.SaveMessage(post.OwnerID, post.ID, int64(post.Date), post.Text, text, link, photos); err != nil {
+		repost := len(post.CopyHistory) > 0
+		if err := svc.SaveMessage(post.OwnerID, post.ID, int64(post.Date), post.Text, text, link, photos, repost); err != nil {
 			slog.Error("db save failed", "err", err, "owner_id", post.OwnerID, "post_id", post.ID)
 		}
 		if post.Date > int(g.LastTS) {
@@ -258,95 +317,77 @@ func (s *service) scanAllGroups(gs []Group) {
 	}
 }
 
-// SaveMessage parses raw VK text and persists it via sqlc UpsertPost.
-func (s *service) SaveMessage(ownerID int, postID int, date int64, raw, normalized, link string, photos []string) error {
+// SaveMessage parses raw VK text and persists it with its animal and
+// identifier rows. repost is set for posts sharing another one.
+func (s *service) SaveMessage(ownerID int, postID int, date int64, raw, normalized, link string, photos []string, repost bool) error {
 	// Parse domain-level fields from raw text
-	p := lostdogs.Parse(postID, raw)
-
-	// Map domain enums to DB strings
-	sex := string(p.Sex)
-	if sex == "" {
-		sex = "unknown"
-	}
-	animal := string(p.Animal)
-	if animal == "" {
-		animal = "unknown"
-	}
-	ptype := string(p.Type)
-	if ptype == "" {
-		ptype = "unknown"
-	}
-	// Optional string pointers
-	sPtr := func(v string) *string {
-		if strings.TrimSpace(v) == "" {
-			return nil
-		}
-		return &v
-	}
+	p, ex := s.currentParser().ParseExplainAt(postID, raw, time.Unix(date, 0))
 
-	// Slices -> JSON-backed StringSlice
-	var phones itypes.StringSlice
-	if len(p.Phones) > 0 {
-		phones = itypes.StringSlice(p.Phones)
-	}
-	var contactNames itypes.StringSlice
-	if len(p.ContactNames) > 0 {
-		contactNames = itypes.StringSlice(p.ContactNames)
-	}
-	var vkAccounts itypes.StringSlice
-	if len(p.VKAccounts) > 0 {
-		vkAccounts = itypes.StringSlice(p.VKAccounts)
-	}
-	var photoURLs itypes.StringSlice
-	if len(photos) > 0 {
-		photoURLs = itypes.StringSlice(photos)
-	}
-
-	params := sqldb.UpsertPostParams{
-		OwnerID:       int64(ownerID),
-		PostID:        int64(postID),
-		Date:          date,
-		Text:          normalized,
-		Raw:           raw,
-		Type:          ptype,
-		Animal:        animal,
-		Sex:           sex,
-		Name:          sPtr(p.Name),
-		Location:      sPtr(p.Location),
-		When:          sPtr(p.When),
-		Phones:        phones,
-		ContactNames:  contactNames,
-		VkAccounts:    vkAccounts,
-		Photos:        photoURLs,
-		StatusDetails: sPtr(p.StatusDetails),
-	}
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
-	if err := s.queries.UpsertPost(ctx, params); err != nil {
+	s.posterRole(ctx, int64(ownerID), int64(postID), date, repost, &p, &ex)
+	params := upsertPostParams(int64(ownerID), int64(postID), date, normalized, photos, repost, p, ex)
+	if err := s.storePost(ctx, params, p); err != nil {
 		return err
 	}
 
-	if shouldPost(p) {
-		// Enqueue to Telegram outbox for matching posts (e.g., lost)
-		if err := s.queries.EnqueueOutbox(ctx, sqldb.EnqueueOutboxParams{OwnerID: int64(ownerID), PostID: int64(postID)}); err != nil {
+	s.enqueue(ctx, int64(ownerID), int64(postID), s.routes.match(p), p.Urgency)
+
+	return nil
+}
+
+// enqueue schedules a stored post for delivery to the destination outboxes
+// in d, urgent posts ahead of the rest. Errors are logged; enqueueing is
+// best-effort like the rest of the pipeline.
+func (s *service) enqueue(ctx context.Context, ownerID, postID int64, d dests, u lostdogs.Urgency) {
+	prio := int64(u.Rank())
+	if d.tg {
+		if err := s.queries.EnqueueOutbox(ctx, sqldb.EnqueueOutboxParams{OwnerID: ownerID, PostID: postID, Priority: prio}); err != nil {
 			slog.Error("telegram enqueue failed", "err", err, "owner_id", ownerID, "post_id", postID)
 		}
-		// Enqueue to VK outbox for matching posts (e.g., lost)
-		if err := s.queries.EnqueueOutboxVK(ctx, sqldb.EnqueueOutboxVKParams{OwnerID: int64(ownerID), PostID: int64(postID)}); err != nil {
+	}
+	if d.vk {
+		if err := s.queries.EnqueueOutboxVK(ctx, sqldb.EnqueueOutboxVKParams{OwnerID: ownerID, PostID: postID, Priority: prio}); err != nil {
 			slog.Error("vk enqueue failed", "err", err, "owner_id", ownerID, "post_id", postID)
 		}
 	}
-
-	return nil
 }
 
 var allowedTypes = []lostdogs.PostType{lostdogs.TypeLost, lostdogs.TypeFound, lostdogs.TypeSighting}
 
-func shouldPost(p lostdogs.Post) bool {
-	if slices.Contains(allowedTypes, p.Type) && p.Animal == lostdogs.AnimalDog {
+// routes lists the animals each destination broadcasts: an animal type
+// ("dog", "other") or a species ("parrot"). An empty list means dogs only.
+type routes struct {
+	tg, vk []string
+}
+
+// dests are the destination outboxes a post goes to.
+type dests struct {
+	tg, vk bool
+}
+
+func (d dests) any() bool { return d.tg || d.vk }
+
+// match returns the destinations a parsed post is broadcast to.
+func (r routes) match(p lostdogs.Post) dests {
+	return dests{tg: shouldPost(p, r.tg), vk: shouldPost(p, r.vk)}
+}
+
+// shouldPost reports whether a parsed post is broadcast to a destination
+// taking animals. Resolved cases are stored but never enqueued; a
+// multi-animal post goes out when any of its animals would.
+func shouldPost(p lostdogs.Post, animals []string) bool {
+	if p.Resolved {
+		return false
+	}
+	if len(animals) == 0 {
+		animals = []string{string(lostdogs.AnimalDog)}
+	}
+	if slices.Contains(allowedTypes, p.Type) &&
+		(slices.Contains(animals, string(p.Animal)) || p.Species != "" && slices.Contains(animals, p.Species)) {
 		return true
 	}
-	return false
+	return slices.ContainsFunc(p.Parts, func(a lostdogs.Post) bool { return shouldPost(a, animals) })
 }
 
 func initLogger(level slog.Level) {
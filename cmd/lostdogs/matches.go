@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/caarlos0/env/v11"
+	sqldb "github.com/jehaby/lostdogs/internal/db"
+)
+
+var matchStatuses = []string{"new", "confirmed", "rejected"}
+
+// runMatches implements `lostdogs matches`: list lost/found pairs that share
+// a chip, tattoo or tag phone, and let a moderator confirm or reject one.
+func runMatches(args []string) error {
+	fs := flag.NewFlagSet("matches", flag.ContinueOnError)
+	var (
+		status = fs.String("status", "new", "list matches with this status (new, confirmed, rejected)")
+		set    = fs.String("set", "", "set the status of the -lost/-found pair instead of listing")
+		lost   = fs.String("lost", "", "lost post as owner_post, e.g. -123_456")
+		found  = fs.String("found", "", "found post as owner_post")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	for _, v := range []string{*status, *set} {
+		if v != "" && !slices.Contains(matchStatuses, v) {
+			return fmt.Errorf("unknown status %q (allowed: %v)", v, matchStatuses)
+		}
+	}
+
+	cfg := dbConfig{}
+	if err := env.Parse(&cfg); err != nil {
+		return fmt.Errorf("parse config: %w", err)
+	}
+	initLogger(cfg.LogLevel)
+	svc := newDBService(cfg)
+	defer svc.db.Close()
+	ctx := context.Background()
+
+	if *set != "" {
+		p := sqldb.SetPostMatchStatusParams{Status: *set}
+		if _, err := fmt.Sscanf(*lost, "%d_%d", &p.LostOwnerID, &p.LostPostID); err != nil {
+			return fmt.Errorf("bad -lost %q: %w", *lost, err)
+		}
+		if _, err := fmt.Sscanf(*found, "%d_%d", &p.FoundOwnerID, &p.FoundPostID); err != nil {
+			return fmt.Errorf("bad -found %q: %w", *found, err)
+		}
+		n, err := svc.queries.SetPostMatchStatus(ctx, p)
+		if err != nil {
+			return fmt.Errorf("set match status: %w", err)
+		}
+		if n == 0 {
+			return fmt.Errorf("no match between %s and %s", *lost, *found)
+		}
+		fmt.Printf("%s ~ %s: %s\n", *lost, *found, *set)
+		return nil
+	}
+
+	ms, err := svc.queries.ListPostMatches(ctx, *status)
+	if err != nil {
+		return fmt.Errorf("list matches: %w", err)
+	}
+	printMatches(os.Stdout, ms)
+	return nil
+}
+
+func printMatches(w io.Writer, ms []sqldb.PostMatch) {
+	for _, m := range ms {
+		fmt.Fprintf(w, "%s %-9s %-16s lost https://vk.com/wall%d_%d found https://vk.com/wall%d_%d\n",
+			m.CreatedAt.Format("2006-01-02 15:04"), m.Kind, m.Value, m.LostOwnerID, m.LostPostID, m.FoundOwnerID, m.FoundPostID)
+	}
+	fmt.Fprintf(w, "%d matches\n", len(ms))
+}
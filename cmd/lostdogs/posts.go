@@ -0,0 +1,441 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jehaby/lostdogs"
+	sqldb "github.com/jehaby/lostdogs/internal/db"
+	itypes "github.com/jehaby/lostdogs/internal/types"
+)
+
+// upsertPostParams maps a parsed post, its explanation and VK metadata onto
+// the posts row.
+func upsertPostParams(ownerID, postID int64, date int64, normalized string, photos []string, repost bool, p lostdogs.Post, ex lostdogs.Explanation) sqldb.UpsertPostParams {
+	return sqldb.UpsertPostParams{
+		OwnerID:       ownerID,
+		PostID:        postID,
+		Date:          date,
+		Text:          normalized,
+		Raw:           p.Raw,
+		Type:          enumOr(string(p.Type)),
+		Animal:        enumOr(string(p.Animal)),
+		Sex:           enumOr(string(p.Sex)),
+		Name:          strPtr(p.Name),
+		Location:      strPtr(p.Location),
+		When:          strPtr(p.When),
+		ContactNames:  sliceOrNil(p.ContactNames),
+		Photos:        sliceOrNil(photos),
+		StatusDetails: strPtr(p.StatusDetails),
+		Breed:         strPtr(p.Breed),
+		BreedID:       strPtr(p.BreedID),
+		BreedMixed:    p.BreedMixed,
+		Age:           strPtr(p.Age),
+		Sterilized:    p.Extras.Sterilized,
+		Vaccinated:    p.Extras.Vaccinated,
+		Chipped:       p.Extras.Chipped,
+		LitterOk:      p.Extras.LitterOK,
+		ParserVersion: strPtr(p.ParserVersion),
+		Explain:       explainJSON(ex),
+		TypeSource:    strPtr(string(p.TypeSource)),
+		AnimalSource:  strPtr(string(p.AnimalSource)),
+		WhenFrom:      unixPtr(p.WhenFrom),
+		WhenTo:        unixPtr(p.WhenTo),
+		WhenPrecision: strPtr(string(p.WhenPrecision)),
+		Address:       strPtr(p.Address),
+		District:      strPtr(p.District),
+		Lat:           floatPtr(p.Lat, p.District != ""),
+		Lon:           floatPtr(p.Lon, p.District != ""),
+		AgeMinMonths:  intPtr(p.AgeMinMonths, p.AgeMaxMonths > 0),
+		AgeMaxMonths:  intPtr(p.AgeMaxMonths, p.AgeMaxMonths > 0),
+		AgeClass:      strPtr(string(p.AgeClass)),
+		Colors:        sliceOrNil(p.Appearance.Colors),
+		Coat:          strPtr(string(p.Appearance.Coat)),
+		Gear:          sliceOrNil(p.Appearance.Gear),
+		Marks:         sliceOrNil(p.Appearance.Marks),
+		Resolved:      p.Resolved,
+		Species:       strPtr(p.Species),
+		Size:          strPtr(string(p.Size.Class)),
+		WeightMinKg:   floatPtr(p.Size.WeightMinKg, p.Size.WeightMaxKg > 0),
+		WeightMaxKg:   floatPtr(p.Size.WeightMaxKg, p.Size.WeightMaxKg > 0),
+		HeightMinCm:   intPtr(p.Size.HeightMinCm, p.Size.HeightMaxCm > 0),
+		HeightMaxCm:   intPtr(p.Size.HeightMaxCm, p.Size.HeightMaxCm > 0),
+		Build:         strPtr(string(p.Size.Build)),
+		Urgency:       strPtr(string(p.Urgency)),
+		Health:        sliceOrNil(p.Health),
+		Reward:        p.Reward,
+		RewardAmount:  intPtr(p.RewardAmount, p.RewardAmount > 0),
+		ScamRisk:      p.ScamRisk,
+		Role:          strPtr(string(p.Role)),
+		RoleSource:    strPtr(string(p.RoleSource)),
+		Repost:        repost,
+	}
+}
+
+// storePost upserts the posts row and replaces the post's animal, contact and
+// identifier rows in one transaction. A lost post sharing an identifier with a
+// found or sighting post (or the other way round) is recorded in post_matches
+// and logged for moderators.
+func (s *service) storePost(ctx context.Context, params sqldb.UpsertPostParams, p lostdogs.Post) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	q := s.queries.WithTx(tx)
+	if err := q.UpsertPost(ctx, params); err != nil {
+		return err
+	}
+	if err := q.DeletePostAnimals(ctx, sqldb.DeletePostAnimalsParams{OwnerID: params.OwnerID, PostID: params.PostID}); err != nil {
+		return err
+	}
+	for i, a := range p.Parts {
+		if err := q.InsertPostAnimal(ctx, postAnimalParams(params.OwnerID, params.PostID, i+1, a)); err != nil {
+			return err
+		}
+	}
+	if err := q.DeletePostContacts(ctx, sqldb.DeletePostContactsParams{OwnerID: params.OwnerID, PostID: params.PostID}); err != nil {
+		return err
+	}
+	for i, c := range p.Contacts {
+		if err := q.InsertPostContact(ctx, postContactParams(params.OwnerID, params.PostID, i+1, c)); err != nil {
+			return err
+		}
+	}
+	matches, err := storeIdentifiers(ctx, q, params.OwnerID, params.PostID, p)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, m := range matches {
+		slog.Warn("found post matches a lost one; needs moderation", "kind", m.Kind, "value", m.Value,
+			"lost", fmt.Sprintf("%d_%d", m.LostOwnerID, m.LostPostID), "found", fmt.Sprintf("%d_%d", m.FoundOwnerID, m.FoundPostID))
+	}
+	return nil
+}
+
+// storeIdentifiers replaces the post's identifier rows and links it to the
+// posts of the opposite side carrying the same identifier. It returns the
+// matches that are new.
+func storeIdentifiers(ctx context.Context, q *sqldb.Queries, ownerID, postID int64, p lostdogs.Post) ([]sqldb.InsertPostMatchParams, error) {
+	if err := q.DeletePostIdentifiers(ctx, sqldb.DeletePostIdentifiersParams{OwnerID: ownerID, PostID: postID}); err != nil {
+		return nil, err
+	}
+	side := matchSide(string(p.Type))
+	var fresh []sqldb.InsertPostMatchParams
+	for _, id := range p.IDs {
+		if err := q.InsertPostIdentifier(ctx, sqldb.InsertPostIdentifierParams{OwnerID: ownerID, PostID: postID, Kind: string(id.Kind), Value: id.Value}); err != nil {
+			return nil, err
+		}
+		if side == "" {
+			continue
+		}
+		others, err := q.FindPostsByIdentifier(ctx, sqldb.FindPostsByIdentifierParams{Kind: string(id.Kind), Value: id.Value, OwnerID: ownerID, PostID: postID})
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range others {
+			if other := matchSide(o.Type); other == "" || other == side {
+				continue
+			}
+			m := sqldb.InsertPostMatchParams{
+				LostOwnerID: ownerID, LostPostID: postID,
+				FoundOwnerID: o.OwnerID, FoundPostID: o.PostID,
+				Kind: string(id.Kind), Value: id.Value,
+			}
+			if side == "found" {
+				m.LostOwnerID, m.LostPostID, m.FoundOwnerID, m.FoundPostID = o.OwnerID, o.PostID, ownerID, postID
+			}
+			n, err := q.InsertPostMatch(ctx, m)
+			if err != nil {
+				return nil, err
+			}
+			if n > 0 {
+				fresh = append(fresh, m)
+			}
+		}
+	}
+	return fresh, nil
+}
+
+// phoneHistoryDays is how far back the other posts of a phone are looked at
+// when deciding the poster's role.
+const phoneHistoryDays = 180
+
+// posterRole applies lostdogs.SetPosterRole to a parsed post and its
+// explanation: a repost, or a phone that posts from the days before it carry
+// for other animals, makes a volunteer or a shelter of the poster; later
+// posts never count, so reparse agrees with live ingest. Of several phones
+// the busiest counts. History lookups are best-effort; on error the role
+// read from the text stands.
+func (s *service) posterRole(ctx context.Context, ownerID, postID, date int64, repost bool, p *lostdogs.Post, ex *lostdogs.Explanation) {
+	info := lostdogs.PosterInfo{Repost: repost}
+	for _, ph := range p.Phones() {
+		h, err := s.queries.PhoneHistory(ctx, sqldb.PhoneHistoryParams{
+			Value: ph, Since: date - phoneHistoryDays*24*3600, Until: date, OwnerID: ownerID, PostID: postID,
+		})
+		if err != nil {
+			slog.Error("phone history failed", "err", err, "owner_id", ownerID, "post_id", postID)
+			break
+		}
+		if int(h.Cases) > info.Cases {
+			info.Cases, info.Adoptions = int(h.Cases), int(h.Adoptions)
+		}
+	}
+	lostdogs.SetPosterRole(p, ex, info)
+}
+
+// matchSide is "lost" or "found" for the post types that take part in
+// matching (a sighting is on the finder's side), "" for the rest.
+func matchSide(t string) string {
+	switch lostdogs.PostType(t) {
+	case lostdogs.TypeLost:
+		return "lost"
+	case lostdogs.TypeFound, lostdogs.TypeSighting:
+		return "found"
+	}
+	return ""
+}
+
+// postAnimalParams maps one part of a multi-animal post onto a post_animals row.
+func postAnimalParams(ownerID, postID int64, idx int, a lostdogs.Post) sqldb.InsertPostAnimalParams {
+	return sqldb.InsertPostAnimalParams{
+		OwnerID:      ownerID,
+		PostID:       postID,
+		Idx:          int64(idx),
+		Text:         a.Raw,
+		Type:         enumOr(string(a.Type)),
+		Animal:       enumOr(string(a.Animal)),
+		Sex:          enumOr(string(a.Sex)),
+		Name:         strPtr(a.Name),
+		Breed:        strPtr(a.Breed),
+		BreedID:      strPtr(a.BreedID),
+		Age:          strPtr(a.Age),
+		AgeMinMonths: intPtr(a.AgeMinMonths, a.AgeMaxMonths > 0),
+		AgeMaxMonths: intPtr(a.AgeMaxMonths, a.AgeMaxMonths > 0),
+		Phones:       sliceOrNil(a.Phones()),
+		Species:      strPtr(a.Species),
+	}
+}
+
+// postContactParams maps a contact onto a post_contacts row.
+func postContactParams(ownerID, postID int64, idx int, c lostdogs.Contact) sqldb.InsertPostContactParams {
+	return sqldb.InsertPostContactParams{
+		OwnerID: ownerID,
+		PostID:  postID,
+		Idx:     int64(idx),
+		Kind:    string(c.Kind),
+		Value:   c.Value,
+		Name:    strPtr(c.Name),
+	}
+}
+
+// contactsFromRows rebuilds the contacts of a stored post.
+func contactsFromRows(rows []sqldb.PostContact) []lostdogs.Contact {
+	var out []lostdogs.Contact
+	for _, r := range rows {
+		out = append(out, lostdogs.Contact{Kind: lostdogs.ContactKind(r.Kind), Value: r.Value, Name: strVal(r.Name)})
+	}
+	return out
+}
+
+// phoneContacts rebuilds the contacts of a part, which stores phones only.
+func phoneContacts(phones []string) []lostdogs.Contact {
+	var out []lostdogs.Contact
+	for _, ph := range phones {
+		out = append(out, lostdogs.Contact{Kind: lostdogs.ContactPhone, Value: ph})
+	}
+	return out
+}
+
+// idsFromRows rebuilds the identifiers of a stored post.
+func idsFromRows(rows []sqldb.PostIdentifier) []lostdogs.Identifier {
+	var out []lostdogs.Identifier
+	for _, r := range rows {
+		out = append(out, lostdogs.Identifier{Kind: lostdogs.IDKind(r.Kind), Value: r.Value})
+	}
+	return out
+}
+
+// partsFromRows rebuilds the parts of a stored post; nil when it has none.
+func partsFromRows(rows []sqldb.PostAnimal) []lostdogs.Post {
+	if len(rows) == 0 {
+		return nil
+	}
+	out := make([]lostdogs.Post, len(rows))
+	for i, r := range rows {
+		out[i] = lostdogs.Post{
+			ID:           int(r.PostID),
+			Raw:          r.Text,
+			Type:         lostdogs.PostType(r.Type),
+			Animal:       lostdogs.AnimalType(r.Animal),
+			Sex:          lostdogs.SexType(r.Sex),
+			Name:         strVal(r.Name),
+			Breed:        strVal(r.Breed),
+			BreedID:      strVal(r.BreedID),
+			Age:          strVal(r.Age),
+			AgeMinMonths: intVal(r.AgeMinMonths),
+			AgeMaxMonths: intVal(r.AgeMaxMonths),
+			Contacts:     phoneContacts(r.Phones),
+			Species:      strVal(r.Species),
+		}
+	}
+	return out
+}
+
+func explainJSON(ex lostdogs.Explanation) *string {
+	b, err := json.Marshal(ex)
+	if err != nil {
+		return nil
+	}
+	v := string(b)
+	return &v
+}
+
+// postFromRow rebuilds the domain post from a stored row. The post ID is the
+// VK post id, matching what SaveMessage passes to lostdogs.Parse.
+func postFromRow(r sqldb.GetPostRow) lostdogs.Post {
+	return lostdogs.Post{
+		ID:            int(r.PostID),
+		Raw:           r.Raw,
+		Type:          lostdogs.PostType(r.Type),
+		Animal:        lostdogs.AnimalType(r.Animal),
+		Species:       strVal(r.Species),
+		Breed:         strVal(r.Breed),
+		BreedID:       strVal(r.BreedID),
+		BreedMixed:    r.BreedMixed,
+		Sex:           lostdogs.SexType(r.Sex),
+		Age:           strVal(r.Age),
+		AgeMinMonths:  intVal(r.AgeMinMonths),
+		AgeMaxMonths:  intVal(r.AgeMaxMonths),
+		AgeClass:      lostdogs.AgeClass(strVal(r.AgeClass)),
+		Name:          strVal(r.Name),
+		Location:      strVal(r.Location),
+		Address:       strVal(r.Address),
+		District:      strVal(r.District),
+		Lat:           floatVal(r.Lat),
+		Lon:           floatVal(r.Lon),
+		When:          strVal(r.When),
+		WhenFrom:      unixVal(r.WhenFrom),
+		WhenTo:        unixVal(r.WhenTo),
+		WhenPrecision: lostdogs.WhenPrecision(strVal(r.WhenPrecision)),
+		ContactNames:  []string(r.ContactNames),
+		Extras: lostdogs.Extras{
+			Sterilized: r.Sterilized,
+			Vaccinated: r.Vaccinated,
+			Chipped:    r.Chipped,
+			LitterOK:   r.LitterOk,
+		},
+		Appearance: lostdogs.Appearance{
+			Colors: []string(r.Colors),
+			Coat:   lostdogs.CoatLength(strVal(r.Coat)),
+			Gear:   []string(r.Gear),
+			Marks:  []string(r.Marks),
+		},
+		Size: lostdogs.Size{
+			Class:       lostdogs.SizeClass(strVal(r.Size)),
+			WeightMinKg: floatVal(r.WeightMinKg),
+			WeightMaxKg: floatVal(r.WeightMaxKg),
+			HeightMinCm: intVal(r.HeightMinCm),
+			HeightMaxCm: intVal(r.HeightMaxCm),
+			Build:       lostdogs.Build(strVal(r.Build)),
+		},
+		StatusDetails: strVal(r.StatusDetails),
+		Resolved:      r.Resolved,
+		Urgency:       lostdogs.Urgency(strVal(r.Urgency)),
+		Health:        []string(r.Health),
+		Reward:        r.Reward,
+		RewardAmount:  intVal(r.RewardAmount),
+		ScamRisk:      r.ScamRisk,
+		Role:          lostdogs.Role(strVal(r.Role)),
+		RoleSource:    lostdogs.RoleSource(strVal(r.RoleSource)),
+		ParserVersion: strVal(r.ParserVersion),
+		TypeSource:    lostdogs.Source(strVal(r.TypeSource)),
+		AnimalSource:  lostdogs.Source(strVal(r.AnimalSource)),
+	}
+}
+
+// enumOr maps an empty domain enum to the DB default.
+func enumOr(v string) string {
+	if v == "" {
+		return "unknown"
+	}
+	return v
+}
+
+func strPtr(v string) *string {
+	if strings.TrimSpace(v) == "" {
+		return nil
+	}
+	return &v
+}
+
+func strVal(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+func unixPtr(t time.Time) *int64 {
+	if t.IsZero() {
+		return nil
+	}
+	v := t.Unix()
+	return &v
+}
+
+// unixVal maps a stored unix time back to Izhevsk local time.
+func unixVal(v *int64) time.Time {
+	if v == nil {
+		return time.Time{}
+	}
+	return time.Unix(*v, 0).In(lostdogs.Izhevsk)
+}
+
+// floatPtr stores a coordinate or a weight only when ok: the post matched a
+// place, the weight is known.
+func floatPtr(v float64, ok bool) *float64 {
+	if !ok {
+		return nil
+	}
+	return &v
+}
+
+// intPtr stores a number only when ok: an age or height range is known, a
+// reward amount was given.
+func intPtr(v int, ok bool) *int64 {
+	if !ok {
+		return nil
+	}
+	n := int64(v)
+	return &n
+}
+
+func intVal(v *int64) int {
+	if v == nil {
+		return 0
+	}
+	return int(*v)
+}
+
+func floatVal(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// sliceOrNil keeps empty slices as SQL NULL rather than "[]".
+func sliceOrNil(ss []string) itypes.StringSlice {
+	if len(ss) == 0 {
+		return nil
+	}
+	return itypes.StringSlice(ss)
+}
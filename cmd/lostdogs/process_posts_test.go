@@ -4,48 +4,40 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
-	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	object "github.com/SevereCloud/vksdk/v3/object"
 	root "github.com/jehaby/lostdogs"
 	sqldb "github.com/jehaby/lostdogs/internal/db"
+	"github.com/jehaby/lostdogs/internal/telegram"
+	"github.com/jehaby/lostdogs/internal/vk"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/require"
 )
 
-// minimal structure of fixture posts produced by cmd/dump-wall
-type fixturePost struct {
-	OwnerID int    `json:"owner_id"`
-	ID      int    `json:"id"`
-	Date    int    `json:"date"`
-	Text    string `json:"text"`
+// newTestService returns a service over an isolated in-memory SQLite database
+// with all migrations applied; name must be unique per test.
+func newTestService(t *testing.T, name string) *service {
+	t.Helper()
+	db, err := sql.Open("sqlite3", "file:"+name+"?cache=shared&mode=memory")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	require.NoError(t, applyMigrations(db, "../../resources/db/migrations"))
+	return &service{db: db, queries: sqldb.New(db)}
 }
 
 func TestProcessPosts_CountLostFromFixture(t *testing.T) {
 	t.Parallel()
 
-	// Open isolated in-memory SQLite and apply migrations
-	db, err := sql.Open("sqlite3", "file:memdb_process_posts?cache=shared&mode=memory")
-	require.NoError(t, err)
-	t.Cleanup(func() { _ = db.Close() })
-
-	require.NoError(t, applyMigrations(db, "../../resources/db/migrations"))
-
-	svc := &service{
-		db:      db,
-		queries: sqldb.New(db),
-	}
+	svc := newTestService(t, "memdb_process_posts")
 
 	// Load fixture posts
 	fixturePath := filepath.Join("..", "..", "resources", "fixtures", "wall_zoopoisk_18_100.json")
-	b, err := os.ReadFile(fixturePath)
+	fps, err := loadFixture(fixturePath)
 	require.NoError(t, err)
 
-	var fps []fixturePost
-	require.NoError(t, json.Unmarshal(b, &fps))
-
 	// Convert to VK SDK type expected by processPosts
 	posts := make([]object.WallWallpost, 0, len(fps))
 	var expectLost int
@@ -68,8 +60,319 @@ func TestProcessPosts_CountLostFromFixture(t *testing.T) {
 
 	// Query DB for count of rows with type='lost'
 	var gotLost int
-	err = db.QueryRowContext(ctx, "SELECT COUNT(1) FROM posts WHERE type = 'lost'").Scan(&gotLost)
+	err = svc.db.QueryRowContext(ctx, "SELECT COUNT(1) FROM posts WHERE type = 'lost'").Scan(&gotLost)
 	require.NoError(t, err)
 
 	require.Equal(t, expectLost, gotLost, "lost posts count should match parse results")
 }
+
+func TestSaveMessage_RoundTripsParsedPost(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t, "memdb_round_trip")
+
+	raw := "Вчера вечером пропала собака, метис лабрадора, рыжая, в красном ошейнике, 2 года. Стерилизована, вакцинирована, чипирована. Пушкинская улица, 283. 89120281683 Юрий"
+	photos := []string{"https://example.com/1.jpg"}
+	require.NoError(t, svc.SaveMessage(-1, 42, 1700000000, raw, normalize(raw), "", photos, false))
+
+	row, err := svc.queries.GetPost(context.Background(), sqldb.GetPostParams{OwnerID: -1, PostID: 42})
+	require.NoError(t, err)
+
+	contacts, err := svc.queries.ListPostContacts(context.Background(), sqldb.ListPostContactsParams{OwnerID: -1, PostID: 42})
+	require.NoError(t, err)
+	got := postFromRow(row)
+	got.Contacts = contactsFromRows(contacts)
+
+	want := root.ParseAt(42, raw, time.Unix(1700000000, 0))
+	require.Equal(t, nilEmptySlices(want), got)
+	require.Equal(t, photos, []string(row.Photos))
+	require.NotEmpty(t, want.Breed)
+	require.NotEmpty(t, want.Age)
+	require.True(t, want.Extras.Chipped)
+	require.NotEmpty(t, want.Appearance.Colors)
+	require.NotEmpty(t, want.Appearance.Gear)
+	require.Equal(t, root.WhenPartOfDay, want.WhenPrecision)
+}
+
+// nilEmptySlices mirrors the DB convention of storing empty lists as NULL.
+func nilEmptySlices(p root.Post) root.Post {
+	if len(p.Contacts) == 0 {
+		p.Contacts = nil
+	}
+	for _, ss := range []*[]string{&p.ContactNames, &p.Appearance.Colors, &p.Appearance.Gear, &p.Appearance.Marks, &p.Health} {
+		if len(*ss) == 0 {
+			*ss = nil
+		}
+	}
+	return p
+}
+
+func TestSaveMessage_SkipsResolved(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t, "memdb_resolved")
+
+	open := "Пропала собака, рыжий кобель, район Буммаш. 89120281683"
+	resolved := "НАШЛАСЬ! Пропала собака, рыжий кобель, район Буммаш. Спасибо всем"
+	require.NoError(t, svc.SaveMessage(-1, 1, 1700000000, open, normalize(open), "", nil, false))
+	require.NoError(t, svc.SaveMessage(-1, 2, 1700000000, resolved, normalize(resolved), "", nil, false))
+
+	var ids []int64
+	rows, err := svc.db.Query("SELECT post_id FROM outbox ORDER BY post_id")
+	require.NoError(t, err)
+	for rows.Next() {
+		var id int64
+		require.NoError(t, rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, []int64{1}, ids)
+
+	var stored bool
+	require.NoError(t, svc.db.QueryRow("SELECT resolved FROM posts WHERE post_id = 2").Scan(&stored))
+	require.True(t, stored)
+}
+
+func TestSaveMessage_StoresAnimals(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t, "memdb_animals")
+	ctx := context.Background()
+	key := sqldb.ListPostAnimalsParams{OwnerID: -1, PostID: 1}
+
+	list := "Ищут дом! 1) Кот Барсик, 2 года, рыжий. 2) Собака Жучка, 5 месяцев, девочка. Звоните 89120281683"
+	require.NoError(t, svc.SaveMessage(-1, 1, 1700000000, list, normalize(list), "", nil, false))
+	rows, err := svc.queries.ListPostAnimals(ctx, key)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, int64(1), rows[0].Idx)
+	require.Equal(t, "cat", rows[0].Animal)
+	require.Equal(t, "Барсик", *rows[0].Name)
+	require.Equal(t, "dog", rows[1].Animal)
+	require.Equal(t, "f", rows[1].Sex)
+	require.Equal(t, []string{"+79120281683"}, []string(rows[0].Phones), "phones come from the post")
+
+	// An edit that drops the list drops the rows.
+	single := "Ищет дом кот Барсик, 2 года, рыжий. Звоните 89120281683"
+	require.NoError(t, svc.SaveMessage(-1, 1, 1700000000, single, normalize(single), "", nil, false))
+	rows, err = svc.queries.ListPostAnimals(ctx, key)
+	require.NoError(t, err)
+	require.Empty(t, rows)
+}
+
+func TestSaveMessage_RoutesByAnimal(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t, "memdb_routes")
+	svc.routes = routes{tg: []string{"dog", "parrot"}, vk: []string{"dog"}}
+
+	dog := "Пропала собака, рыжий кобель, район Буммаш. 89120281683"
+	parrot := "Улетел волнистый попугай, зелёный, район Буммаш. 89120281683"
+	cat := "Пропала кошка, рыжая, район Буммаш. 89120281683"
+	for i, raw := range []string{dog, parrot, cat} {
+		require.NoError(t, svc.SaveMessage(-1, i+1, 1700000000, raw, normalize(raw), "", nil, false))
+	}
+
+	var species string
+	require.NoError(t, svc.db.QueryRow("SELECT species FROM posts WHERE post_id = 2").Scan(&species))
+	require.Equal(t, "parrot", species)
+
+	ids := func(table string) []int64 {
+		var out []int64
+		rows, err := svc.db.Query("SELECT post_id FROM " + table + " ORDER BY post_id")
+		require.NoError(t, err)
+		for rows.Next() {
+			var id int64
+			require.NoError(t, rows.Scan(&id))
+			out = append(out, id)
+		}
+		require.NoError(t, rows.Err())
+		return out
+	}
+	require.Equal(t, []int64{1, 2}, ids("outbox"))
+	require.Equal(t, []int64{1}, ids("outbox_vk"))
+}
+
+func TestSaveMessage_UrgentFirst(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t, "memdb_urgent")
+	ctx := context.Background()
+
+	sighting := "Видели собаку, рыжий кобель, бегает у школы на Буммаше"
+	injured := "Найдена собака, сбита машиной, лежит у остановки на Буммаше. 89120281683"
+	require.NoError(t, svc.SaveMessage(-1, 1, 1700000000, sighting, normalize(sighting), "", nil, false))
+	require.NoError(t, svc.SaveMessage(-1, 2, 1700000100, injured, normalize(injured), "", nil, false))
+
+	row, err := svc.queries.GetPost(ctx, sqldb.GetPostParams{OwnerID: -1, PostID: 2})
+	require.NoError(t, err)
+	require.Equal(t, "critical", *row.Urgency)
+	require.Equal(t, []string{"hit_by_car"}, []string(row.Health))
+
+	var queued int
+	require.NoError(t, svc.db.QueryRow("SELECT COUNT(1) FROM outbox WHERE status = 'pending'").Scan(&queued))
+	require.Equal(t, 2, queued)
+
+	lease := int64(1)
+	require.NoError(t, svc.queries.ClaimPendingMark(ctx, sqldb.ClaimPendingMarkParams{Lease: &lease, Limit: 1}))
+	claimed, err := svc.queries.ListSendingByLease(ctx, &lease)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	require.Equal(t, int64(2), claimed[0].PostID, "the injured dog goes first")
+
+	require.NoError(t, svc.queries.ClaimPendingMarkVK(ctx, sqldb.ClaimPendingMarkVKParams{Lease: &lease, Limit: 1}))
+	claimedVK, err := svc.queries.ListSendingByLeaseVK(ctx, &lease)
+	require.NoError(t, err)
+	require.Len(t, claimedVK, 1)
+	require.Equal(t, int64(2), claimedVK[0].PostID)
+}
+
+func TestSaveMessage_RewardInMessages(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t, "memdb_reward")
+	ctx := context.Background()
+
+	lost := "Пропала собака, рыжий кобель, район Буммаш. Вознаграждение 5000 ₽. 89120281683"
+	scam := "Найдена собака, рыжий кобель. Верну за 3000 рублей, пишите в личку"
+	require.NoError(t, svc.SaveMessage(-1, 1, 1700000000, lost, normalize(lost), "", nil, false))
+	require.NoError(t, svc.SaveMessage(-1, 2, 1700000000, scam, normalize(scam), "", nil, false))
+
+	row, err := svc.queries.GetPost(ctx, sqldb.GetPostParams{OwnerID: -1, PostID: 1})
+	require.NoError(t, err)
+	require.True(t, row.Reward)
+	require.Equal(t, int64(5000), *row.RewardAmount)
+	for _, msg := range []string{telegram.BuildMessage(row, nil), vk.BuildMessage(row, nil)} {
+		require.Contains(t, msg, "💰 Вознаграждение: 5 000 ₽")
+		require.NotContains(t, msg, "⚠️")
+	}
+
+	row, err = svc.queries.GetPost(ctx, sqldb.GetPostParams{OwnerID: -1, PostID: 2})
+	require.NoError(t, err)
+	require.True(t, row.ScamRisk)
+	require.False(t, row.Reward)
+	for _, msg := range []string{telegram.BuildMessage(row, nil), vk.BuildMessage(row, nil)} {
+		require.Contains(t, msg, "⚠️ Автор просит деньги за возврат питомца")
+		require.NotContains(t, msg, "💰")
+	}
+}
+
+func TestSaveMessage_LinksMatchingIdentifiers(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t, "memdb_matches")
+	ctx := context.Background()
+
+	lost := "Пропала собака, рыжий кобель, чип 643 094 100 123 456. Звоните 89120281683"
+	other := "Пропал пёс у вокзала, чипирован, номер чипа 643094100123456"
+	found := "Найдена собака, рыжий кобель, отсканировали чип: 643094100123456, ищем хозяев"
+	require.NoError(t, svc.SaveMessage(-1, 1, 1700000000, lost, normalize(lost), "", nil, false))
+	require.NoError(t, svc.SaveMessage(-1, 2, 1700000100, other, normalize(other), "", nil, false))
+	require.NoError(t, svc.SaveMessage(-2, 7, 1700003600, found, normalize(found), "", nil, false))
+
+	ids, err := svc.queries.ListPostIdentifiers(ctx, sqldb.ListPostIdentifiersParams{OwnerID: -2, PostID: 7})
+	require.NoError(t, err)
+	require.Equal(t, []sqldb.PostIdentifier{{OwnerID: -2, PostID: 7, Kind: "chip", Value: "643094100123456"}}, ids)
+
+	// Both lost posts are linked to the found one, not to each other; saving
+	// the found post again adds nothing.
+	require.NoError(t, svc.SaveMessage(-2, 7, 1700003600, found, normalize(found), "", nil, false))
+	ms, err := svc.queries.ListPostMatches(ctx, "new")
+	require.NoError(t, err)
+	require.Len(t, ms, 2)
+	for i, m := range ms {
+		require.Equal(t, [4]int64{-1, int64(i + 1), -2, 7}, [4]int64{m.LostOwnerID, m.LostPostID, m.FoundOwnerID, m.FoundPostID})
+		require.Equal(t, "chip", m.Kind)
+	}
+
+	n, err := svc.queries.SetPostMatchStatus(ctx, sqldb.SetPostMatchStatusParams{Status: "confirmed", LostOwnerID: -1, LostPostID: 1, FoundOwnerID: -2, FoundPostID: 7})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+	ms, err = svc.queries.ListPostMatches(ctx, "new")
+	require.NoError(t, err)
+	require.Len(t, ms, 1)
+}
+
+func TestSaveMessage_ContactsInMessages(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t, "memdb_contacts")
+	ctx := context.Background()
+	key := sqldb.ListPostContactsParams{OwnerID: -1, PostID: 1}
+
+	raw := "Пропала собака, рыжий кобель. Звоните 89120281683 (WhatsApp), телеграм @lost_dog_izh, хозяйка [id123|Ольга]"
+	require.NoError(t, svc.SaveMessage(-1, 1, 1700000000, raw, normalize(raw), "", nil, false))
+	contacts, err := svc.queries.ListPostContacts(ctx, key)
+	require.NoError(t, err)
+	var got []string
+	for _, c := range contacts {
+		got = append(got, c.Kind+":"+c.Value)
+	}
+	require.Equal(t, []string{"phone:+79120281683", "whatsapp:+79120281683", "telegram:@lost_dog_izh", "vk:id123"}, got)
+	require.Equal(t, "Ольга", *contacts[3].Name)
+
+	row, err := svc.queries.GetPost(ctx, sqldb.GetPostParams{OwnerID: -1, PostID: 1})
+	require.NoError(t, err)
+	tg := telegram.BuildMessage(row, contacts)
+	require.Contains(t, tg, "📞 +7 912 028-16-83\n")
+	require.Contains(t, tg, `💬 WhatsApp: <a href="https://wa.me/79120281683">+7 912 028-16-83</a>`)
+	require.Contains(t, tg, "✈️ Telegram: @lost_dog_izh\n")
+	require.Contains(t, tg, `👤 VK: <a href="https://vk.com/id123">Ольга</a>`)
+	msg := vk.BuildMessage(row, contacts)
+	require.Contains(t, msg, "💬 WhatsApp: wa.me/79120281683\n")
+	require.Contains(t, msg, "✈️ Telegram: t.me/lost_dog_izh\n")
+	require.Contains(t, msg, "👤 VK: [id123|Ольга]\n")
+
+	// An edit that drops the contacts drops the rows.
+	edited := "Пропала собака, рыжий кобель, район Буммаш, ищем всем миром"
+	require.NoError(t, svc.SaveMessage(-1, 1, 1700000000, edited, normalize(edited), "", nil, false))
+	contacts, err = svc.queries.ListPostContacts(ctx, key)
+	require.NoError(t, err)
+	require.Empty(t, contacts)
+}
+
+func TestSaveMessage_PosterRole(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t, "memdb_role")
+	ctx := context.Background()
+	role := func(ownerID, postID int64) string {
+		t.Helper()
+		row, err := svc.queries.GetPost(ctx, sqldb.GetPostParams{OwnerID: ownerID, PostID: postID})
+		require.NoError(t, err)
+		// The stored explanation explains the stored role.
+		stored, err := svc.queries.GetPostExplain(ctx, sqldb.GetPostExplainParams{OwnerID: ownerID, PostID: postID})
+		require.NoError(t, err)
+		var ex root.Explanation
+		require.NoError(t, json.Unmarshal([]byte(*stored.Explain), &ex))
+		require.Equal(t, *row.Role, ex.Field("role").Value)
+		if *row.RoleSource == "repost" || *row.RoleSource == "history" {
+			require.Equal(t, *row.RoleSource, ex.Field("role").Rule)
+		}
+		return *row.Role + "/" + *row.RoleSource
+	}
+
+	lost := "Пропала наша собака, рыжая сука, район Буммаш. 89120281683"
+	require.NoError(t, svc.SaveMessage(-1, 1, 1700000000, lost, normalize(lost), "", nil, false))
+	require.Equal(t, "owner/text", role(-1, 1))
+
+	// The same case posted again is not another animal.
+	require.NoError(t, svc.SaveMessage(-2, 1, 1700000100, lost, normalize(lost), "", nil, false))
+	require.Equal(t, "owner/text", role(-2, 1))
+
+	require.NoError(t, svc.SaveMessage(-1, 2, 1700000200, lost, normalize(lost), "", nil, true))
+	require.Equal(t, "volunteer/repost", role(-1, 2))
+
+	// A phone in posts about three or more other animals is a volunteer's.
+	for i, breed := range []string{"хаски", "такса", "лабрадор"} {
+		raw := "Пропал пёс, " + breed + ", кобель, район Буммаш. 89120281683"
+		require.NoError(t, svc.SaveMessage(-3, i+1, 1700001000, raw, normalize(raw), "", nil, false))
+	}
+	found := "Найдена собака у вокзала, рыжая сука, ищем хозяев. 89120281683"
+	require.NoError(t, svc.SaveMessage(-3, 10, 1700002000, found, normalize(found), "", nil, false))
+	require.Equal(t, "volunteer/history", role(-3, 10))
+
+	// Posts older than the history window do not count.
+	require.NoError(t, svc.SaveMessage(-3, 11, 1700002000+200*24*3600, found, normalize(found), "", nil, false))
+	require.Equal(t, "finder/type", role(-3, 11))
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/jehaby/lostdogs"
+	sqldb "github.com/jehaby/lostdogs/internal/db"
+)
+
+type reparseOptions struct {
+	Since   time.Time // inclusive; zero means no lower bound
+	Until   time.Time // exclusive; zero means no upper bound
+	OwnerID *int64    // restrict to a single VK group (negative owner id)
+	DryRun  bool      // report only, do not write
+	Enqueue bool      // enqueue posts that newly match a destination
+	Batch   int
+}
+
+// runReparse implements `lostdogs reparse`: re-run the parser over stored raw
+// texts, write back changed rows and print a per-field change summary.
+func runReparse(args []string) error {
+	fs := flag.NewFlagSet("reparse", flag.ContinueOnError)
+	var (
+		since   = fs.String("since", "", "only posts dated on/after this day (YYYY-MM-DD)")
+		until   = fs.String("until", "", "only posts dated before this day (YYYY-MM-DD)")
+		ownerID = fs.Int64("owner-id", 0, "only posts of this VK owner id (e.g. -12345 for a group)")
+		dryRun  = fs.Bool("dry-run", false, "report changes without writing them")
+		enqueue = fs.Bool("enqueue", false, "enqueue posts that start matching delivery rules into the outboxes")
+		batch   = fs.Int("batch", 500, "rows per page")
+		rules   = fs.String("rules", "", "rules file to parse with (default: RULES_FILE or the embedded rules)")
+		model   = fs.String("model", "", "fallback model file (default: MODEL_FILE; \"-\" disables it)")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := dbConfig{}
+	if err := env.Parse(&cfg); err != nil {
+		return fmt.Errorf("parse config: %w", err)
+	}
+	initLogger(cfg.LogLevel)
+
+	opts := reparseOptions{DryRun: *dryRun, Enqueue: *enqueue, Batch: *batch}
+	var err error
+	if opts.Since, err = parseDay(*since); err != nil {
+		return fmt.Errorf("bad -since: %w", err)
+	}
+	if opts.Until, err = parseDay(*until); err != nil {
+		return fmt.Errorf("bad -until: %w", err)
+	}
+	if *ownerID != 0 {
+		opts.OwnerID = ownerID
+	}
+
+	if *rules != "" {
+		cfg.RulesFile = *rules
+	}
+	switch *model {
+	case "":
+	case "-":
+		cfg.ModelFile = ""
+	default:
+		cfg.ModelFile = *model
+	}
+	p, err := loadParser(cfg)
+	if err != nil {
+		return fmt.Errorf("load parser: %w", err)
+	}
+
+	svc := newDBService(cfg)
+	defer svc.db.Close()
+	svc.parser.Store(p)
+
+	sum, err := svc.reparse(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+	sum.Print(os.Stdout)
+	return nil
+}
+
+// parseDay parses a YYYY-MM-DD flag as local midnight in Izhevsk, the zone
+// post dates are read in.
+func parseDay(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation(time.DateOnly, v, lostdogs.Izhevsk)
+}
+
+// reparse streams stored posts page by page through the current parser.
+func (s *service) reparse(ctx context.Context, opts reparseOptions) (*reparseSummary, error) {
+	if opts.Batch <= 0 {
+		opts.Batch = 500
+	}
+	params := sqldb.ListPostsPageParams{
+		AfterOwnerID: math.MinInt64,
+		DateFrom:     math.MinInt64,
+		DateTo:       math.MaxInt64,
+		OwnerID:      opts.OwnerID,
+		Limit:        int64(opts.Batch),
+	}
+	if !opts.Since.IsZero() {
+		params.DateFrom = opts.Since.Unix()
+	}
+	if !opts.Until.IsZero() {
+		params.DateTo = opts.Until.Unix()
+	}
+
+	sum := newReparseSummary(opts.DryRun)
+	for {
+		rows, err := s.queries.ListPostsPage(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("list posts: %w", err)
+		}
+		for _, r := range rows {
+			row := sqldb.GetPostRow(r)
+			old := postFromRow(row)
+			animals, err := s.queries.ListPostAnimals(ctx, sqldb.ListPostAnimalsParams{OwnerID: row.OwnerID, PostID: row.PostID})
+			if err != nil {
+				return nil, fmt.Errorf("list animals of post %d_%d: %w", row.OwnerID, row.PostID, err)
+			}
+			old.Parts = partsFromRows(animals)
+			contacts, err := s.queries.ListPostContacts(ctx, sqldb.ListPostContactsParams{OwnerID: row.OwnerID, PostID: row.PostID})
+			if err != nil {
+				return nil, fmt.Errorf("list contacts of post %d_%d: %w", row.OwnerID, row.PostID, err)
+			}
+			old.Contacts = contactsFromRows(contacts)
+			ids, err := s.queries.ListPostIdentifiers(ctx, sqldb.ListPostIdentifiersParams{OwnerID: row.OwnerID, PostID: row.PostID})
+			if err != nil {
+				return nil, fmt.Errorf("list identifiers of post %d_%d: %w", row.OwnerID, row.PostID, err)
+			}
+			old.IDs = idsFromRows(ids)
+			cur, ex := s.currentParser().ParseExplainAt(int(row.PostID), row.Raw, time.Unix(row.Date, 0))
+			s.posterRole(ctx, row.OwnerID, row.PostID, row.Date, row.Repost, &cur, &ex)
+			changes := diffPosts(old, cur)
+			sum.Add(changes)
+			if len(changes) == 0 && old.ParserVersion == cur.ParserVersion {
+				continue
+			}
+			if opts.DryRun {
+				continue
+			}
+			if err := s.storePost(ctx, upsertPostParams(row.OwnerID, row.PostID, row.Date, row.Text, row.Photos, row.Repost, cur, ex), cur); err != nil {
+				return nil, fmt.Errorf("update post %d_%d: %w", row.OwnerID, row.PostID, err)
+			}
+			sum.Written++
+			if !opts.Enqueue {
+				continue
+			}
+			was, now := s.routes.match(old), s.routes.match(cur)
+			if d := (dests{tg: now.tg && !was.tg, vk: now.vk && !was.vk}); d.any() {
+				s.enqueue(ctx, row.OwnerID, row.PostID, d, cur.Urgency)
+				sum.Enqueued++
+			}
+		}
+		if len(rows) < opts.Batch {
+			break
+		}
+		last := rows[len(rows)-1]
+		params.AfterOwnerID, params.AfterPostID = last.OwnerID, last.PostID
+		slog.Debug("reparse progress", "scanned", sum.Scanned, "changed", sum.Changed)
+	}
+	return sum, nil
+}
+
+// fieldChange is a single differing field between the stored and the fresh
+// parse. From/To are empty for free-text fields where values are not grouped.
+type fieldChange struct {
+	Field string
+	From  string
+	To    string
+}
+
+// diffPosts compares the fields persisted on posts. Enum and boolean fields
+// report their transition, free-text fields only that they changed.
+func diffPosts(old, cur lostdogs.Post) []fieldChange {
+	var out []fieldChange
+	enum := func(field, a, b string) {
+		// Parse leaves enums empty for empty/link posts; the DB stores "unknown".
+		if a, b = enumOr(a), enumOr(b); a != b {
+			out = append(out, fieldChange{Field: field, From: a, To: b})
+		}
+	}
+	text := func(field, a, b string) {
+		if a != b {
+			out = append(out, fieldChange{Field: field})
+		}
+	}
+	boolean := func(field string, a, b bool) {
+		if a != b {
+			out = append(out, fieldChange{Field: field, From: strconv.FormatBool(a), To: strconv.FormatBool(b)})
+		}
+	}
+	source := func(field string, a, b lostdogs.Source) {
+		if a != b {
+			out = append(out, fieldChange{Field: field, From: sourceOr(a), To: sourceOr(b)})
+		}
+	}
+	list := func(field string, a, b []string) {
+		text(field, strings.Join(a, "\x00"), strings.Join(b, "\x00"))
+	}
+
+	enum("type", string(old.Type), string(cur.Type))
+	enum("animal", string(old.Animal), string(cur.Animal))
+	enum("species", old.Species, cur.Species)
+	source("type_source", old.TypeSource, cur.TypeSource)
+	source("animal_source", old.AnimalSource, cur.AnimalSource)
+	enum("sex", string(old.Sex), string(cur.Sex))
+	text("breed", old.Breed, cur.Breed)
+	enum("breed_id", old.BreedID, cur.BreedID)
+	boolean("breed_mixed", old.BreedMixed, cur.BreedMixed)
+	text("age", old.Age, cur.Age)
+	text("age_range", fmt.Sprint(old.AgeMinMonths, old.AgeMaxMonths), fmt.Sprint(cur.AgeMinMonths, cur.AgeMaxMonths))
+	enum("age_class", string(old.AgeClass), string(cur.AgeClass))
+	text("name", old.Name, cur.Name)
+	text("location", old.Location, cur.Location)
+	text("address", old.Address, cur.Address)
+	enum("district", old.District, cur.District)
+	text("when", old.When, cur.When)
+	text("when_range", whenKey(old), whenKey(cur))
+	text("contacts", contactsKey(old.Contacts), contactsKey(cur.Contacts))
+	list("contact_names", old.ContactNames, cur.ContactNames)
+	boolean("sterilized", old.Extras.Sterilized, cur.Extras.Sterilized)
+	boolean("vaccinated", old.Extras.Vaccinated, cur.Extras.Vaccinated)
+	boolean("chipped", old.Extras.Chipped, cur.Extras.Chipped)
+	boolean("litter_ok", old.Extras.LitterOK, cur.Extras.LitterOK)
+	list("colors", old.Appearance.Colors, cur.Appearance.Colors)
+	enum("coat", string(old.Appearance.Coat), string(cur.Appearance.Coat))
+	list("gear", old.Appearance.Gear, cur.Appearance.Gear)
+	list("marks", old.Appearance.Marks, cur.Appearance.Marks)
+	enum("size", string(old.Size.Class), string(cur.Size.Class))
+	text("weight", fmt.Sprint(old.Size.WeightMinKg, old.Size.WeightMaxKg), fmt.Sprint(cur.Size.WeightMinKg, cur.Size.WeightMaxKg))
+	text("height", fmt.Sprint(old.Size.HeightMinCm, old.Size.HeightMaxCm), fmt.Sprint(cur.Size.HeightMinCm, cur.Size.HeightMaxCm))
+	enum("build", string(old.Size.Build), string(cur.Size.Build))
+	enum("urgency", string(old.Urgency), string(cur.Urgency))
+	list("health", old.Health, cur.Health)
+	text("ids", idsKey(old.IDs), idsKey(cur.IDs))
+	boolean("reward", old.Reward, cur.Reward)
+	text("reward_amount", fmt.Sprint(old.RewardAmount), fmt.Sprint(cur.RewardAmount))
+	boolean("scam_risk", old.ScamRisk, cur.ScamRisk)
+	enum("role", string(old.Role), string(cur.Role))
+	enum("role_source", string(old.RoleSource), string(cur.RoleSource))
+	text("status_details", old.StatusDetails, cur.StatusDetails)
+	boolean("resolved", old.Resolved, cur.Resolved)
+	text("animals", partsKey(old.Parts), partsKey(cur.Parts))
+	return out
+}
+
+// whenKey identifies a resolved When interval; stored times have second
+// precision, so compare at that granularity.
+func whenKey(p lostdogs.Post) string {
+	if p.WhenFrom.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%d-%d-%s", p.WhenFrom.Unix(), p.WhenTo.Unix(), p.WhenPrecision)
+}
+
+// contactsKey identifies the contacts of a post in order.
+func contactsKey(cs []lostdogs.Contact) string {
+	var b strings.Builder
+	for _, c := range cs {
+		b.WriteString(c.String() + "|" + c.Name + "\x00")
+	}
+	return b.String()
+}
+
+// idsKey identifies the identifiers of a post in order.
+func idsKey(ids []lostdogs.Identifier) string {
+	var b strings.Builder
+	for _, id := range ids {
+		b.WriteString(id.String() + "\x00")
+	}
+	return b.String()
+}
+
+// partsKey identifies the stored fields of the parts of a multi-animal post.
+func partsKey(parts []lostdogs.Post) string {
+	var b strings.Builder
+	for _, a := range parts {
+		fmt.Fprintf(&b, "%s|%s|%s|%s|%s|%s|%s|%d-%d|%s\x00", a.Raw, enumOr(string(a.Type)), enumOr(string(a.Animal)), a.Species,
+			enumOr(string(a.Sex)), a.Name, a.BreedID, a.AgeMinMonths, a.AgeMaxMonths, strings.Join(a.Phones(), ","))
+	}
+	return b.String()
+}
+
+func sourceOr(s lostdogs.Source) string {
+	if s == "" {
+		return "none"
+	}
+	return string(s)
+}
+
+type reparseSummary struct {
+	DryRun   bool
+	Scanned  int
+	Changed  int // posts with at least one field change
+	Written  int
+	Enqueued int
+	counts   map[fieldChange]int
+}
+
+func newReparseSummary(dryRun bool) *reparseSummary {
+	return &reparseSummary{DryRun: dryRun, counts: map[fieldChange]int{}}
+}
+
+func (s *reparseSummary) Add(changes []fieldChange) {
+	s.Scanned++
+	if len(changes) > 0 {
+		s.Changed++
+	}
+	for _, c := range changes {
+		s.counts[c]++
+	}
+}
+
+// Lines returns the summary lines ordered by count, most frequent first,
+// e.g. "37 posts changed type unknown→lost".
+func (s *reparseSummary) Lines() []string {
+	keys := make([]fieldChange, 0, len(s.counts))
+	for k := range s.counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if s.counts[a] != s.counts[b] {
+			return s.counts[a] > s.counts[b]
+		}
+		if a.Field != b.Field {
+			return a.Field < b.Field
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	out := make([]string, 0, len(keys))
+	for _, k := range keys {
+		line := fmt.Sprintf("%d posts changed %s", s.counts[k], k.Field)
+		if k.From != "" || k.To != "" {
+			line += " " + k.From + "→" + k.To
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+func (s *reparseSummary) Print(w io.Writer) {
+	mode := ""
+	if s.DryRun {
+		mode = " (dry run, nothing written)"
+	}
+	fmt.Fprintf(w, "scanned %d posts, %d changed, %d written, %d enqueued%s\n", s.Scanned, s.Changed, s.Written, s.Enqueued, mode)
+	for _, l := range s.Lines() {
+		fmt.Fprintln(w, "  "+l)
+	}
+}
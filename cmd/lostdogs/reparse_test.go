@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sqldb "github.com/jehaby/lostdogs/internal/db"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDay_Izhevsk(t *testing.T) {
+	d, err := parseDay("2025-09-01")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 8, 31, 20, 0, 0, 0, time.UTC), d.UTC())
+
+	d, err = parseDay("")
+	require.NoError(t, err)
+	require.True(t, d.IsZero())
+}
+
+func TestReparse_UpdatesStaleRows(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t, "memdb_reparse")
+	ctx := context.Background()
+
+	// Rows as an older parser would have stored them.
+	stale := []sqldb.UpsertPostParams{
+		{OwnerID: -1, PostID: 1, Date: 100, Text: "Пропала собака в районе Буммаша", Raw: "Пропала собака в районе Буммаша", Type: "unknown", Animal: "dog", Sex: "unknown"},
+		{OwnerID: -1, PostID: 2, Date: 200, Text: "Найден кот во дворе на Пушкинской", Raw: "Найден кот во дворе на Пушкинской", Type: "unknown", Animal: "unknown", Sex: "unknown"},
+		{OwnerID: -2, PostID: 1, Date: 300, Text: "Пропала собака в районе Буммаша", Raw: "Пропала собака в районе Буммаша", Type: "unknown", Animal: "dog", Sex: "unknown"},
+	}
+	for _, p := range stale {
+		require.NoError(t, svc.queries.UpsertPost(ctx, p))
+	}
+
+	// Dry run reports but leaves the rows untouched.
+	sum, err := svc.reparse(ctx, reparseOptions{DryRun: true, Batch: 2})
+	require.NoError(t, err)
+	require.Equal(t, 3, sum.Scanned)
+	require.Equal(t, 3, sum.Changed)
+	require.Equal(t, 0, sum.Written)
+	require.Contains(t, sum.Lines(), "2 posts changed type unknown→lost")
+	require.Contains(t, sum.Lines(), "1 posts changed animal unknown→cat")
+
+	row, err := svc.queries.GetPost(ctx, sqldb.GetPostParams{OwnerID: -1, PostID: 1})
+	require.NoError(t, err)
+	require.Equal(t, "unknown", row.Type)
+
+	// Real run restricted to one group writes and enqueues.
+	owner := int64(-1)
+	sum, err = svc.reparse(ctx, reparseOptions{OwnerID: &owner, Enqueue: true})
+	require.NoError(t, err)
+	require.Equal(t, 2, sum.Scanned)
+	require.Equal(t, 2, sum.Written)
+	require.Equal(t, 1, sum.Enqueued)
+
+	row, err = svc.queries.GetPost(ctx, sqldb.GetPostParams{OwnerID: -1, PostID: 1})
+	require.NoError(t, err)
+	require.Equal(t, "lost", row.Type)
+	require.NotNil(t, row.ParserVersion)
+
+	var queued int
+	require.NoError(t, svc.db.QueryRowContext(ctx, "SELECT COUNT(1) FROM outbox").Scan(&queued))
+	require.Equal(t, 1, queued)
+
+	// A second pass finds nothing left to do.
+	sum, err = svc.reparse(ctx, reparseOptions{OwnerID: &owner})
+	require.NoError(t, err)
+	require.Equal(t, 0, sum.Changed)
+	require.Equal(t, 0, sum.Written)
+}
+
+func TestReparse_PosterRoleIgnoresLaterPosts(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t, "memdb_reparse_role")
+	ctx := context.Background()
+
+	lost := "Пропала наша собака, рыжая сука, район Буммаш. 89120281683"
+	require.NoError(t, svc.SaveMessage(-1, 1, 1700000000, lost, normalize(lost), "", nil, false))
+
+	// The phone later turns up in posts about three other animals.
+	for i, breed := range []string{"хаски", "такса", "лабрадор"} {
+		raw := "Пропал пёс, " + breed + ", кобель, район Буммаш. 89120281683"
+		require.NoError(t, svc.SaveMessage(-2, i+1, 1700001000, raw, normalize(raw), "", nil, false))
+	}
+
+	// Reparsing the first post sees only what was there when it was posted.
+	owner := int64(-1)
+	sum, err := svc.reparse(ctx, reparseOptions{OwnerID: &owner})
+	require.NoError(t, err)
+	require.Equal(t, 1, sum.Scanned)
+	require.Equal(t, 0, sum.Changed, sum.Lines())
+
+	row, err := svc.queries.GetPost(ctx, sqldb.GetPostParams{OwnerID: -1, PostID: 1})
+	require.NoError(t, err)
+	require.Equal(t, "owner", *row.Role)
+	require.Equal(t, "text", *row.RoleSource)
+}
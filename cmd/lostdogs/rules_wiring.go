@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/jehaby/lostdogs"
+	"github.com/jehaby/lostdogs/internal/nbayes"
+)
+
+// loadParser builds a parser from the configured rules file (the embedded
+// default rules when unset) and attaches the fallback model, if any.
+func loadParser(cfg dbConfig) (*lostdogs.Parser, error) {
+	p := lostdogs.DefaultParser()
+	if cfg.RulesFile != "" {
+		b, err := os.ReadFile(cfg.RulesFile)
+		if err != nil {
+			return nil, err
+		}
+		r, err := lostdogs.LoadRules(b)
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range r.Warnings() {
+			slog.Warn("parser rules", "file", cfg.RulesFile, "warning", w)
+		}
+		p = lostdogs.NewParser(r)
+	}
+	if cfg.ModelFile != "" {
+		m, err := nbayes.Load(cfg.ModelFile)
+		if err != nil {
+			return nil, fmt.Errorf("load model: %w", err)
+		}
+		p = p.WithClassifier(m)
+	}
+	return p, nil
+}
+
+// rulesStart installs the configured rules and, for a RULES_FILE, starts a
+// background poller that swaps in the file whenever it changes. A file that
+// fails validation is logged and the previous rules stay active.
+func rulesStart(svc *service, cfg dbConfig) error {
+	p, err := loadParser(cfg)
+	if err != nil {
+		return err
+	}
+	svc.parser.Store(p)
+	slog.Info("parser rules loaded", "file", cfg.RulesFile, "version", p.Version())
+	if cfg.RulesFile == "" || cfg.RulesReloadInterval <= 0 {
+		return nil
+	}
+	fi, err := os.Stat(cfg.RulesFile)
+	if err != nil {
+		return err
+	}
+	go watchRules(svc, cfg, fi.ModTime())
+	return nil
+}
+
+func watchRules(svc *service, cfg dbConfig, last time.Time) {
+	path := cfg.RulesFile
+	ticker := time.NewTicker(cfg.RulesReloadInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		fi, err := os.Stat(path)
+		if err != nil {
+			slog.Warn("rules file stat failed", "file", path, "err", err)
+			continue
+		}
+		if fi.ModTime().Equal(last) {
+			continue
+		}
+		last = fi.ModTime()
+		p, err := loadParser(cfg)
+		if err != nil {
+			slog.Error("rules reload failed; keeping previous rules", "file", path, "err", err)
+			continue
+		}
+		svc.parser.Store(p)
+		slog.Info("parser rules reloaded", "file", path, "version", p.Version())
+	}
+}
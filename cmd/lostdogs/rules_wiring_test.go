@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jehaby/lostdogs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRulesStart_HotReload(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "rules.yml")
+	require.NoError(t, os.WriteFile(path, lostdogs.DefaultRulesYAML(), 0o644))
+
+	svc := &service{}
+	require.NoError(t, rulesStart(svc, dbConfig{RulesFile: path, RulesReloadInterval: 10 * time.Millisecond}))
+	text := "Потеряшка! Рыжий пёс у ТЦ Италмас, звоните"
+	require.Equal(t, lostdogs.TypeUnknown, svc.currentParser().Parse(0, text).Type)
+
+	// An invalid file is rejected and the previous rules stay active.
+	before := svc.currentParser()
+	writeRules(t, path, "version: '2'\ntype: {}\n", time.Now().Add(time.Second))
+	time.Sleep(50 * time.Millisecond)
+	require.Same(t, before, svc.currentParser())
+
+	edited := strings.Replace(string(lostdogs.DefaultRulesYAML()), `lemmas: [пропал,`, `lemmas: [потеряшка, пропал,`, 1)
+	writeRules(t, path, edited, time.Now().Add(2*time.Second))
+	require.Eventually(t, func() bool {
+		return svc.currentParser().Parse(0, text).Type == lostdogs.TypeLost
+	}, time.Second, 10*time.Millisecond)
+}
+
+// writeRules writes the file with an explicit mtime so the change is visible
+// even on filesystems with coarse timestamps.
+func writeRules(t *testing.T, path, body string, mtime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o644))
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/caarlos0/env/v11"
+	sqldb "github.com/jehaby/lostdogs/internal/db"
+	"github.com/jehaby/lostdogs/internal/nbayes"
+)
+
+// runTrain implements `lostdogs train`: fit the naive Bayes fallback on the
+// gold labels and write it where MODEL_FILE can point to.
+func runTrain(args []string) error {
+	fs := flag.NewFlagSet("train", flag.ContinueOnError)
+	var (
+		out  = fs.String("out", "model.json.gz", "where to write the trained model")
+		minN = fs.Int("min-n", 2, "shortest character n-gram")
+		maxN = fs.Int("max-n", 4, "longest character n-gram")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *minN < 1 || *maxN < *minN {
+		return fmt.Errorf("bad n-gram range %d..%d", *minN, *maxN)
+	}
+
+	cfg := dbConfig{}
+	if err := env.Parse(&cfg); err != nil {
+		return fmt.Errorf("parse config: %w", err)
+	}
+	initLogger(cfg.LogLevel)
+	svc := newDBService(cfg)
+	defer svc.db.Close()
+
+	labels, err := svc.queries.ListLabels(context.Background())
+	if err != nil {
+		return fmt.Errorf("list labels: %w", err)
+	}
+	m := trainModel(labels, *minN, *maxN)
+	if len(m.Fields) == 0 {
+		return fmt.Errorf("no usable gold labels yet; run `lostdogs label` first")
+	}
+	if err := m.Save(*out); err != nil {
+		return fmt.Errorf("save model: %w", err)
+	}
+	for _, f := range []string{"type", "animal"} {
+		if c := m.Fields[f]; c != nil {
+			fmt.Printf("%-6s trained on %d posts, labels: %v\n", f, docCount(c), c.Labels())
+		}
+	}
+	fmt.Printf("model written to %s\n", *out)
+	return nil
+}
+
+// trainModel fits one classifier per field on the labeled raw texts. Types
+// that Parse decides structurally (empty, link) are left out; "unknown" is
+// kept so the model can learn to abstain.
+func trainModel(labels []sqldb.ListLabelsRow, minN, maxN int) *nbayes.Model {
+	m := nbayes.NewModel()
+	add := func(field string, label *string, text string) {
+		if label == nil {
+			return
+		}
+		c := m.Fields[field]
+		if c == nil {
+			c = nbayes.New(minN, maxN)
+			m.Fields[field] = c
+		}
+		c.Train(text, *label)
+	}
+	for _, l := range labels {
+		if l.Type == nil || (*l.Type != "empty" && *l.Type != "link") {
+			add("type", l.Type, l.Raw)
+			add("animal", l.Animal, l.Raw)
+		}
+	}
+	return m
+}
+
+func docCount(c *nbayes.Classifier) int {
+	n := 0
+	for _, d := range c.Docs {
+		n += d
+	}
+	return n
+}
@@ -0,0 +1,216 @@
+package lostdogs
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Contacts are the ways to reach the author: phones, VK pages, Telegram and
+// WhatsApp, e-mail, or just "пишите в личку", a VK message to the author.
+
+type ContactKind string
+
+const (
+	ContactPhone    ContactKind = "phone"    // "+79120281683"
+	ContactVK       ContactKind = "vk"       // "id123", "durov", "vk.cc/aBc"; "" for the author's messages
+	ContactTelegram ContactKind = "telegram" // "@username" or a +7 phone
+	ContactWhatsApp ContactKind = "whatsapp" // a +7 phone
+	ContactEmail    ContactKind = "email"    // lower-cased address
+)
+
+type Contact struct {
+	Kind  ContactKind
+	Value string
+	Name  string // display name of a VK mention, "" otherwise
+}
+
+// String is "kind:value", e.g. "telegram:@username".
+func (c Contact) String() string {
+	return string(c.Kind) + ":" + c.Value
+}
+
+// Phones returns the phone numbers among the post's contacts.
+func (p Post) Phones() []string {
+	var out []string
+	for _, c := range p.Contacts {
+		if c.Kind == ContactPhone {
+			out = append(out, c.Value)
+		}
+	}
+	return out
+}
+
+// ContactsSpec is the optional `contacts` section of a rule set.
+type ContactsSpec struct {
+	DM       []string `yaml:"dm"`       // "пишите в личку": message the author on VK
+	Telegram []string `yaml:"telegram"` // words that make a phone or @handle next to them Telegram's
+	WhatsApp []string `yaml:"whatsapp"`
+	VK       []string `yaml:"vk"`    // words that make the next @handle a VK page
+	Other    []string `yaml:"other"` // networks whose @handles are not contacts: "инстаграм"
+}
+
+type contactRules struct {
+	dm, telegram, whatsapp, vk, other *regexp.Regexp // whole words; nil when unset
+}
+
+func (c *compiler) contacts(path string, spec ContactsSpec) contactRules {
+	return contactRules{
+		dm:       c.optionalWords(path+".dm", spec.DM),
+		telegram: c.optionalWords(path+".telegram", spec.Telegram),
+		whatsapp: c.optionalWords(path+".whatsapp", spec.WhatsApp),
+		vk:       c.optionalWords(path+".vk", spec.VK),
+		other:    c.optionalWords(path+".other", spec.Other),
+	}
+}
+
+var (
+	reEmail       = regexp.MustCompile(`(?i)[a-z0-9][a-z0-9._%+-]*@[a-z0-9-]+(?:\.[a-z0-9-]+)*\.[a-z]{2,}`)
+	reTelegramURL = regexp.MustCompile(`(?i)(?:^|[^\w.])(?:https?://)?(?:t|telegram)\.me/([a-z][a-z0-9_]{3,31})`)
+	reWhatsAppURL = regexp.MustCompile(`(?i)(?:https?://)?(?:wa\.me/|api\.whatsapp\.com/send/?\?phone=)\+?(\d{10,11})`)
+	reVKPage      = regexp.MustCompile(`(?i)(?:https?://)?(?:m\.)?vk\.(?:com|ru)/([\w.-]+)`)
+	reVKShort     = regexp.MustCompile(`(?i)(?:https?://)?vk\.cc/([a-z0-9]+)`)
+
+	// An @handle not inside an e-mail or a URL. VK ids ("@id123") are VK's.
+	reHandle   = regexp.MustCompile(`(?:^|[^\w@./])@([A-Za-z][A-Za-z0-9_.]{3,31})`)
+	reVKNumID  = regexp.MustCompile(`^(?:id|club|public)\d+$`)
+	reVKObject = regexp.MustCompile(`(?i)^(?:wall|photo|video|album|topic|clip|market|doc|audio|story|board|note|poll|page|product)-?\d|^(?:away\.php|feed|im|search|app\d+)$`)
+
+	// What may stand between a phone and a messenger word after it:
+	// "89120281683 (WhatsApp)", "89120281683 / телеграм".
+	reMessengerAfter = regexp.MustCompile(`^\s*[(/]?\s*$`)
+
+	// A sentence break; "тел.: 8912..." is not one.
+	reSentenceBreak = regexp.MustCompile(`[.!?]+\s+\p{Lu}|[!?\n]`)
+)
+
+// messengerWindow is how far before a phone or @handle a messenger word is
+// looked for.
+const messengerWindow = 40
+
+// extractContacts returns the contacts of the post in the order they appear.
+// A phone next to a messenger word is also that messenger's contact.
+func (sc *scan) extractContacts() []Contact {
+	type found struct {
+		Contact
+		at int
+	}
+	var out []found
+	add := func(c Contact, rule string, start, end int) {
+		if slices.ContainsFunc(out, func(f found) bool { return f.Kind == c.Kind && f.Value == c.Value }) {
+			return
+		}
+		out = append(out, found{c, start})
+		sc.record("contacts", rule, start, end)
+	}
+	cr := sc.rules.contacts
+
+	for _, rg := range sc.indexAll(rePhone) {
+		ph := normalizePhone(sc.s[rg[0]:rg[1]])
+		if ph == "" {
+			continue
+		}
+		add(Contact{Kind: ContactPhone, Value: ph}, "phone", rg[0], rg[1])
+		for _, m := range []struct {
+			kind ContactKind
+			re   *regexp.Regexp
+		}{{ContactWhatsApp, cr.whatsapp}, {ContactTelegram, cr.telegram}} {
+			if sc.nearWord(m.re, rg[0], rg[1]) {
+				add(Contact{Kind: m.kind, Value: ph}, string(m.kind), rg[0], rg[1])
+			}
+		}
+	}
+	for _, m := range sc.submatchAll(reWhatsAppURL) {
+		if ph := normalizePhone(sc.s[m[2]:m[3]]); ph != "" {
+			add(Contact{Kind: ContactWhatsApp, Value: ph}, "whatsapp", m[0], m[1])
+		}
+	}
+	for _, m := range sc.submatchAll(reTelegramURL) {
+		add(Contact{Kind: ContactTelegram, Value: "@" + sc.s[m[2]:m[3]]}, "telegram", m[0], m[1])
+	}
+	for _, m := range sc.submatchAll(reVKBracket) {
+		add(Contact{Kind: ContactVK, Value: sc.s[m[2]:m[3]], Name: sc.s[m[4]:m[5]]}, "vk_mention", m[0], m[1])
+	}
+	for _, m := range sc.submatchAll(reVKPage) {
+		if page := strings.TrimRight(sc.s[m[2]:m[3]], "."); page != "" && !reVKObject.MatchString(page) {
+			add(Contact{Kind: ContactVK, Value: page}, "vk_link", m[0], m[1])
+		}
+	}
+	for _, m := range sc.submatchAll(reVKShort) {
+		add(Contact{Kind: ContactVK, Value: "vk.cc/" + sc.s[m[2]:m[3]]}, "vk_link", m[0], m[1])
+	}
+	for _, m := range sc.submatchAll(reHandle) {
+		h := strings.TrimRight(sc.s[m[2]:m[3]], ".")
+		end := m[2] + len(h)
+		switch {
+		case reVKNumID.MatchString(h) || sc.nearWord(cr.vk, m[2]-1, end):
+			add(Contact{Kind: ContactVK, Value: h}, "vk_handle", m[2]-1, end)
+		case sc.nearWord(cr.other, m[2]-1, end):
+		default:
+			add(Contact{Kind: ContactTelegram, Value: "@" + h}, "telegram", m[2]-1, end)
+		}
+	}
+	for _, m := range sc.indexAll(reEmail) {
+		add(Contact{Kind: ContactEmail, Value: strings.ToLower(sc.s[m[0]:m[1]])}, "email", m[0], m[1])
+	}
+	if cr.dm != nil {
+		if m := sc.firstAffirmed(cr.dm); m != nil {
+			add(Contact{Kind: ContactVK}, "dm", m[2], m[3])
+		}
+	}
+
+	slices.SortStableFunc(out, func(a, b found) int { return a.at - b.at })
+	contacts := make([]Contact, len(out))
+	for i, f := range out {
+		contacts[i] = f.Contact
+	}
+	if len(contacts) > 0 {
+		sc.decide("contacts", string(contacts[0].Kind), 1)
+	}
+	return contacts
+}
+
+// nearWord reports whether a word of re stands in the same sentence before
+// [start, end) with no other phone in between, or right after it.
+func (sc *scan) nearWord(re *regexp.Regexp, start, end int) bool {
+	if re == nil || !sc.may(re) {
+		return false
+	}
+	from := max(0, start-messengerWindow)
+	for from > 0 && !utf8.RuneStart(sc.s[from]) {
+		from--
+	}
+	before := sc.s[from:start]
+	if bs := reSentenceBreak.FindAllStringIndex(before, -1); len(bs) > 0 {
+		before = before[bs[len(bs)-1][0]+1:]
+	}
+	if ps := rePhone.FindAllStringIndex(before, -1); len(ps) > 0 {
+		before = before[ps[len(ps)-1][1]:]
+	}
+	if re.MatchString(before) {
+		return true
+	}
+	m := re.FindStringSubmatchIndex(sc.s[end:])
+	return m != nil && reMessengerAfter.MatchString(sc.s[end:end+m[2]])
+}
+
+// withoutChipContacts drops phone contacts that are digits of a chip number.
+func withoutChipContacts(cs []Contact, ids []Identifier) []Contact {
+	return slices.DeleteFunc(cs, func(c Contact) bool {
+		if !strings.HasPrefix(c.Value, "+7") {
+			return false
+		}
+		return slices.ContainsFunc(ids, func(id Identifier) bool {
+			return id.Kind == IDChip && strings.Contains(id.Value, c.Value[2:])
+		})
+	})
+}
+
+func contactsText(cs []Contact) string {
+	parts := make([]string, len(cs))
+	for i, c := range cs {
+		parts[i] = c.String()
+	}
+	return strings.Join(parts, ", ")
+}
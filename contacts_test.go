@@ -0,0 +1,50 @@
+package lostdogs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_Contacts(t *testing.T) {
+	cases := []struct {
+		text string
+		want []Contact
+	}{
+		{
+			text: "Пропала собака! Звоните 89120281683 (WhatsApp), пишите в личку",
+			want: []Contact{{Kind: ContactPhone, Value: "+79120281683"}, {Kind: ContactWhatsApp, Value: "+79120281683"}, {Kind: ContactVK}},
+		},
+		{
+			text: "Найден пёс. Ватсап, телеграм: 8 912 028-16-83. Почта Foo.Bar@mail.ru",
+			want: []Contact{
+				{Kind: ContactPhone, Value: "+79120281683"}, {Kind: ContactWhatsApp, Value: "+79120281683"},
+				{Kind: ContactTelegram, Value: "+79120281683"}, {Kind: ContactEmail, Value: "foo.bar@mail.ru"},
+			},
+		},
+		{
+			text: "Пропал кот, тел.: 89120281683, телеграм @lost_cat_izh, инста @catsizh",
+			want: []Contact{{Kind: ContactPhone, Value: "+79120281683"}, {Kind: ContactTelegram, Value: "@lost_cat_izh"}},
+		},
+		{
+			text: "Найдена собака, подробности t.me/izhdogs, vk.com/id123, vk.cc/aBc12, фото vk.com/wall-1_2",
+			want: []Contact{{Kind: ContactTelegram, Value: "@izhdogs"}, {Kind: ContactVK, Value: "id123"}, {Kind: ContactVK, Value: "vk.cc/aBc12"}},
+		},
+		{
+			text: "Нашли кошку [id123|Ольга Петрова] звоните 8912 0281683 или 89991234567 ватсап",
+			want: []Contact{
+				{Kind: ContactVK, Value: "id123", Name: "Ольга Петрова"}, {Kind: ContactPhone, Value: "+79120281683"},
+				{Kind: ContactPhone, Value: "+79991234567"}, {Kind: ContactWhatsApp, Value: "+79991234567"},
+			},
+		},
+		{text: "Пропала собака у рынка, страница вк @durov.", want: []Contact{{Kind: ContactVK, Value: "durov"}}},
+		{text: "Ищет дом кот, 2 года, пишите в лс или в личные сообщения группы", want: []Contact{{Kind: ContactVK}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.text, func(t *testing.T) {
+			p, ex := ParseExplain(1, tc.text)
+			assert.Equal(t, tc.want, p.Contacts)
+			assert.Equal(t, contactsText(tc.want), ex.Field("contacts").Value)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 	"unicode"
 )
 
@@ -13,19 +14,50 @@ type Post struct {
 	Raw           string
 	Type          PostType
 	Animal        AnimalType
-	Breed         string
+	Species       string // for AnimalOther: "parrot", "rabbit", "horse"...; see the rules' species section
+	Breed         string // catalogue name, or BreedMixedName for an unnamed mix
+	BreedID       string // catalogue ID, see BreedCatalog; empty when no breed was named
+	BreedMixed    bool   // "метис", "дворняжка": Breed, if any, is the dominant one
 	Sex           SexType
-	Age           string
+	Age           string // age phrase as written, for display
+	AgeMinMonths  int    // age range in months; AgeMaxMonths is 0 when unknown
+	AgeMaxMonths  int
+	AgeClass      AgeClass
 	Name          string
 	Location      string
+	Address       string  // gazetteer-normalized place, e.g. "ул. Пушкинская, 283"
+	District      string  // city or rural district of Address
+	Lat, Lon      float64 // approximate coordinates; zero when no place matched
 	When          string
-	Phones        []string
+	WhenFrom      time.Time // resolved When interval [WhenFrom, WhenTo) in Izhevsk time
+	WhenTo        time.Time
+	WhenPrecision WhenPrecision // empty when When could not be resolved
+	Contacts      []Contact     // phones, VK pages, messengers, e-mail; see contacts.go
 	ContactNames  []string
-	VKAccounts    []string
 	Extras        Extras
+	Appearance    Appearance
+	Size          Size
+	Urgency       Urgency      // empty for empty and link-only posts
+	Health        []string     // injury and health flags, e.g. "hit_by_car", "limp"
+	IDs           []Identifier // chip numbers, tattoo codes, tag phones
+	Reward        bool         // a reward is promised
+	RewardAmount  int          // in rubles; 0 when not given
+	ScamRisk      bool         // a found post asks to pay for the pet's return
+	Role          Role         // who wrote the post, see role.go
+	RoleSource    RoleSource   // empty when the role is unknown
 	StatusDetails string
+	Resolved      bool   // "нашлась", "хозяева найдены": the case is closed
+	Parts         []Post // one per animal of an enumerated post, see parts.go; nil otherwise
+	ParserVersion string
+	TypeSource    Source // empty when nothing decided the type
+	AnimalSource  Source
 }
 
+// ParserVersion identifies the extraction code that produced a Post. Bump it
+// whenever the Go side of the parser changes in a way that affects stored
+// results; rule changes are tracked by Rules.Version.
+const ParserVersion = "15"
+
 // Controlled enums
 type PostType string
 
@@ -65,86 +97,148 @@ type Extras struct {
 	LitterOK   bool
 }
 
-// Compiled regexes (case-insensitive where needed)
+// Compiled regexes for structural patterns (phones, dates, links...).
+// Keyword vocabularies live in the rule set, see rules.go.
 var (
-	reSpace       = regexp.MustCompile(`\s+`)
-	reLost        = regexp.MustCompile(`(?i)(пропал[аи]?|потерял[асься]?|убежал[аи]?|сбежал[аи]?)`)
-	reFound       = regexp.MustCompile(`(?i)(найден[аоы]?|нашл[аи]|подобрал[аи])`)
-	reSighting    = regexp.MustCompile(`(?i)(замечен[ао]?|видел[аи]?|бегает|появил[асься])`)
-	reAdoption    = regexp.MustCompile(`(?i)(ищет\s+дом|в\s+добрые\s+руки|отда[ёмм]|пристраив[ае])`)
-	reCareMarkers = regexp.MustCompile(`(?i)(стерилиз|кастрир|вакц|привит|чипир|лоток|лотк)`) // for adoption leaning
-	// Use Unicode-safe boundaries: ASCII \b doesn't handle Cyrillic correctly.
-	reFundraising  = regexp.MustCompile(`(?i)(^|[^\p{L}\d])(сбор|оплатить|перевод|передержк|карта)([^\p{L}\d]|$)`)
-	reTieFoundSpec = regexp.MustCompile(`(?i)найден\S*.*(кот|собак|п[её]с|кобел|щен|живот)`) // specific found pattern
+	reSpace = regexp.MustCompile(`\s+`)
 
 	rePhone = regexp.MustCompile(`(?:(?:\+7|8)\s*\(?\d{3}\)?[\s-]?\d{3}[\s-]?\d{2}[\s-]?\d{2}|\b9\d{2}[\s-]?\d{3}[\s-]?\d{2}[\s-]?\d{2}\b|\b7\d{10}\b)`)
 
 	reVKURL     = regexp.MustCompile(`(?i)vk\.com/\S+`)
 	reVKBracket = regexp.MustCompile(`\[(id\d+)\|([^\]]+)\]`)
 
-	// Note: Go RE2 doesn't support negative lookahead; keep it simple.
-	// Avoid ASCII word-boundaries; use explicit fragments and common punctuation/space/end checks.
-	reCat  = regexp.MustCompile(`(?i)кошечк|кошк|кот(?:\s|[.,!?:;]|$)|кот[её]н|кис[ао]ньк|бенгальск`)
-	reDog  = regexp.MustCompile(`(?i)собак|пс|п[её]с(?:\s|$)|п[её]сик|кобел|щен`)
-	reMale = regexp.MustCompile(`(?i)кобел(ё|е)к|мальчик`)
-	reFem  = regexp.MustCompile(`(?i)девочк|сука`)
-
-	reBreed = regexp.MustCompile(`(?i)бенгальск[аяийое]|йорк(?:шир(?:ск(?:ий|ая))?)?|лабрадор|овчарк|хаск|такс|спаниел|метис`)
-
 	// Allow compact forms like "2х месяцев" (optional x/х after number).
 	reAge  = regexp.MustCompile(`(?i)(\d+[,.]?\d*|\d+\s*-\s*\d+)\s*[xх]?\s*(мес|месяц|месяцев|год|года|лет)`) // capture first concise
 	reDate = regexp.MustCompile(`\b\d{1,2}[.]\d{1,2}[.]\d{2,4}\b`)
-	reTime = regexp.MustCompile(`(?i)(?:в\s*)?\b\d{1,2}[:.]\d{2}\b`)
-
-	reLocTrigger = regexp.MustCompile(`(?i)улиц|шосс|просп|пер(?:е)?ул|площад|бульвар|район|снт|город|деревн|пос(?:е)?лок|Ижевск|Закирова|Первомайск|Люкшудья|Шабердино|Воткинск`)
 
 	reCapCyrWord = regexp.MustCompile(`\b[А-ЯЁ][а-яё]{2,}\b`)
 
-	reStatus = regexp.MustCompile(`(?i)рыж|белоснежн|пуглив|ласков|игрив|домашн|без\s*ошейн|кастрир|стерилиз|вакцин|чипир|лоток`)
-
 	reName1 = regexp.MustCompile(`(?i)(?:кличка|зовут)[^\n]{0,20}\s+([A-Za-zА-Яа-яЁё][\p{L}-]{2,})`)
 	reName2 = regexp.MustCompile(`(?i)кошк[аи][^\n]{0,20}\s+([A-Za-zА-Яа-яЁё][\p{L}-]{2,})`)
 )
 
-// Parse returns a fully-populated Post from raw text. Pure and deterministic.
-func Parse(id int, raw string) Post {
-	s := normalizeSpace(raw)
-	p := Post{ID: id, Raw: raw}
+// Parser extracts Posts using a rule set. It is immutable and safe for
+// concurrent use; swap the whole Parser to change rules.
+type Parser struct {
+	rules    *Rules
+	gaz      *Gazetteer
+	breeds   *BreedCatalog
+	model    Classifier    // optional fallback, see WithClassifier
+	keywords *keywordIndex // regexps of the above, see keywords.go
+}
+
+func NewParser(r *Rules) *Parser {
+	ps := &Parser{rules: r, gaz: defaultGazetteer, breeds: defaultBreeds}
+	ps.keywords = newKeywordIndex(r.regexps, ps.breeds.regexps, ps.gaz.regexps, structuralRegexps)
+	return ps
+}
+
+var defaultParser = NewParser(defaultRules)
+
+// DefaultParser uses the embedded default rules.
+func DefaultParser() *Parser { return defaultParser }
+
+func (ps *Parser) Rules() *Rules { return ps.rules }
+
+// Version is stored with every post: code version plus rule set version, plus
+// the model version when a classifier is attached.
+func (ps *Parser) Version() string {
+	v := ParserVersion + "+" + ps.rules.Version()
+	if ps.model != nil {
+		v += "+model-" + ps.model.Version()
+	}
+	return v
+}
+
+// Parse returns a fully-populated Post from raw text using the default rules.
+// Pure and deterministic.
+func Parse(id int, raw string) Post { return defaultParser.Parse(id, raw) }
+
+// ParseAt is Parse for a post published at posted, so relative dates like
+// "вчера вечером" resolve into WhenFrom/WhenTo.
+func ParseAt(id int, raw string, posted time.Time) Post {
+	return defaultParser.ParseAt(id, raw, posted)
+}
+
+// ParseExplain is Parse that also reports which rules produced each field.
+func ParseExplain(id int, raw string) (Post, Explanation) {
+	return defaultParser.ParseExplain(id, raw)
+}
+
+// Parse returns a fully-populated Post from raw text. Pure and deterministic
+// for a given rule set. Only absolute dates are resolved; see ParseAt.
+func (ps *Parser) Parse(id int, raw string) Post {
+	p, _ := ps.ParseExplain(id, raw)
+	return p
+}
+
+// ParseAt is Parse relative to the post's publication time.
+func (ps *Parser) ParseAt(id int, raw string, posted time.Time) Post {
+	p, _ := ps.ParseExplainAt(id, raw, posted)
+	return p
+}
+
+// ParseExplain is Parse that also reports which rules produced each field.
+func (ps *Parser) ParseExplain(id int, raw string) (Post, Explanation) {
+	return ps.ParseExplainAt(id, raw, time.Time{})
+}
+
+// ParseExplainAt is ParseAt that also reports which rules produced each field.
+func (ps *Parser) ParseExplainAt(id int, raw string, posted time.Time) (Post, Explanation) {
+	s := normalizeText(raw)
+	sc := ps.newScan(s)
+	p := Post{ID: id, Raw: raw, ParserVersion: ps.Version()}
 	if strings.TrimSpace(s) == "" {
 		p.Type = TypeEmpty
-		return p
+		sc.decide("type", "empty", 1)
+		return p, sc.explain(p)
 	}
 
-	// Accounts and link-only detection helpers
-	vkAcc := extractVKAccounts(s)
 	if isLinkOnly(s) {
 		p.Type = TypeLink
-		p.VKAccounts = vkAcc
-		return p
+		p.Contacts = sc.extractContacts()
+		sc.decide("type", "link_only", 1)
+		return p, sc.explain(p)
 	}
 
-	p.Type = detectType(s)
-	p.Phones = extractPhones(s)
-	p.VKAccounts = vkAcc
-	p.Animal = detectAnimal(s)
-	p.Breed = extractBreed(s)
-	p.Sex = detectSex(s)
-	p.Age = extractAge(s)
-	p.When = extractWhen(s)
-	p.Location = extractLocationHeuristic(s)
-	names := extractContactNamesAroundPhones(s, p.Phones)
+	ps.extract(sc, &p, posted)
+	p.Parts = ps.parts(p, s, posted)
+	return p, sc.explain(p)
+}
+
+// extract fills the fields of p from the scan text.
+func (ps *Parser) extract(sc *scan, p *Post, posted time.Time) {
+	s := sc.s
+	p.Type, p.TypeSource = sc.detectType()
+	p.Contacts = sc.extractContacts()
+	p.Animal, p.Species, p.AnimalSource = sc.detectAnimal()
+	p.Breed, p.BreedID, p.BreedMixed = sc.extractBreed(ps.breeds, p)
+	p.Sex = sc.detectSex()
+	p.Age, p.AgeMinMonths, p.AgeMaxMonths, p.AgeClass = sc.extractAge()
+	p.When, p.WhenFrom, p.WhenTo, p.WhenPrecision = sc.extractWhen(posted)
+	p.Location = sc.extractLocationHeuristic()
+	geo := sc.resolvePlace(ps.gaz)
+	p.Address, p.District, p.Lat, p.Lon = geo.Address, geo.District, geo.Lat, geo.Lon
+	names := extractContactNamesAroundPhones(s, p.Phones())
 	// also add first names from VK mentions
 	names = append(names, extractNamesFromMentions(s)...)
 	p.ContactNames = dedupeKeepOrder(names)
+	p.IDs = sc.extractIdentifiers()
+	p.Contacts = withoutChipContacts(p.Contacts, p.IDs)
 	p.Extras = Extras{
-		Sterilized: reCareMarkers.MatchString(s) && regexp.MustCompile(`(?i)стерилиз|кастрир`).MatchString(s),
-		Vaccinated: regexp.MustCompile(`(?i)вакцин|привит`).MatchString(s),
-		Chipped:    regexp.MustCompile(`(?i)чипир`).MatchString(s),
-		LitterOK:   regexp.MustCompile(`(?i)лоток`).MatchString(s),
-	}
-	p.StatusDetails = extractStatusDetails(s)
-	p.Name = extractPetName(s)
-	return p
+		Sterilized: sc.find("sterilized", "sterilized", ps.rules.sterilized),
+		Vaccinated: sc.find("vaccinated", "vaccinated", ps.rules.vaccinated),
+		Chipped:    sc.find("chipped", "chipped", ps.rules.chipped) || hasChip(p.IDs),
+		LitterOK:   sc.find("litter_ok", "litter", ps.rules.litter),
+	}
+	p.Appearance = sc.extractAppearance()
+	p.Size = sc.extractSize(p.Animal)
+	p.StatusDetails = sc.extractStatusDetails()
+	p.Resolved = sc.detectResolved()
+	p.Urgency, p.Health = sc.detectUrgency()
+	p.Reward, p.RewardAmount, p.ScamRisk = sc.extractReward(p.Type)
+	p.Role, p.RoleSource = sc.detectRole(p.Type)
+	p.Name = sc.extractPetName()
 }
 
 func normalizeSpace(s string) string {
@@ -153,80 +247,41 @@ func normalizeSpace(s string) string {
 }
 
 func detectType(s string) PostType {
-	lost := reLost.MatchString(s)
-	found := reFound.MatchString(s)
-	sight := reSighting.MatchString(s)
-	adopt := reAdoption.MatchString(s) || reCareMarkers.MatchString(s)
-	fund := reFundraising.MatchString(s)
-
-	if found && lost {
-		if reTieFoundSpec.MatchString(s) {
-			return TypeFound
-		}
-		return TypeLost
-	}
-	if found {
-		return TypeFound
-	}
-	if lost {
-		return TypeLost
-	}
-	if sight {
-		return TypeSighting
-	}
-	if adopt {
-		return TypeAdoption
+	t, _ := newScan(defaultRules, s).detectType()
+	return t
+}
+
+func (sc *scan) detectType() (PostType, Source) {
+	b := sc.vote("type", sc.rules.typ)
+	label := sc.typeInContext(b)
+	if label == "" {
+		label = sc.pick("type", sc.rules.typ, b)
 	}
-	if fund {
-		return TypeFundraising
+	t, src := sc.fallback("type", label, postTypeLabels)
+	if t == "" {
+		return TypeUnknown, src
 	}
-	return TypeUnknown
+	return PostType(t), src
 }
 
 func extractPhones(s string) []string {
-	m := rePhone.FindAllString(s, -1)
-	seen := make(map[string]bool)
-	out := make([]string, 0, len(m))
-	for _, raw := range m {
-		digits := onlyDigits(raw)
-		if len(digits) == 11 && digits[0] == '8' {
-			digits = "7" + digits[1:]
-		}
-		if len(digits) == 11 && digits[0] == '7' {
-			norm := "+" + digits
-			if !seen[norm] {
-				seen[norm] = true
-				out = append(out, norm)
-			}
-			continue
-		}
-		if len(digits) == 10 && digits[0] == '9' {
-			norm := "+7" + digits
-			if !seen[norm] {
-				seen[norm] = true
-				out = append(out, norm)
-			}
-		}
-	}
-	return out
+	return Post{Contacts: newScan(defaultRules, s).extractContacts()}.Phones()
 }
 
-func extractVKAccounts(s string) []string {
-	out := []string{}
-	seen := map[string]bool{}
-	for _, v := range reVKBracket.FindAllString(s, -1) {
-		if !seen[v] {
-			seen[v] = true
-			out = append(out, v)
-		}
+// normalizePhone returns a rePhone match as "+7XXXXXXXXXX", or "" when it is
+// not a Russian number.
+func normalizePhone(s string) string {
+	digits := onlyDigits(s)
+	if len(digits) == 11 && digits[0] == '8' {
+		digits = "7" + digits[1:]
 	}
-	for _, v := range reVKURL.FindAllString(s, -1) {
-		if !seen[v] {
-			seen[v] = true
-			out = append(out, v)
-		}
+	switch {
+	case len(digits) == 11 && digits[0] == '7':
+		return "+" + digits
+	case len(digits) == 10 && digits[0] == '9':
+		return "+7" + digits
 	}
-	return out
+	return ""
 }
 
 func extractContactNamesAroundPhones(s string, phones []string) []string {
@@ -254,89 +309,84 @@ func extractContactNamesAroundPhones(s string, phones []string) []string {
 }
 
 func detectAnimal(s string) AnimalType {
-	if reCat.MatchString(s) {
-		return AnimalCat
-	}
-	if reDog.MatchString(s) {
-		return AnimalDog
-	}
-	return AnimalUnknown
+	a, _, _ := newScan(defaultRules, s).detectAnimal()
+	return a
 }
 
-func extractBreed(s string) string {
-	b := reBreed.FindString(s)
-	if b == "" {
-		return ""
-	}
-	return titleCase(b)
+// detectAnimal returns the animal and, for AnimalOther, its species. A
+// species named before any cat or dog word wins: "улетел попугай, дома
+// кошка" is about the parrot.
+func (sc *scan) detectAnimal() (AnimalType, string, Source) {
+	b := sc.vote("animal", sc.rules.animal)
+	label := sc.pick("animal", sc.rules.animal, b)
+	species, at := sc.detectSpecies()
+	if species != "" && (label == "" || at < firstHit(b.hits[label])) {
+		sc.decide("animal", species, 0.8)
+		label = string(AnimalOther)
+	}
+	a, src := sc.fallback("animal", label, animalLabels)
+	if a == "" {
+		return AnimalUnknown, "", src
+	}
+	if a != string(AnimalOther) {
+		species = ""
+	}
+	return AnimalType(a), species, src
 }
 
-func detectSex(s string) SexType {
-	if reMale.MatchString(s) {
-		return SexM
-	}
-	if reFem.MatchString(s) {
-		return SexF
-	}
-	return SexUnknown
-}
-
-func extractAge(s string) string {
-	m := reAge.FindStringSubmatch(s)
-	if len(m) == 0 {
-		return ""
-	}
-	val := strings.ReplaceAll(m[1], ",", ".")
-	unit := m[2]
-	return normalizeSpace(val + " " + unit)
-}
-
-func extractWhen(s string) string {
-	loc := reDate.FindStringIndex(s)
-	if loc == nil {
-		return ""
-	}
-	date := s[loc[0]:loc[1]]
-	// look ahead for time near date
-	// search within next 30 chars
-	end := loc[1] + 30
-	if end > len(s) {
-		end = len(s)
+// extractBreed resolves the breed against the catalogue. A breed also tells
+// the animal when no rule did ("Пропал йорк").
+func (sc *scan) extractBreed(bc *BreedCatalog, p *Post) (name, id string, mixed bool) {
+	b, found, mixed := sc.resolveBreed(bc, p.Animal)
+	if !found {
+		if mixed {
+			return BreedMixedName, "", true
+		}
+		return "", "", false
 	}
-	after := s[loc[1]:end]
-	tm := reTime.FindString(after)
-	if tm != "" {
-		tm = strings.TrimSpace(strings.TrimPrefix(strings.ToLower(tm), "в"))
-		tm = strings.ReplaceAll(tm, ".", ":")
-		return strings.TrimSpace(date + " " + tm)
+	if p.Animal == AnimalUnknown {
+		p.Animal, p.AnimalSource = b.Animal, SourceRules
+		sc.decide("animal", "breed", 0.7)
 	}
-	return date
+	return b.Name, b.ID, mixed
 }
 
-func extractLocationHeuristic(s string) string {
+func (sc *scan) extractLocationHeuristic() string {
 	// Split by commas and pick shortest segment with a trigger.
 	// If the next comma-separated segment is a numeric-like house number,
 	// append it to the candidate (e.g., "Пушкинская улица, 283" → "Пушкинская улица 283").
-	parts := strings.Split(s, ",")
+	parts := strings.Split(sc.s, ",")
 	best := ""
+	var bestSpan [2]int
+	off := 0 // byte offset of parts[i] in sc.s
 	for i := 0; i < len(parts); i++ {
+		partOff := off
+		off += len(parts[i]) + 1
 		t := strings.TrimSpace(parts[i])
 		if t == "" {
 			continue
 		}
-		if reLocTrigger.MatchString(t) {
+		if sc.may(sc.rules.locTrigger) && sc.rules.locTrigger.MatchString(t) {
+			start := partOff + strings.Index(parts[i], t)
+			span := [2]int{start, start + len(t)}
 			cand := t
 			if i+1 < len(parts) {
 				nxt := strings.TrimSpace(parts[i+1])
 				if nxt != "" && isNumericLike(nxt) {
 					cand = strings.TrimSpace(cand + " " + nxt)
+					span[1] = off + strings.Index(parts[i+1], nxt) + len(nxt)
 				}
 			}
 			if best == "" || len([]rune(cand)) < len([]rune(best)) {
 				best = cand
+				bestSpan = span
 			}
 		}
 	}
+	if best != "" {
+		sc.record("location", "shortest_trigger_segment", bestSpan[0], bestSpan[1])
+		sc.decide("location", "shortest_trigger_segment", 0.5)
+	}
 	return best
 }
 
@@ -356,11 +406,34 @@ func isNumericLike(s string) bool {
 	return hasDigit
 }
 
-func extractStatusDetails(s string) string {
-	ms := reStatus.FindAllString(s, -1)
-	if len(ms) == 0 {
+// reNegated matches a negation right before a resolved phrase.
+var reNegated = regexp.MustCompile(`(?i)(?:^|[^\p{L}])(?:не|пока\s+не|ещ[её]\s+не)\s*$`)
+
+// detectResolved reports whether the post says the case is closed: the pet is
+// home, the owner was found or the post is no longer relevant.
+func (sc *scan) detectResolved() bool {
+	if sc.rules.resolved == nil {
+		return false
+	}
+	for _, loc := range sc.indexAll(sc.rules.resolved) {
+		if reNegated.MatchString(sc.s[:loc[0]]) {
+			continue
+		}
+		sc.record("resolved", "resolved", loc[0], loc[1])
+		return true
+	}
+	return false
+}
+
+func (sc *scan) extractStatusDetails() string {
+	idxs := sc.findAll("status_details", "status", sc.rules.status)
+	if len(idxs) == 0 {
 		return ""
 	}
+	ms := make([]string, 0, len(idxs))
+	for _, rg := range idxs {
+		ms = append(ms, sc.s[rg[0]:rg[1]])
+	}
 	uniq := dedupeKeepOrder(ms)
 	out := strings.Join(uniq, ", ")
 	if len([]rune(out)) > 140 {
@@ -370,12 +443,16 @@ func extractStatusDetails(s string) string {
 	return out
 }
 
-func extractPetName(s string) string {
-	if m := reName1.FindStringSubmatch(s); len(m) > 1 {
-		return titleCase(m[1])
+func (sc *scan) extractPetName() string {
+	if m := sc.submatch(reName1); m != nil {
+		sc.record("name", "name_keyword", m[2], m[3])
+		sc.decide("name", "name_keyword", 0.8)
+		return titleCase(sc.s[m[2]:m[3]])
 	}
-	if m := reName2.FindStringSubmatch(s); len(m) > 1 {
-		return titleCase(m[1])
+	if m := sc.submatch(reName2); m != nil {
+		sc.record("name", "name_after_cat", m[2], m[3])
+		sc.decide("name", "name_after_cat", 0.5)
+		return titleCase(sc.s[m[2]:m[3]])
 	}
 	return ""
 }
@@ -157,7 +157,7 @@ func TestParse(t *testing.T) {
 				assert.Contains(t, got.Age, tc.want.AgeContains, "AgeContains")
 			}
 			if tc.want.Phones != nil {
-				assert.Equal(t, tc.want.Phones, got.Phones, "Phones")
+				assert.Equal(t, tc.want.Phones, got.Phones(), "Phones")
 			}
 			if len(tc.want.LocationContains) > 0 {
 				assert.Truef(t, containsAll(got.Location, tc.want.LocationContains), "Location: %q missing %v", got.Location, tc.want.LocationContains)
@@ -256,6 +256,28 @@ func TestDetectAnimal(t *testing.T) {
 	}
 }
 
+func TestDetectSpecies(t *testing.T) {
+	cases := []struct {
+		text    string
+		animal  AnimalType
+		species string
+	}{
+		{"Улетел волнистый попугай, зелёный, отзывается на Кешу", AnimalOther, "parrot"},
+		{"Найдена морская свинка во дворе дома на Пушкинской", AnimalOther, "guinea_pig"},
+		{"Потерялся хорёк, очень ручной, район Буммаш", AnimalOther, "ferret"},
+		{"Пропала коза, белая, с колокольчиком на шее", AnimalOther, "goat"},
+		{"Пропала лошадь, гнедая кобыла, ушла из конюшни", AnimalOther, "horse"},
+		{"Пропала кошка, дома остались черепаха и попугай", AnimalCat, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.text, func(t *testing.T) {
+			p := Parse(1, tc.text)
+			require.Equal(t, tc.animal, p.Animal)
+			require.Equal(t, tc.species, p.Species)
+		})
+	}
+}
+
 func TestDetectType(t *testing.T) {
 	cases := []struct {
 		name string
@@ -265,10 +287,15 @@ func TestDetectType(t *testing.T) {
 		{name: "Lost: пропала", text: "Пропала кошка, Ижевск", want: TypeLost},
 		{name: "Lost: потерялся", text: "Потерялся пёсик дворняга", want: TypeLost},
 		{name: "Lost: убежал", text: "Убежал котик вчера", want: TypeLost},
+		{name: "Lost: пропавшего", text: "Хозяева ищут пропавшего кота", want: TypeLost},
 
 		{name: "Found: найден", text: "Найден кот во дворе", want: TypeFound},
 		{name: "Found: нашли", text: "Нашли собаку у магазина", want: TypeFound},
 		{name: "Found: подобрали", text: "Подобрали щенка у подъезда", want: TypeFound},
+		{name: "Found: нашла", text: "Нашла кошку у подъезда", want: TypeFound},
+		{name: "Found: нашёл", text: "Сосед нашёл пса в гаражах", want: TypeFound},
+
+		{name: "Adoption: dog named Найда", text: "Ищет дом собака Найда, 3 года, стерилизована", want: TypeAdoption},
 
 		{name: "Sighting: бегает", text: "Бегает кобелек во дворе", want: TypeSighting},
 		{name: "Sighting: замечена", text: "Замечена собака у школы", want: TypeSighting},
@@ -281,8 +308,14 @@ func TestDetectType(t *testing.T) {
 		{name: "Fundraising: сбор", text: "Сбор на оплату передержки", want: TypeFundraising},
 		{name: "Fundraising: перевод/карта", text: "Нужен перевод на карту Сбер", want: TypeFundraising},
 
-		{name: "Tie both words → Found (найден...)", text: "Найден кот, потом потерялся", want: TypeFound},
-		{name: "Tie both words → Lost (без 'найден')", text: "Нашли собаку, но пропала позже", want: TypeLost},
+		{name: "Both words → Found (mentioned first)", text: "Найден кот, потом потерялся", want: TypeFound},
+		{name: "Both words → Lost (after но)", text: "Нашли собаку, но пропала позже", want: TypeLost},
+		{name: "Negated lost, finder cue → Found", text: "Не потерялся, а ищет хозяев, рыжий пёс", want: TypeFound},
+		{name: "Finder cue with найденной", text: "Ищем хозяев найденной собаки", want: TypeFound},
+		{name: "Lost, then back home → Lost", text: "Пропала, но нашлась! Спасибо всем", want: TypeLost},
+		{name: "Still not found → Lost", text: "Кот пропал неделю назад, до сих пор не найден", want: TypeLost},
+		{name: "Owner cue outweighs кто нашел", text: "Внимание! Пропала наша собака. Кто нашел, звоните", want: TypeLost},
+		{name: "Finder headline with a later lost mention", text: "Найдена собака. Хозяева, отзовитесь! Видимо, потерялась", want: TypeFound},
 
 		{name: "Unknown", text: "Привет всем! Отличный день.", want: TypeUnknown},
 
@@ -312,3 +345,29 @@ func sliceHasAny(hay []string, needles []string) bool {
 }
 
 // Using testify for equality checks in tests; helpers above cover contains cases.
+
+func TestDetectResolved(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		want bool
+	}{
+		{name: "нашлась!", text: "НАШЛАСЬ! Спасибо всем за репосты, кошка Муся дома", want: true},
+		{name: "хозяин найден", text: "Найден пёс у ТЦ Талисман. Хозяин найден, спасибо!", want: true},
+		{name: "дома", text: "Дома! Пропавшая собака вернулась", want: true},
+		{name: "неактуально", text: "Неактуально. Пропал кот, рыжий, район Буммаш", want: true},
+		{name: "вернулся домой", text: "Кот вернулся домой сам, всем спасибо", want: true},
+
+		{name: "lost", text: "Пропала собака возле дома, помогите найти", want: false},
+		{name: "ещё не нашлась", text: "Кошка ещё не нашлась, продолжаем искать", want: false},
+		{name: "нашлась, ищем хозяев", text: "Нашлась собака у магазина, ищем хозяев", want: false},
+		{name: "актуально", text: "Всё ещё актуально! Пропал пёс, кобель", want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Parse(1, tc.text)
+			require.Equal(t, tc.want, got.Resolved)
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package lostdogs
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jehaby/lostdogs/internal/ru"
+)
+
+// Explanation tells why Parse produced the values it did: for every field,
+// the rule that decided it and all rule hits that were considered.
+type Explanation struct {
+	// Text is the normalized text that match spans refer to.
+	Text string `json:"text"`
+	// Confidence is a rough overall score in [0,1], the mean of the type and
+	// animal confidences.
+	Confidence float64            `json:"confidence"`
+	Fields     []FieldExplanation `json:"fields"`
+}
+
+type FieldExplanation struct {
+	Field      string  `json:"field"`
+	Value      string  `json:"value"`
+	Rule       string  `json:"rule,omitempty"` // deciding rule; empty when nothing fired
+	Confidence float64 `json:"confidence"`
+	Matches    []Match `json:"matches,omitempty"`
+}
+
+// Match is a single rule hit. Start and End are rune offsets into
+// Explanation.Text (End exclusive).
+type Match struct {
+	Rule  string `json:"rule"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Text  string `json:"text"`
+}
+
+// Field returns the explanation for a field name, or nil.
+func (e Explanation) Field(name string) *FieldExplanation {
+	for i := range e.Fields {
+		if e.Fields[i].Field == name {
+			return &e.Fields[i]
+		}
+	}
+	return nil
+}
+
+// scan is the state of one Parse call: the normalized text plus the rule hits
+// and decisions collected by the extractors.
+type scan struct {
+	rules    *Rules
+	model    Classifier
+	s        string
+	kw       keywordHits // keywords found in s, see keywords.go
+	toks     []ru.Token  // words of s, see tokens
+	clauses  []clause    // see clauses
+	matches  map[string][]Match
+	verdicts map[string]verdict
+}
+
+type verdict struct {
+	rule string
+	conf float64
+}
+
+func newScan(r *Rules, s string) *scan {
+	return &scan{rules: r, s: s, matches: map[string][]Match{}, verdicts: map[string]verdict{}}
+}
+
+// newScan starts a scan of s with the parser's rules, model and keyword index.
+func (ps *Parser) newScan(s string) *scan {
+	sc := newScan(ps.rules, s)
+	sc.model = ps.model
+	sc.kw = ps.keywords.scan(s)
+	return sc
+}
+
+// may reports whether re can match the scan text or a part of it.
+func (sc *scan) may(re *regexp.Regexp) bool { return sc.kw.may(re) }
+
+// index, indexAll, submatch and submatchAll are the regexp methods of the
+// same names over the scan text. The keyword index answers them when it can
+// and runs re over the whole text only when it has to.
+func (sc *scan) index(re *regexp.Regexp) []int {
+	if ms, ok := sc.kw.find(re, sc.s, 1); ok {
+		if ms == nil {
+			return nil
+		}
+		return ms[0][:2:2]
+	}
+	return re.FindStringIndex(sc.s)
+}
+
+func (sc *scan) indexAll(re *regexp.Regexp) [][]int {
+	if ms, ok := sc.kw.find(re, sc.s, -1); ok {
+		for i, m := range ms {
+			ms[i] = m[:2:2]
+		}
+		return ms
+	}
+	return re.FindAllStringIndex(sc.s, -1)
+}
+
+func (sc *scan) submatch(re *regexp.Regexp) []int {
+	if ms, ok := sc.kw.find(re, sc.s, 1); ok {
+		if ms == nil {
+			return nil
+		}
+		return ms[0]
+	}
+	return re.FindStringSubmatchIndex(sc.s)
+}
+
+func (sc *scan) submatchAll(re *regexp.Regexp) [][]int {
+	if ms, ok := sc.kw.find(re, sc.s, -1); ok {
+		return ms
+	}
+	return re.FindAllStringSubmatchIndex(sc.s, -1)
+}
+
+// find reports whether re matches and records the leftmost hit.
+func (sc *scan) find(field, rule string, re *regexp.Regexp) bool {
+	return sc.first(field, rule, re) != nil
+}
+
+// tokens returns the words of the scan text, tokenizing on first use.
+func (sc *scan) tokens() []ru.Token {
+	if sc.toks == nil {
+		sc.toks = ru.Tokenize(sc.s)
+		if sc.toks == nil {
+			sc.toks = []ru.Token{}
+		}
+	}
+	return sc.toks
+}
+
+// matchRule returns the start of every hit of a label rule, by pattern or by
+// lemma, that is not negated ("не потерялся"), and records the leftmost one.
+func (sc *scan) matchRule(field string, r labelRule) []int {
+	var spans [][]int
+	if r.re != nil {
+		spans = sc.indexAll(r.re)
+	}
+	spans = append(spans, sc.lemmaSpans(r.lemmas)...)
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	var starts []int
+	for _, sp := range spans {
+		if sc.negated(sp[0]) {
+			continue
+		}
+		if len(starts) == 0 {
+			sc.record(field, r.name, sp[0], sp[1])
+		}
+		starts = append(starts, sp[0])
+	}
+	return starts
+}
+
+// lemmaSpans returns the byte spans where any of the stem sequences occurs in
+// the scan text as consecutive words.
+func (sc *scan) lemmaSpans(phrases [][]string) [][]int {
+	if len(phrases) == 0 {
+		return nil
+	}
+	var out [][]int
+	toks := sc.tokens()
+	for i := range toks {
+		for _, ph := range phrases {
+			if i+len(ph) > len(toks) || !stemsAt(toks[i:], ph) {
+				continue
+			}
+			out = append(out, []int{toks[i].Start, toks[i+len(ph)-1].End})
+			break
+		}
+	}
+	return out
+}
+
+func stemsAt(toks []ru.Token, stems []string) bool {
+	for j, st := range stems {
+		if toks[j].Stem != st {
+			return false
+		}
+	}
+	return true
+}
+
+// first returns the byte span of the leftmost hit of re and records it.
+func (sc *scan) first(field, rule string, re *regexp.Regexp) []int {
+	loc := sc.index(re)
+	if loc != nil {
+		sc.record(field, rule, loc[0], loc[1])
+	}
+	return loc
+}
+
+// findAll returns the byte spans of all hits of re and records them.
+func (sc *scan) findAll(field, rule string, re *regexp.Regexp) [][]int {
+	idxs := sc.indexAll(re)
+	for _, loc := range idxs {
+		sc.record(field, rule, loc[0], loc[1])
+	}
+	return idxs
+}
+
+// record stores a hit given as byte offsets into sc.s.
+func (sc *scan) record(field, rule string, start, end int) {
+	sc.matches[field] = append(sc.matches[field], Match{
+		Rule:  rule,
+		Start: utf8.RuneCountInString(sc.s[:start]),
+		End:   utf8.RuneCountInString(sc.s[:end]),
+		Text:  sc.s[start:end],
+	})
+}
+
+// decide sets the rule that determined a field's value.
+func (sc *scan) decide(field, rule string, conf float64) {
+	sc.verdicts[field] = verdict{rule: rule, conf: conf}
+}
+
+// explainOrder is the order fields are reported in.
+var explainOrder = []string{
+	"type", "animal", "species", "sex", "breed", "breed_mixed", "age", "name", "location", "address", "when", "contacts",
+	"sterilized", "vaccinated", "chipped", "litter_ok", "colors", "coat", "gear", "marks",
+	"size", "weight", "height", "build", "urgency", "health", "ids", "reward", "scam_risk",
+	"role", "status_details", "resolved",
+}
+
+func (sc *scan) explain(p Post) Explanation {
+	values := map[string]string{
+		"type":           string(p.Type),
+		"animal":         string(p.Animal),
+		"species":        p.Species,
+		"sex":            string(p.Sex),
+		"breed":          p.Breed,
+		"breed_mixed":    boolStr(p.BreedMixed),
+		"age":            p.Age,
+		"name":           p.Name,
+		"location":       p.Location,
+		"address":        p.Address,
+		"when":           p.When,
+		"contacts":       contactsText(p.Contacts),
+		"sterilized":     boolStr(p.Extras.Sterilized),
+		"vaccinated":     boolStr(p.Extras.Vaccinated),
+		"chipped":        boolStr(p.Extras.Chipped),
+		"litter_ok":      boolStr(p.Extras.LitterOK),
+		"colors":         strings.Join(p.Appearance.Colors, ", "),
+		"coat":           string(p.Appearance.Coat),
+		"gear":           strings.Join(p.Appearance.Gear, ", "),
+		"marks":          strings.Join(p.Appearance.Marks, ", "),
+		"size":           string(p.Size.Class),
+		"weight":         weightText(p.Size),
+		"height":         heightText(p.Size),
+		"build":          string(p.Size.Build),
+		"urgency":        string(p.Urgency),
+		"health":         strings.Join(p.Health, ", "),
+		"ids":            idsText(p.IDs),
+		"reward":         rewardText(p),
+		"scam_risk":      boolStr(p.ScamRisk),
+		"role":           string(p.Role),
+		"status_details": p.StatusDetails,
+		"resolved":       boolStr(p.Resolved),
+	}
+	ex := Explanation{Text: sc.s}
+	for _, f := range explainOrder {
+		v := sc.verdicts[f]
+		fe := FieldExplanation{Field: f, Value: values[f], Rule: v.rule, Confidence: v.conf, Matches: sc.matches[f]}
+		// Flags and lists are certain once a rule matched.
+		if fe.Rule == "" && len(fe.Matches) > 0 {
+			fe.Rule, fe.Confidence = fe.Matches[0].Rule, 1
+		}
+		ex.Fields = append(ex.Fields, fe)
+	}
+	ex.Confidence = (sc.verdicts["type"].conf + sc.verdicts["animal"].conf) / 2
+	if p.Type == TypeEmpty || p.Type == TypeLink {
+		ex.Confidence = 1
+	}
+	return ex
+}
+
+func boolStr(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
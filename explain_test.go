@@ -0,0 +1,52 @@
+package lostdogs
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExplain(t *testing.T) {
+	text := "Найден кот, потом потерялся. Звоните 8 922 405 26 12"
+	p, ex := ParseExplain(0, text)
+
+	require.Equal(t, Parse(0, text), p, "ParseExplain must not change the parse result")
+	require.Equal(t, text, ex.Text)
+
+	typ := ex.Field("type")
+	require.NotNil(t, typ)
+	assert.Equal(t, "found", typ.Value)
+	assert.Equal(t, "context", typ.Rule)
+	assert.Less(t, typ.Confidence, 0.9, "a type weighed in context should be less confident")
+
+	rules := map[string]string{}
+	for _, m := range typ.Matches {
+		rules[m.Rule] = m.Text
+	}
+	assert.Equal(t, "Найден", rules["found"])
+	assert.Equal(t, "потерялс", rules["lost"])
+
+	// Spans are rune offsets into the normalized text.
+	runes := []rune(ex.Text)
+	for _, f := range ex.Fields {
+		for _, m := range f.Matches {
+			require.LessOrEqual(t, m.End, utf8.RuneCountInString(ex.Text))
+			assert.Equal(t, m.Text, string(runes[m.Start:m.End]), "%s/%s span", f.Field, m.Rule)
+		}
+	}
+
+	animal := ex.Field("animal")
+	assert.Equal(t, "cat", animal.Rule)
+	assert.InDelta(t, (typ.Confidence+animal.Confidence)/2, ex.Confidence, 1e-9)
+}
+
+func TestParseExplain_NothingFired(t *testing.T) {
+	_, ex := ParseExplain(0, "Привет всем! Отличный день, погода хорошая.")
+	typ := ex.Field("type")
+	require.NotNil(t, typ)
+	assert.Equal(t, "unknown", typ.Value)
+	assert.Empty(t, typ.Rule)
+	assert.Zero(t, ex.Confidence)
+}
@@ -0,0 +1,172 @@
+package lostdogs
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	yaml "github.com/goccy/go-yaml"
+)
+
+//go:embed resources/gazetteer/udmurtia.yml
+var defaultGazetteerYAML []byte
+
+// Place kinds, from least to most specific.
+const (
+	PlaceDistrict      = "district"
+	PlaceMicrodistrict = "microdistrict"
+	PlaceSettlement    = "settlement"
+	PlaceStreet        = "street"
+	PlaceLandmark      = "landmark"
+)
+
+var placeKinds = []string{PlaceDistrict, PlaceMicrodistrict, PlaceSettlement, PlaceStreet, PlaceLandmark}
+
+// GazetteerSpec is the YAML form of a gazetteer; see
+// resources/gazetteer/udmurtia.yml.
+type GazetteerSpec struct {
+	Version string      `yaml:"version"`
+	Places  []PlaceSpec `yaml:"places"`
+}
+
+type PlaceSpec struct {
+	Name     string   `yaml:"name"`
+	Kind     string   `yaml:"kind"`
+	District string   `yaml:"district"`
+	Lat      float64  `yaml:"lat"`
+	Lon      float64  `yaml:"lon"`
+	Patterns []string `yaml:"patterns"`
+}
+
+// Gazetteer is a compiled list of known places. It is immutable and safe for
+// concurrent use.
+type Gazetteer struct {
+	version string
+	places  []place
+	regexps []*regexp.Regexp // for the keyword index
+}
+
+type place struct {
+	PlaceSpec
+	re *regexp.Regexp
+}
+
+var defaultGazetteer = MustLoadGazetteer(defaultGazetteerYAML)
+
+// DefaultGazetteer returns the gazetteer embedded from resources/gazetteer.
+func DefaultGazetteer() *Gazetteer { return defaultGazetteer }
+
+// MustLoadGazetteer is LoadGazetteer that panics on error.
+func MustLoadGazetteer(b []byte) *Gazetteer {
+	g, err := LoadGazetteer(b)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// LoadGazetteer parses and validates a YAML gazetteer, reporting all problems
+// at once like LoadRules.
+func LoadGazetteer(b []byte) (*Gazetteer, error) {
+	var spec GazetteerSpec
+	if err := yaml.UnmarshalWithOptions(b, &spec, yaml.DisallowUnknownField()); err != nil {
+		return nil, fmt.Errorf("gazetteer: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	c := &compiler{}
+	if strings.TrimSpace(spec.Version) == "" {
+		c.errorf("version", "must be set")
+	}
+	g := &Gazetteer{version: spec.Version + "-" + hex.EncodeToString(sum[:4])}
+	for i, ps := range spec.Places {
+		p := fmt.Sprintf("places[%d]", i)
+		if ps.Name == "" {
+			c.errorf(p+".name", "must be set")
+		}
+		if !slices.Contains(placeKinds, ps.Kind) {
+			c.errorf(p+".kind", "unknown kind %q (allowed: %s)", ps.Kind, strings.Join(placeKinds, ", "))
+		}
+		switch {
+		case ps.Kind == PlaceDistrict && ps.District == "":
+			ps.District = ps.Name
+		case ps.District == "":
+			c.errorf(p+".district", "must be set")
+		}
+		if ps.Lat < -90 || ps.Lat > 90 || ps.Lon < -180 || ps.Lon > 180 || (ps.Lat == 0 && ps.Lon == 0) {
+			c.errorf(p, "bad coordinates %v, %v", ps.Lat, ps.Lon)
+		}
+		g.places = append(g.places, place{PlaceSpec: ps, re: c.words(p+".patterns", ps.Patterns)})
+	}
+	if len(c.errs) > 0 {
+		return nil, fmt.Errorf("gazetteer: %w", errors.Join(c.errs...))
+	}
+	g.regexps = c.regexps
+	return g, nil
+}
+
+// Version identifies the gazetteer contents.
+func (g *Gazetteer) Version() string { return g.version }
+
+// Geo is a place resolved from post text.
+type Geo struct {
+	Address  string // normalized, e.g. "ул. Пушкинская, 283"
+	District string
+	Lat, Lon float64
+}
+
+var reHouseNumber = regexp.MustCompile(`(?i)^[\s,]*(?:(?:ул\.?|улиц\p{L}*|шоссе|ш\.)[\s,]*)?(?:д\.|дом)?\s*(\d{1,3}[а-яa-z]?(?:/\d{1,3})?)(?:[^\d]|$)`)
+
+// placeRank orders candidate places; a street with a house number is the most
+// precise hint a post can give.
+func placeRank(kind string, house bool) int {
+	if kind == PlaceStreet && house {
+		return len(placeKinds)
+	}
+	return slices.Index(placeKinds, kind)
+}
+
+// Confidence of a resolved place by rank, see placeRank.
+var placeConfidence = []float64{0.5, 0.5, 0.6, 0.6, 0.7, 0.8}
+
+// resolvePlace matches every gazetteer place against the scan text, records
+// the hits and returns the most specific one (the earliest among equals).
+func (sc *scan) resolvePlace(g *Gazetteer) Geo {
+	bestRank, bestStart := -1, 0
+	var best *place
+	var house string
+	for i := range g.places {
+		pl := &g.places[i]
+		m := sc.submatch(pl.re)
+		if m == nil {
+			continue
+		}
+		sc.record("address", pl.Name, m[2], m[3])
+		h := ""
+		if pl.Kind == PlaceStreet {
+			if hm := reHouseNumber.FindStringSubmatch(sc.s[m[3]:]); hm != nil {
+				h = hm[1]
+			}
+		}
+		r := placeRank(pl.Kind, h != "")
+		if r > bestRank || (r == bestRank && m[2] < bestStart) {
+			best, bestRank, bestStart, house = pl, r, m[2], h
+		}
+	}
+	if best == nil {
+		return Geo{}
+	}
+	sc.decide("address", best.Name, placeConfidence[bestRank])
+	geo := Geo{District: best.District, Lat: best.Lat, Lon: best.Lon}
+	if best.Kind != PlaceDistrict {
+		geo.Address = best.Name
+		if house != "" {
+			geo.Address += ", " + house
+		}
+	}
+	return geo
+}
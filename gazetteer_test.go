@@ -0,0 +1,66 @@
+package lostdogs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolvePlace(t *testing.T) {
+	cases := []struct {
+		text     string
+		address  string
+		district string
+	}{
+		{"Пропала собака, метис лабрадора. Пушкинская улица, 283. 89120281683", "ул. Пушкинская, 283", "Октябрьский район"},
+		{"Потерялась кошка. Воткинское шоссе 39 26.08.2025 примерно в 22:00", "Воткинское шоссе, 39", "Устиновский район"},
+		{"Найден пёс около ТЦ Талисман, очень ласковый", "ТЦ «Талисман»", "Первомайский район"},
+		{"Бегает собака на Буммаше у дома на ул. Петрова 30", "ул. Петрова, 30", "Устиновский район"},
+		{"Нашли котенка в Завьялово, ищем хозяев", "Завьялово", "Завьяловский район"},
+		{"Пропал кот, Ленинский район, помогите найти", "", "Ленинский район"},
+		{"Котик бегает по двору уже неделю, кто знает чей", "", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.text, func(t *testing.T) {
+			p, ex := ParseExplain(1, tc.text)
+			assert.Equal(t, tc.address, p.Address)
+			assert.Equal(t, tc.district, p.District)
+			if tc.district == "" {
+				assert.Zero(t, p.Lat)
+				return
+			}
+			assert.InDelta(t, 56.8, p.Lat, 1.5)
+			assert.InDelta(t, 53.2, p.Lon, 1.5)
+			f := ex.Field("address")
+			require.NotNil(t, f)
+			assert.NotEmpty(t, f.Rule)
+			assert.NotEmpty(t, f.Matches)
+		})
+	}
+}
+
+func TestLoadGazetteer_Validation(t *testing.T) {
+	_, err := LoadGazetteer([]byte(`
+version: "1"
+places:
+  - name: Нигде
+    kind: planet
+    lat: 0
+    lon: 0
+    patterns: ['нигд(']
+`))
+	require.Error(t, err)
+	for _, want := range []string{
+		`places[0].kind: unknown kind "planet"`,
+		`places[0].district: must be set`,
+		`places[0]: bad coordinates`,
+		`places[0].patterns[0]:`,
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+
+	g, err := LoadGazetteer(defaultGazetteerYAML)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultGazetteer().Version(), g.Version())
+}
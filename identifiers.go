@@ -0,0 +1,107 @@
+package lostdogs
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Identifiers are what ties a found animal to its owner beyond looks: the
+// 15-digit microchip number, a tattoo or brand code, the phone on a collar tag.
+// They are stored per post so a found post can be linked to the lost post
+// carrying the same number.
+
+type IDKind string
+
+const (
+	IDChip     IDKind = "chip"      // ISO 11784 microchip, 15 digits
+	IDTattoo   IDKind = "tattoo"    // tattoo or brand code, e.g. "МКС1234"
+	IDTagPhone IDKind = "tag_phone" // phone on a collar tag, "+79120281683"
+)
+
+type Identifier struct {
+	Kind  IDKind
+	Value string // normalized: digits, an upper-case code or a +7 phone
+}
+
+// String is "kind:value", e.g. "chip:643094100123456".
+func (id Identifier) String() string {
+	return string(id.Kind) + ":" + id.Value
+}
+
+var (
+	// A contiguous 15-digit number is a chip wherever it stands; grouped
+	// digits ("643 094 100 123 456") only after a chip word.
+	reChipBare    = regexp.MustCompile(`(?:^|[^\d])(\d{15})(?:[^\d]|$)`)
+	reChipGrouped = regexp.MustCompile(`(?i)(?:чип\p{L}*|микрочип\p{L}*|chip)[^\d]{0,30}(\d{3}(?:[\s-]?\d){12})(?:[^\d]|$)`)
+
+	// A tattoo or brand code after its word, optionally after where it is
+	// ("клеймо в паху МКС 1234"). Letters of the code must be upper case so
+	// that ordinary words after it are not taken.
+	reTattoo = regexp.MustCompile(`(?i:клейм\p{L}*|тату(?:ировк\p{L}*)?|татуаж\p{L}*)(?:\s+(?:на|в|во)(?:\s+\p{Ll}+){1,3})?[\s:№-]*((?:[A-ZА-ЯЁ]{1,4}[\s-]?)?\d{2,7}(?:[\s-]?[A-ZА-ЯЁ\d]{1,4})?)(?:[^\p{L}\d]|$)`)
+
+	// Words that make the next phone a tag's: "на адреснике телефон ...".
+	reTagWord = regexp.MustCompile(`(?i)(?:адресник|жетон|медальон|бирк|на\s+ошейник)\p{L}*[^.!?]*$`)
+)
+
+// tagWindow is how far before a phone a tag word is looked for.
+const tagWindow = 60
+
+// latinLookalikes maps Latin capitals to the Cyrillic ones they look like, so
+// "MKC1234" and "МКС1234" are the same code.
+var latinLookalikes = strings.NewReplacer(
+	"A", "А", "B", "В", "C", "С", "E", "Е", "H", "Н", "K", "К", "M", "М",
+	"O", "О", "P", "Р", "T", "Т", "X", "Х", "Y", "У", "Ё", "Е",
+)
+
+// extractIdentifiers returns the chip numbers, tattoo codes and tag phones of
+// the post in that order.
+func (sc *scan) extractIdentifiers() []Identifier {
+	var out []Identifier
+	add := func(kind IDKind, v string, start, end int) {
+		id := Identifier{Kind: kind, Value: v}
+		if !slices.Contains(out, id) {
+			out = append(out, id)
+		}
+		sc.record("ids", string(kind), start, end)
+	}
+	for _, re := range []*regexp.Regexp{reChipBare, reChipGrouped} {
+		for _, m := range sc.submatchAll(re) {
+			add(IDChip, onlyDigits(sc.s[m[2]:m[3]]), m[2], m[3])
+		}
+	}
+	for _, m := range sc.submatchAll(reTattoo) {
+		code := strings.NewReplacer(" ", "", "-", "").Replace(sc.s[m[2]:m[3]])
+		add(IDTattoo, latinLookalikes.Replace(code), m[2], m[3])
+	}
+	for _, rg := range sc.indexAll(rePhone) {
+		from := max(0, rg[0]-tagWindow)
+		for from > 0 && !utf8.RuneStart(sc.s[from]) {
+			from--
+		}
+		if !reTagWord.MatchString(sc.s[from:rg[0]]) {
+			continue
+		}
+		if ph := normalizePhone(sc.s[rg[0]:rg[1]]); ph != "" {
+			add(IDTagPhone, ph, rg[0], rg[1])
+		}
+	}
+	if len(out) > 0 {
+		sc.decide("ids", string(out[0].Kind), 0.9)
+	}
+	return out
+}
+
+// hasChip reports whether ids include a chip number.
+func hasChip(ids []Identifier) bool {
+	return slices.ContainsFunc(ids, func(id Identifier) bool { return id.Kind == IDChip })
+}
+
+func idsText(ids []Identifier) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = id.String()
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,47 @@
+package lostdogs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_Identifiers(t *testing.T) {
+	cases := []struct {
+		text   string
+		ids    []Identifier
+		phones []string
+	}{
+		{
+			text: "Найдена собака, чипирована, номер чипа 643 094 100 123 456, звоните 89120281683",
+			ids:  []Identifier{{IDChip, "643094100123456"}}, phones: []string{"+79120281683"},
+		},
+		{
+			text: "Пропал пёс, чип 643094100123456, рыжий кобель",
+			ids:  []Identifier{{IDChip, "643094100123456"}},
+		},
+		{
+			text: "Найден кобель, клеймо в паху МКС 1234, ищем хозяев",
+			ids:  []Identifier{{IDTattoo, "МКС1234"}},
+		},
+		{
+			text: "Пропала сука, тату на ухе: MKC1234 (латиницей), помогите найти",
+			ids:  []Identifier{{IDTattoo, "МКС1234"}},
+		},
+		{
+			text: "Найдена собака, на адреснике телефон 8 912 028 16 83, не дозвониться",
+			ids:  []Identifier{{IDTagPhone, "+79120281683"}}, phones: []string{"+79120281683"},
+		},
+		{text: "Найдена собака, клеймо есть но не помним номер, звоните 89120281683", phones: []string{"+79120281683"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.text, func(t *testing.T) {
+			p := Parse(1, tc.text)
+			assert.Equal(t, tc.ids, p.IDs)
+			assert.Equal(t, tc.phones, p.Phones())
+			if hasChip(tc.ids) {
+				assert.True(t, p.Extras.Chipped)
+			}
+		})
+	}
+}
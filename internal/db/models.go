@@ -10,6 +10,23 @@ import (
 	"github.com/jehaby/lostdogs/internal/types"
 )
 
+type Label struct {
+	OwnerID   int64     `json:"owner_id"`
+	PostID    int64     `json:"post_id"`
+	Raw       string    `json:"raw"`
+	Source    string    `json:"source"`
+	Type      *string   `json:"type"`
+	Animal    *string   `json:"animal"`
+	Sex       *string   `json:"sex"`
+	Note      *string   `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	AgeClass  *string   `json:"age_class"`
+	Size      *string   `json:"size"`
+	Urgency   *string   `json:"urgency"`
+	Role      *string   `json:"role"`
+}
+
 type Outbox struct {
 	ID          int64     `json:"id"`
 	OwnerID     int64     `json:"owner_id"`
@@ -21,6 +38,7 @@ type Outbox struct {
 	LeasedUntil *int64    `json:"leased_until"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	Priority    int64     `json:"priority"`
 }
 
 type Post struct {
@@ -35,10 +53,95 @@ type Post struct {
 	Name          *string           `json:"name"`
 	Location      *string           `json:"location"`
 	When          *string           `json:"when"`
-	Phones        types.StringSlice `json:"phones"`
 	ContactNames  types.StringSlice `json:"contact_names"`
-	VkAccounts    types.StringSlice `json:"vk_accounts"`
 	Photos        types.StringSlice `json:"photos"`
 	StatusDetails *string           `json:"status_details"`
 	CreatedAt     time.Time         `json:"created_at"`
+	Breed         *string           `json:"breed"`
+	Age           *string           `json:"age"`
+	Sterilized    bool              `json:"sterilized"`
+	Vaccinated    bool              `json:"vaccinated"`
+	Chipped       bool              `json:"chipped"`
+	LitterOk      bool              `json:"litter_ok"`
+	ParserVersion *string           `json:"parser_version"`
+	Explain       *string           `json:"explain"`
+	TypeSource    *string           `json:"type_source"`
+	AnimalSource  *string           `json:"animal_source"`
+	WhenFrom      *int64            `json:"when_from"`
+	WhenTo        *int64            `json:"when_to"`
+	WhenPrecision *string           `json:"when_precision"`
+	Address       *string           `json:"address"`
+	District      *string           `json:"district"`
+	Lat           *float64          `json:"lat"`
+	Lon           *float64          `json:"lon"`
+	AgeMinMonths  *int64            `json:"age_min_months"`
+	AgeMaxMonths  *int64            `json:"age_max_months"`
+	AgeClass      *string           `json:"age_class"`
+	Colors        types.StringSlice `json:"colors"`
+	Coat          *string           `json:"coat"`
+	Gear          types.StringSlice `json:"gear"`
+	Marks         types.StringSlice `json:"marks"`
+	BreedID       *string           `json:"breed_id"`
+	BreedMixed    bool              `json:"breed_mixed"`
+	Resolved      bool              `json:"resolved"`
+	Species       *string           `json:"species"`
+	Size          *string           `json:"size"`
+	WeightMinKg   *float64          `json:"weight_min_kg"`
+	WeightMaxKg   *float64          `json:"weight_max_kg"`
+	HeightMinCm   *int64            `json:"height_min_cm"`
+	HeightMaxCm   *int64            `json:"height_max_cm"`
+	Build         *string           `json:"build"`
+	Urgency       *string           `json:"urgency"`
+	Health        types.StringSlice `json:"health"`
+	Reward        bool              `json:"reward"`
+	RewardAmount  *int64            `json:"reward_amount"`
+	ScamRisk      bool              `json:"scam_risk"`
+	Role          *string           `json:"role"`
+	RoleSource    *string           `json:"role_source"`
+	Repost        bool              `json:"repost"`
+}
+
+type PostAnimal struct {
+	OwnerID      int64             `json:"owner_id"`
+	PostID       int64             `json:"post_id"`
+	Idx          int64             `json:"idx"`
+	Text         string            `json:"text"`
+	Type         string            `json:"type"`
+	Animal       string            `json:"animal"`
+	Sex          string            `json:"sex"`
+	Name         *string           `json:"name"`
+	Breed        *string           `json:"breed"`
+	BreedID      *string           `json:"breed_id"`
+	Age          *string           `json:"age"`
+	AgeMinMonths *int64            `json:"age_min_months"`
+	AgeMaxMonths *int64            `json:"age_max_months"`
+	Phones       types.StringSlice `json:"phones"`
+	Species      *string           `json:"species"`
+}
+
+type PostContact struct {
+	OwnerID int64   `json:"owner_id"`
+	PostID  int64   `json:"post_id"`
+	Idx     int64   `json:"idx"`
+	Kind    string  `json:"kind"`
+	Value   string  `json:"value"`
+	Name    *string `json:"name"`
+}
+
+type PostIdentifier struct {
+	OwnerID int64  `json:"owner_id"`
+	PostID  int64  `json:"post_id"`
+	Kind    string `json:"kind"`
+	Value   string `json:"value"`
+}
+
+type PostMatch struct {
+	LostOwnerID  int64     `json:"lost_owner_id"`
+	LostPostID   int64     `json:"lost_post_id"`
+	FoundOwnerID int64     `json:"found_owner_id"`
+	FoundPostID  int64     `json:"found_post_id"`
+	Kind         string    `json:"kind"`
+	Value        string    `json:"value"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
 }
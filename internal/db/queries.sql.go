@@ -18,7 +18,7 @@ SET status='sending', leased_until=?1, updated_at=CURRENT_TIMESTAMP
 WHERE id IN (
   SELECT id FROM outbox
   WHERE status='pending' AND (leased_until IS NULL OR leased_until < strftime('%s','now'))
-  ORDER BY created_at ASC
+  ORDER BY priority DESC, created_at ASC
   LIMIT ?2
 )
 `
@@ -33,24 +33,93 @@ func (q *Queries) ClaimPendingMark(ctx context.Context, arg ClaimPendingMarkPara
 	return err
 }
 
-const enqueueOutbox = `-- name: EnqueueOutbox :exec
+const deletePostAnimals = `-- name: DeletePostAnimals :exec
 
-INSERT INTO outbox (owner_id, post_id)
-VALUES (?1, ?2)
-ON CONFLICT(owner_id, post_id) DO NOTHING
+DELETE FROM post_animals WHERE owner_id = ?1 AND post_id = ?2
 `
 
-type EnqueueOutboxParams struct {
+type DeletePostAnimalsParams struct {
+	OwnerID int64 `json:"owner_id"`
+	PostID  int64 `json:"post_id"`
+}
+
+// Animals of multi-animal posts
+func (q *Queries) DeletePostAnimals(ctx context.Context, arg DeletePostAnimalsParams) error {
+	_, err := q.db.ExecContext(ctx, deletePostAnimals, arg.OwnerID, arg.PostID)
+	return err
+}
+
+const deletePostContacts = `-- name: DeletePostContacts :exec
+
+DELETE FROM post_contacts WHERE owner_id = ?1 AND post_id = ?2
+`
+
+type DeletePostContactsParams struct {
 	OwnerID int64 `json:"owner_id"`
 	PostID  int64 `json:"post_id"`
 }
 
+// Contact channels
+func (q *Queries) DeletePostContacts(ctx context.Context, arg DeletePostContactsParams) error {
+	_, err := q.db.ExecContext(ctx, deletePostContacts, arg.OwnerID, arg.PostID)
+	return err
+}
+
+const deletePostIdentifiers = `-- name: DeletePostIdentifiers :exec
+
+DELETE FROM post_identifiers WHERE owner_id = ?1 AND post_id = ?2
+`
+
+type DeletePostIdentifiersParams struct {
+	OwnerID int64 `json:"owner_id"`
+	PostID  int64 `json:"post_id"`
+}
+
+// Identifiers and lost/found matches
+func (q *Queries) DeletePostIdentifiers(ctx context.Context, arg DeletePostIdentifiersParams) error {
+	_, err := q.db.ExecContext(ctx, deletePostIdentifiers, arg.OwnerID, arg.PostID)
+	return err
+}
+
+const enqueueOutbox = `-- name: EnqueueOutbox :exec
+
+INSERT INTO outbox (owner_id, post_id, priority)
+VALUES (?1, ?2, ?3)
+ON CONFLICT(owner_id, post_id) DO UPDATE SET priority = excluded.priority
+WHERE outbox.status = 'pending' AND excluded.priority > outbox.priority
+`
+
+type EnqueueOutboxParams struct {
+	OwnerID  int64 `json:"owner_id"`
+	PostID   int64 `json:"post_id"`
+	Priority int64 `json:"priority"`
+}
+
 // Outbox queries
+// Re-enqueueing a pending post raises its priority if it became more urgent.
 func (q *Queries) EnqueueOutbox(ctx context.Context, arg EnqueueOutboxParams) error {
-	_, err := q.db.ExecContext(ctx, enqueueOutbox, arg.OwnerID, arg.PostID)
+	_, err := q.db.ExecContext(ctx, enqueueOutbox, arg.OwnerID, arg.PostID, arg.Priority)
 	return err
 }
 
+const existsLabel = `-- name: ExistsLabel :one
+SELECT EXISTS(
+  SELECT 1 FROM labels WHERE owner_id = ?1 AND post_id = ?2
+)
+`
+
+type ExistsLabelParams struct {
+	OwnerID int64 `json:"owner_id"`
+	PostID  int64 `json:"post_id"`
+}
+
+func (q *Queries) ExistsLabel(ctx context.Context, arg ExistsLabelParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, existsLabel, arg.OwnerID, arg.PostID)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
 const existsPost = `-- name: ExistsPost :one
 SELECT EXISTS(
   SELECT 1 FROM posts WHERE owner_id = ?1 AND post_id = ?2
@@ -69,9 +138,78 @@ func (q *Queries) ExistsPost(ctx context.Context, arg ExistsPostParams) (int64,
 	return column_1, err
 }
 
+const findPostsByIdentifier = `-- name: FindPostsByIdentifier :many
+
+SELECT p.owner_id, p.post_id, p.type
+FROM post_identifiers i
+JOIN posts p ON p.owner_id = i.owner_id AND p.post_id = i.post_id
+WHERE i.kind = ?1 AND i.value = ?2
+  AND NOT (i.owner_id = ?3 AND i.post_id = ?4)
+ORDER BY p.date, p.owner_id, p.post_id
+`
+
+type FindPostsByIdentifierParams struct {
+	Kind    string `json:"kind"`
+	Value   string `json:"value"`
+	OwnerID int64  `json:"owner_id"`
+	PostID  int64  `json:"post_id"`
+}
+
+type FindPostsByIdentifierRow struct {
+	OwnerID int64  `json:"owner_id"`
+	PostID  int64  `json:"post_id"`
+	Type    string `json:"type"`
+}
+
+// Other posts carrying the same identifier, oldest first.
+func (q *Queries) FindPostsByIdentifier(ctx context.Context, arg FindPostsByIdentifierParams) ([]FindPostsByIdentifierRow, error) {
+	rows, err := q.db.QueryContext(ctx, findPostsByIdentifier,
+		arg.Kind,
+		arg.Value,
+		arg.OwnerID,
+		arg.PostID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindPostsByIdentifierRow
+	for rows.Next() {
+		var i FindPostsByIdentifierRow
+		if err := rows.Scan(
+			&i.OwnerID,
+			&i.PostID,
+			&i.Type,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPost = `-- name: GetPost :one
 SELECT owner_id, post_id, date, text, raw, type, animal, sex, name, location, "when",
-       phones, contact_names, vk_accounts, status_details, created_at
+       contact_names, photos, status_details,
+       breed, age, sterilized, vaccinated, chipped, litter_ok, parser_version,
+       type_source, animal_source,
+       when_from, when_to, when_precision,
+       address, district, lat, lon,
+       age_min_months, age_max_months, age_class,
+       colors, coat, gear, marks,
+       breed_id, breed_mixed,
+       resolved,
+       species,
+       size, weight_min_kg, weight_max_kg, height_min_cm, height_max_cm, build,
+       urgency, health,
+       reward, reward_amount, scam_risk,
+       role, role_source, repost, created_at
 FROM posts
 WHERE owner_id = ?1 AND post_id = ?2
 `
@@ -93,10 +231,50 @@ type GetPostRow struct {
 	Name          *string           `json:"name"`
 	Location      *string           `json:"location"`
 	When          *string           `json:"when"`
-	Phones        types.StringSlice `json:"phones"`
 	ContactNames  types.StringSlice `json:"contact_names"`
-	VkAccounts    types.StringSlice `json:"vk_accounts"`
+	Photos        types.StringSlice `json:"photos"`
 	StatusDetails *string           `json:"status_details"`
+	Breed         *string           `json:"breed"`
+	Age           *string           `json:"age"`
+	Sterilized    bool              `json:"sterilized"`
+	Vaccinated    bool              `json:"vaccinated"`
+	Chipped       bool              `json:"chipped"`
+	LitterOk      bool              `json:"litter_ok"`
+	ParserVersion *string           `json:"parser_version"`
+	TypeSource    *string           `json:"type_source"`
+	AnimalSource  *string           `json:"animal_source"`
+	WhenFrom      *int64            `json:"when_from"`
+	WhenTo        *int64            `json:"when_to"`
+	WhenPrecision *string           `json:"when_precision"`
+	Address       *string           `json:"address"`
+	District      *string           `json:"district"`
+	Lat           *float64          `json:"lat"`
+	Lon           *float64          `json:"lon"`
+	AgeMinMonths  *int64            `json:"age_min_months"`
+	AgeMaxMonths  *int64            `json:"age_max_months"`
+	AgeClass      *string           `json:"age_class"`
+	Colors        types.StringSlice `json:"colors"`
+	Coat          *string           `json:"coat"`
+	Gear          types.StringSlice `json:"gear"`
+	Marks         types.StringSlice `json:"marks"`
+	BreedID       *string           `json:"breed_id"`
+	BreedMixed    bool              `json:"breed_mixed"`
+	Resolved      bool              `json:"resolved"`
+	Species       *string           `json:"species"`
+	Size          *string           `json:"size"`
+	WeightMinKg   *float64          `json:"weight_min_kg"`
+	WeightMaxKg   *float64          `json:"weight_max_kg"`
+	HeightMinCm   *int64            `json:"height_min_cm"`
+	HeightMaxCm   *int64            `json:"height_max_cm"`
+	Build         *string           `json:"build"`
+	Urgency       *string           `json:"urgency"`
+	Health        types.StringSlice `json:"health"`
+	Reward        bool              `json:"reward"`
+	RewardAmount  *int64            `json:"reward_amount"`
+	ScamRisk      bool              `json:"scam_risk"`
+	Role          *string           `json:"role"`
+	RoleSource    *string           `json:"role_source"`
+	Repost        bool              `json:"repost"`
 	CreatedAt     time.Time         `json:"created_at"`
 }
 
@@ -115,20 +293,628 @@ func (q *Queries) GetPost(ctx context.Context, arg GetPostParams) (GetPostRow, e
 		&i.Name,
 		&i.Location,
 		&i.When,
-		&i.Phones,
 		&i.ContactNames,
-		&i.VkAccounts,
+		&i.Photos,
 		&i.StatusDetails,
+		&i.Breed,
+		&i.Age,
+		&i.Sterilized,
+		&i.Vaccinated,
+		&i.Chipped,
+		&i.LitterOk,
+		&i.ParserVersion,
+		&i.TypeSource,
+		&i.AnimalSource,
+		&i.WhenFrom,
+		&i.WhenTo,
+		&i.WhenPrecision,
+		&i.Address,
+		&i.District,
+		&i.Lat,
+		&i.Lon,
+		&i.AgeMinMonths,
+		&i.AgeMaxMonths,
+		&i.AgeClass,
+		&i.Colors,
+		&i.Coat,
+		&i.Gear,
+		&i.Marks,
+		&i.BreedID,
+		&i.BreedMixed,
+		&i.Resolved,
+		&i.Species,
+		&i.Size,
+		&i.WeightMinKg,
+		&i.WeightMaxKg,
+		&i.HeightMinCm,
+		&i.HeightMaxCm,
+		&i.Build,
+		&i.Urgency,
+		&i.Health,
+		&i.Reward,
+		&i.RewardAmount,
+		&i.ScamRisk,
+		&i.Role,
+		&i.RoleSource,
+		&i.Repost,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
+const getPostExplain = `-- name: GetPostExplain :one
+SELECT raw, date, parser_version, explain
+FROM posts
+WHERE owner_id = ?1 AND post_id = ?2
+`
+
+type GetPostExplainParams struct {
+	OwnerID int64 `json:"owner_id"`
+	PostID  int64 `json:"post_id"`
+}
+
+type GetPostExplainRow struct {
+	Raw           string  `json:"raw"`
+	Date          int64   `json:"date"`
+	ParserVersion *string `json:"parser_version"`
+	Explain       *string `json:"explain"`
+}
+
+func (q *Queries) GetPostExplain(ctx context.Context, arg GetPostExplainParams) (GetPostExplainRow, error) {
+	row := q.db.QueryRowContext(ctx, getPostExplain, arg.OwnerID, arg.PostID)
+	var i GetPostExplainRow
+	err := row.Scan(
+		&i.Raw,
+		&i.Date,
+		&i.ParserVersion,
+		&i.Explain,
+	)
+	return i, err
+}
+
+const insertPostAnimal = `-- name: InsertPostAnimal :exec
+INSERT INTO post_animals (
+  owner_id, post_id, idx, text, type, animal, sex, name,
+  breed, breed_id, age, age_min_months, age_max_months, phones, species
+) VALUES (
+  ?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8,
+  ?9, ?10, ?11, ?12, ?13, ?14, ?15
+)
+`
+
+type InsertPostAnimalParams struct {
+	OwnerID      int64             `json:"owner_id"`
+	PostID       int64             `json:"post_id"`
+	Idx          int64             `json:"idx"`
+	Text         string            `json:"text"`
+	Type         string            `json:"type"`
+	Animal       string            `json:"animal"`
+	Sex          string            `json:"sex"`
+	Name         *string           `json:"name"`
+	Breed        *string           `json:"breed"`
+	BreedID      *string           `json:"breed_id"`
+	Age          *string           `json:"age"`
+	AgeMinMonths *int64            `json:"age_min_months"`
+	AgeMaxMonths *int64            `json:"age_max_months"`
+	Phones       types.StringSlice `json:"phones"`
+	Species      *string           `json:"species"`
+}
+
+func (q *Queries) InsertPostAnimal(ctx context.Context, arg InsertPostAnimalParams) error {
+	_, err := q.db.ExecContext(ctx, insertPostAnimal,
+		arg.OwnerID,
+		arg.PostID,
+		arg.Idx,
+		arg.Text,
+		arg.Type,
+		arg.Animal,
+		arg.Sex,
+		arg.Name,
+		arg.Breed,
+		arg.BreedID,
+		arg.Age,
+		arg.AgeMinMonths,
+		arg.AgeMaxMonths,
+		arg.Phones,
+		arg.Species,
+	)
+	return err
+}
+
+const insertPostContact = `-- name: InsertPostContact :exec
+INSERT INTO post_contacts (owner_id, post_id, idx, kind, value, name)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+`
+
+type InsertPostContactParams struct {
+	OwnerID int64   `json:"owner_id"`
+	PostID  int64   `json:"post_id"`
+	Idx     int64   `json:"idx"`
+	Kind    string  `json:"kind"`
+	Value   string  `json:"value"`
+	Name    *string `json:"name"`
+}
+
+func (q *Queries) InsertPostContact(ctx context.Context, arg InsertPostContactParams) error {
+	_, err := q.db.ExecContext(ctx, insertPostContact,
+		arg.OwnerID,
+		arg.PostID,
+		arg.Idx,
+		arg.Kind,
+		arg.Value,
+		arg.Name,
+	)
+	return err
+}
+
+const insertPostIdentifier = `-- name: InsertPostIdentifier :exec
+INSERT INTO post_identifiers (owner_id, post_id, kind, value)
+VALUES (?1, ?2, ?3, ?4)
+ON CONFLICT DO NOTHING
+`
+
+type InsertPostIdentifierParams struct {
+	OwnerID int64  `json:"owner_id"`
+	PostID  int64  `json:"post_id"`
+	Kind    string `json:"kind"`
+	Value   string `json:"value"`
+}
+
+func (q *Queries) InsertPostIdentifier(ctx context.Context, arg InsertPostIdentifierParams) error {
+	_, err := q.db.ExecContext(ctx, insertPostIdentifier,
+		arg.OwnerID,
+		arg.PostID,
+		arg.Kind,
+		arg.Value,
+	)
+	return err
+}
+
+const insertPostMatch = `-- name: InsertPostMatch :execrows
+INSERT INTO post_matches (lost_owner_id, lost_post_id, found_owner_id, found_post_id, kind, value)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+ON CONFLICT DO NOTHING
+`
+
+type InsertPostMatchParams struct {
+	LostOwnerID  int64  `json:"lost_owner_id"`
+	LostPostID   int64  `json:"lost_post_id"`
+	FoundOwnerID int64  `json:"found_owner_id"`
+	FoundPostID  int64  `json:"found_post_id"`
+	Kind         string `json:"kind"`
+	Value        string `json:"value"`
+}
+
+func (q *Queries) InsertPostMatch(ctx context.Context, arg InsertPostMatchParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, insertPostMatch,
+		arg.LostOwnerID,
+		arg.LostPostID,
+		arg.FoundOwnerID,
+		arg.FoundPostID,
+		arg.Kind,
+		arg.Value,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const listLabels = `-- name: ListLabels :many
+SELECT owner_id, post_id, raw, source, type, animal, sex, age_class, size, urgency, role, note
+FROM labels
+ORDER BY owner_id, post_id
+`
+
+type ListLabelsRow struct {
+	OwnerID  int64   `json:"owner_id"`
+	PostID   int64   `json:"post_id"`
+	Raw      string  `json:"raw"`
+	Source   string  `json:"source"`
+	Type     *string `json:"type"`
+	Animal   *string `json:"animal"`
+	Sex      *string `json:"sex"`
+	AgeClass *string `json:"age_class"`
+	Size     *string `json:"size"`
+	Urgency  *string `json:"urgency"`
+	Role     *string `json:"role"`
+	Note     *string `json:"note"`
+}
+
+func (q *Queries) ListLabels(ctx context.Context) ([]ListLabelsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLabels)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLabelsRow
+	for rows.Next() {
+		var i ListLabelsRow
+		if err := rows.Scan(
+			&i.OwnerID,
+			&i.PostID,
+			&i.Raw,
+			&i.Source,
+			&i.Type,
+			&i.Animal,
+			&i.Sex,
+			&i.AgeClass,
+			&i.Size,
+			&i.Urgency,
+			&i.Role,
+			&i.Note,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostAnimals = `-- name: ListPostAnimals :many
+SELECT owner_id, post_id, idx, text, type, animal, sex, name,
+       breed, breed_id, age, age_min_months, age_max_months, phones, species
+FROM post_animals
+WHERE owner_id = ?1 AND post_id = ?2
+ORDER BY idx
+`
+
+type ListPostAnimalsParams struct {
+	OwnerID int64 `json:"owner_id"`
+	PostID  int64 `json:"post_id"`
+}
+
+func (q *Queries) ListPostAnimals(ctx context.Context, arg ListPostAnimalsParams) ([]PostAnimal, error) {
+	rows, err := q.db.QueryContext(ctx, listPostAnimals, arg.OwnerID, arg.PostID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostAnimal
+	for rows.Next() {
+		var i PostAnimal
+		if err := rows.Scan(
+			&i.OwnerID,
+			&i.PostID,
+			&i.Idx,
+			&i.Text,
+			&i.Type,
+			&i.Animal,
+			&i.Sex,
+			&i.Name,
+			&i.Breed,
+			&i.BreedID,
+			&i.Age,
+			&i.AgeMinMonths,
+			&i.AgeMaxMonths,
+			&i.Phones,
+			&i.Species,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostContacts = `-- name: ListPostContacts :many
+SELECT owner_id, post_id, idx, kind, value, name
+FROM post_contacts
+WHERE owner_id = ?1 AND post_id = ?2
+ORDER BY idx
+`
+
+type ListPostContactsParams struct {
+	OwnerID int64 `json:"owner_id"`
+	PostID  int64 `json:"post_id"`
+}
+
+func (q *Queries) ListPostContacts(ctx context.Context, arg ListPostContactsParams) ([]PostContact, error) {
+	rows, err := q.db.QueryContext(ctx, listPostContacts, arg.OwnerID, arg.PostID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostContact
+	for rows.Next() {
+		var i PostContact
+		if err := rows.Scan(
+			&i.OwnerID,
+			&i.PostID,
+			&i.Idx,
+			&i.Kind,
+			&i.Value,
+			&i.Name,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostIdentifiers = `-- name: ListPostIdentifiers :many
+SELECT owner_id, post_id, kind, value
+FROM post_identifiers
+WHERE owner_id = ?1 AND post_id = ?2
+ORDER BY rowid
+`
+
+type ListPostIdentifiersParams struct {
+	OwnerID int64 `json:"owner_id"`
+	PostID  int64 `json:"post_id"`
+}
+
+func (q *Queries) ListPostIdentifiers(ctx context.Context, arg ListPostIdentifiersParams) ([]PostIdentifier, error) {
+	rows, err := q.db.QueryContext(ctx, listPostIdentifiers, arg.OwnerID, arg.PostID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostIdentifier
+	for rows.Next() {
+		var i PostIdentifier
+		if err := rows.Scan(
+			&i.OwnerID,
+			&i.PostID,
+			&i.Kind,
+			&i.Value,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostMatches = `-- name: ListPostMatches :many
+SELECT lost_owner_id, lost_post_id, found_owner_id, found_post_id, kind, value, status, created_at
+FROM post_matches
+WHERE status = ?1
+ORDER BY created_at, lost_owner_id, lost_post_id, found_owner_id, found_post_id
+`
+
+func (q *Queries) ListPostMatches(ctx context.Context, status string) ([]PostMatch, error) {
+	rows, err := q.db.QueryContext(ctx, listPostMatches, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostMatch
+	for rows.Next() {
+		var i PostMatch
+		if err := rows.Scan(
+			&i.LostOwnerID,
+			&i.LostPostID,
+			&i.FoundOwnerID,
+			&i.FoundPostID,
+			&i.Kind,
+			&i.Value,
+			&i.Status,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsPage = `-- name: ListPostsPage :many
+SELECT owner_id, post_id, date, text, raw, type, animal, sex, name, location, "when",
+       contact_names, photos, status_details,
+       breed, age, sterilized, vaccinated, chipped, litter_ok, parser_version,
+       type_source, animal_source,
+       when_from, when_to, when_precision,
+       address, district, lat, lon,
+       age_min_months, age_max_months, age_class,
+       colors, coat, gear, marks,
+       breed_id, breed_mixed,
+       resolved,
+       species,
+       size, weight_min_kg, weight_max_kg, height_min_cm, height_max_cm, build,
+       urgency, health,
+       reward, reward_amount, scam_risk,
+       role, role_source, repost, created_at
+FROM posts
+WHERE (owner_id > ?1 OR (owner_id = ?1 AND post_id > ?2))
+  AND date >= ?3 AND date < ?4
+  AND (?5 IS NULL OR owner_id = ?5)
+ORDER BY owner_id, post_id
+LIMIT ?6
+`
+
+type ListPostsPageParams struct {
+	AfterOwnerID int64  `json:"after_owner_id"`
+	AfterPostID  int64  `json:"after_post_id"`
+	DateFrom     int64  `json:"date_from"`
+	DateTo       int64  `json:"date_to"`
+	OwnerID      *int64 `json:"owner_id"`
+	Limit        int64  `json:"limit"`
+}
+
+type ListPostsPageRow struct {
+	OwnerID       int64             `json:"owner_id"`
+	PostID        int64             `json:"post_id"`
+	Date          int64             `json:"date"`
+	Text          string            `json:"text"`
+	Raw           string            `json:"raw"`
+	Type          string            `json:"type"`
+	Animal        string            `json:"animal"`
+	Sex           string            `json:"sex"`
+	Name          *string           `json:"name"`
+	Location      *string           `json:"location"`
+	When          *string           `json:"when"`
+	ContactNames  types.StringSlice `json:"contact_names"`
+	Photos        types.StringSlice `json:"photos"`
+	StatusDetails *string           `json:"status_details"`
+	Breed         *string           `json:"breed"`
+	Age           *string           `json:"age"`
+	Sterilized    bool              `json:"sterilized"`
+	Vaccinated    bool              `json:"vaccinated"`
+	Chipped       bool              `json:"chipped"`
+	LitterOk      bool              `json:"litter_ok"`
+	ParserVersion *string           `json:"parser_version"`
+	TypeSource    *string           `json:"type_source"`
+	AnimalSource  *string           `json:"animal_source"`
+	WhenFrom      *int64            `json:"when_from"`
+	WhenTo        *int64            `json:"when_to"`
+	WhenPrecision *string           `json:"when_precision"`
+	Address       *string           `json:"address"`
+	District      *string           `json:"district"`
+	Lat           *float64          `json:"lat"`
+	Lon           *float64          `json:"lon"`
+	AgeMinMonths  *int64            `json:"age_min_months"`
+	AgeMaxMonths  *int64            `json:"age_max_months"`
+	AgeClass      *string           `json:"age_class"`
+	Colors        types.StringSlice `json:"colors"`
+	Coat          *string           `json:"coat"`
+	Gear          types.StringSlice `json:"gear"`
+	Marks         types.StringSlice `json:"marks"`
+	BreedID       *string           `json:"breed_id"`
+	BreedMixed    bool              `json:"breed_mixed"`
+	Resolved      bool              `json:"resolved"`
+	Species       *string           `json:"species"`
+	Size          *string           `json:"size"`
+	WeightMinKg   *float64          `json:"weight_min_kg"`
+	WeightMaxKg   *float64          `json:"weight_max_kg"`
+	HeightMinCm   *int64            `json:"height_min_cm"`
+	HeightMaxCm   *int64            `json:"height_max_cm"`
+	Build         *string           `json:"build"`
+	Urgency       *string           `json:"urgency"`
+	Health        types.StringSlice `json:"health"`
+	Reward        bool              `json:"reward"`
+	RewardAmount  *int64            `json:"reward_amount"`
+	ScamRisk      bool              `json:"scam_risk"`
+	Role          *string           `json:"role"`
+	RoleSource    *string           `json:"role_source"`
+	Repost        bool              `json:"repost"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+// Keyset-paginated scan over stored posts, used by the reparse command.
+func (q *Queries) ListPostsPage(ctx context.Context, arg ListPostsPageParams) ([]ListPostsPageRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPostsPage,
+		arg.AfterOwnerID,
+		arg.AfterPostID,
+		arg.DateFrom,
+		arg.DateTo,
+		arg.OwnerID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPostsPageRow
+	for rows.Next() {
+		var i ListPostsPageRow
+		if err := rows.Scan(
+			&i.OwnerID,
+			&i.PostID,
+			&i.Date,
+			&i.Text,
+			&i.Raw,
+			&i.Type,
+			&i.Animal,
+			&i.Sex,
+			&i.Name,
+			&i.Location,
+			&i.When,
+			&i.ContactNames,
+			&i.Photos,
+			&i.StatusDetails,
+			&i.Breed,
+			&i.Age,
+			&i.Sterilized,
+			&i.Vaccinated,
+			&i.Chipped,
+			&i.LitterOk,
+			&i.ParserVersion,
+			&i.TypeSource,
+			&i.AnimalSource,
+			&i.WhenFrom,
+			&i.WhenTo,
+			&i.WhenPrecision,
+			&i.Address,
+			&i.District,
+			&i.Lat,
+			&i.Lon,
+			&i.AgeMinMonths,
+			&i.AgeMaxMonths,
+			&i.AgeClass,
+			&i.Colors,
+			&i.Coat,
+			&i.Gear,
+			&i.Marks,
+			&i.BreedID,
+			&i.BreedMixed,
+			&i.Resolved,
+			&i.Species,
+			&i.Size,
+			&i.WeightMinKg,
+			&i.WeightMaxKg,
+			&i.HeightMinCm,
+			&i.HeightMaxCm,
+			&i.Build,
+			&i.Urgency,
+			&i.Health,
+			&i.Reward,
+			&i.RewardAmount,
+			&i.ScamRisk,
+			&i.Role,
+			&i.RoleSource,
+			&i.Repost,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listSendingByLease = `-- name: ListSendingByLease :many
 SELECT id, owner_id, post_id
 FROM outbox
 WHERE status='sending' AND leased_until=?1
-ORDER BY created_at ASC
+ORDER BY priority DESC, created_at ASC
 `
 
 type ListSendingByLeaseRow struct {
@@ -160,6 +946,51 @@ func (q *Queries) ListSendingByLease(ctx context.Context, lease *int64) ([]ListS
 	return items, nil
 }
 
+const listUnlabeledPosts = `-- name: ListUnlabeledPosts :many
+SELECT p.owner_id, p.post_id, p.raw
+FROM posts p
+LEFT JOIN labels l ON l.owner_id = p.owner_id AND l.post_id = p.post_id
+WHERE l.post_id IS NULL
+  AND (?1 IS NULL OR p.type = ?1)
+ORDER BY p.date DESC
+LIMIT ?2
+`
+
+type ListUnlabeledPostsParams struct {
+	Type  *string `json:"type"`
+	Limit int64   `json:"limit"`
+}
+
+type ListUnlabeledPostsRow struct {
+	OwnerID int64  `json:"owner_id"`
+	PostID  int64  `json:"post_id"`
+	Raw     string `json:"raw"`
+}
+
+// Newest stored posts that have no gold label yet, optionally of one parsed type.
+func (q *Queries) ListUnlabeledPosts(ctx context.Context, arg ListUnlabeledPostsParams) ([]ListUnlabeledPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUnlabeledPosts, arg.Type, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUnlabeledPostsRow
+	for rows.Next() {
+		var i ListUnlabeledPostsRow
+		if err := rows.Scan(&i.OwnerID, &i.PostID, &i.Raw); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const markFailed = `-- name: MarkFailed :exec
 UPDATE outbox
 SET status=CASE WHEN retries+1>=?1 THEN 'failed' ELSE 'pending' END,
@@ -197,6 +1028,46 @@ func (q *Queries) MarkSent(ctx context.Context, arg MarkSentParams) error {
 	return err
 }
 
+const phoneHistory = `-- name: PhoneHistory :one
+
+SELECT COUNT(DISTINCT p.animal || '|' || p.sex || '|' || COALESCE(p.breed_id, '') || '|' || COALESCE(p.name, '')) AS cases,
+       COUNT(DISTINCT CASE WHEN p.type = 'adoption'
+         THEN p.animal || '|' || p.sex || '|' || COALESCE(p.breed_id, '') || '|' || COALESCE(p.name, '') END) AS adoptions
+FROM post_contacts c
+JOIN posts p ON p.owner_id = c.owner_id AND p.post_id = c.post_id
+WHERE c.kind = 'phone' AND c.value = ?1 AND p.date >= ?2 AND p.date < ?3
+  AND p.type NOT IN ('empty', 'link')
+  AND NOT (c.owner_id = ?4 AND c.post_id = ?5)
+`
+
+type PhoneHistoryParams struct {
+	Value   string `json:"value"`
+	Since   int64  `json:"since"`
+	Until   int64  `json:"until"`
+	OwnerID int64  `json:"owner_id"`
+	PostID  int64  `json:"post_id"`
+}
+
+type PhoneHistoryRow struct {
+	Cases     int64 `json:"cases"`
+	Adoptions int64 `json:"adoptions"`
+}
+
+// Distinct animals in other posts from [@since, @until) with the phone, and how
+// many of them were up for adoption.
+func (q *Queries) PhoneHistory(ctx context.Context, arg PhoneHistoryParams) (PhoneHistoryRow, error) {
+	row := q.db.QueryRowContext(ctx, phoneHistory,
+		arg.Value,
+		arg.Since,
+		arg.Until,
+		arg.OwnerID,
+		arg.PostID,
+	)
+	var i PhoneHistoryRow
+	err := row.Scan(&i.Cases, &i.Adoptions)
+	return i, err
+}
+
 const reapStale = `-- name: ReapStale :exec
 UPDATE outbox
 SET status='pending', leased_until=NULL, updated_at=CURRENT_TIMESTAMP
@@ -208,6 +1079,86 @@ func (q *Queries) ReapStale(ctx context.Context) error {
 	return err
 }
 
+const setPostMatchStatus = `-- name: SetPostMatchStatus :execrows
+UPDATE post_matches SET status = ?1
+WHERE lost_owner_id = ?2 AND lost_post_id = ?3
+  AND found_owner_id = ?4 AND found_post_id = ?5
+`
+
+type SetPostMatchStatusParams struct {
+	Status       string `json:"status"`
+	LostOwnerID  int64  `json:"lost_owner_id"`
+	LostPostID   int64  `json:"lost_post_id"`
+	FoundOwnerID int64  `json:"found_owner_id"`
+	FoundPostID  int64  `json:"found_post_id"`
+}
+
+func (q *Queries) SetPostMatchStatus(ctx context.Context, arg SetPostMatchStatusParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setPostMatchStatus,
+		arg.Status,
+		arg.LostOwnerID,
+		arg.LostPostID,
+		arg.FoundOwnerID,
+		arg.FoundPostID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertLabel = `-- name: UpsertLabel :exec
+
+INSERT INTO labels (owner_id, post_id, raw, source, type, animal, sex, age_class, size, urgency, role, note)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12)
+ON CONFLICT(owner_id, post_id) DO UPDATE SET
+  raw = excluded.raw,
+  source = excluded.source,
+  type = excluded.type,
+  animal = excluded.animal,
+  sex = excluded.sex,
+  age_class = excluded.age_class,
+  size = excluded.size,
+  urgency = excluded.urgency,
+  role = excluded.role,
+  note = excluded.note,
+  updated_at = CURRENT_TIMESTAMP
+`
+
+type UpsertLabelParams struct {
+	OwnerID  int64   `json:"owner_id"`
+	PostID   int64   `json:"post_id"`
+	Raw      string  `json:"raw"`
+	Source   string  `json:"source"`
+	Type     *string `json:"type"`
+	Animal   *string `json:"animal"`
+	Sex      *string `json:"sex"`
+	AgeClass *string `json:"age_class"`
+	Size     *string `json:"size"`
+	Urgency  *string `json:"urgency"`
+	Role     *string `json:"role"`
+	Note     *string `json:"note"`
+}
+
+// Gold labels
+func (q *Queries) UpsertLabel(ctx context.Context, arg UpsertLabelParams) error {
+	_, err := q.db.ExecContext(ctx, upsertLabel,
+		arg.OwnerID,
+		arg.PostID,
+		arg.Raw,
+		arg.Source,
+		arg.Type,
+		arg.Animal,
+		arg.Sex,
+		arg.AgeClass,
+		arg.Size,
+		arg.Urgency,
+		arg.Role,
+		arg.Note,
+	)
+	return err
+}
+
 const upsertPost = `-- name: UpsertPost :exec
 INSERT INTO posts (
   owner_id,
@@ -221,11 +1172,51 @@ INSERT INTO posts (
   name,
   location,
   "when",
-  phones,
   contact_names,
-  vk_accounts,
   photos,
-  status_details
+  status_details,
+  breed,
+  age,
+  sterilized,
+  vaccinated,
+  chipped,
+  litter_ok,
+  parser_version,
+  explain,
+  type_source,
+  animal_source,
+  when_from,
+  when_to,
+  when_precision,
+  address,
+  district,
+  lat,
+  lon,
+  age_min_months,
+  age_max_months,
+  age_class,
+  colors,
+  coat,
+  gear,
+  marks,
+  breed_id,
+  breed_mixed,
+  resolved,
+  species,
+  size,
+  weight_min_kg,
+  weight_max_kg,
+  height_min_cm,
+  height_max_cm,
+  build,
+  urgency,
+  health,
+  reward,
+  reward_amount,
+  scam_risk,
+  role,
+  role_source,
+  repost
 )
 VALUES (
   ?1,
@@ -243,7 +1234,47 @@ VALUES (
   ?13,
   ?14,
   ?15,
-  ?16
+  ?16,
+  ?17,
+  ?18,
+  ?19,
+  ?20,
+  ?21,
+  ?22,
+  ?23,
+  ?24,
+  ?25,
+  ?26,
+  ?27,
+  ?28,
+  ?29,
+  ?30,
+  ?31,
+  ?32,
+  ?33,
+  ?34,
+  ?35,
+  ?36,
+  ?37,
+  ?38,
+  ?39,
+  ?40,
+  ?41,
+  ?42,
+  ?43,
+  ?44,
+  ?45,
+  ?46,
+  ?47,
+  ?48,
+  ?49,
+  ?50,
+  ?51,
+  ?52,
+  ?53,
+  ?54,
+  ?55,
+  ?56
 )
 ON CONFLICT(owner_id, post_id) DO UPDATE SET
   date = excluded.date,
@@ -255,11 +1286,51 @@ ON CONFLICT(owner_id, post_id) DO UPDATE SET
   name = excluded.name,
   location = excluded.location,
   "when" = excluded."when",
-  phones = excluded.phones,
   contact_names = excluded.contact_names,
-  vk_accounts = excluded.vk_accounts,
   photos = excluded.photos,
-  status_details = excluded.status_details
+  status_details = excluded.status_details,
+  breed = excluded.breed,
+  age = excluded.age,
+  sterilized = excluded.sterilized,
+  vaccinated = excluded.vaccinated,
+  chipped = excluded.chipped,
+  litter_ok = excluded.litter_ok,
+  parser_version = excluded.parser_version,
+  explain = excluded.explain,
+  type_source = excluded.type_source,
+  animal_source = excluded.animal_source,
+  when_from = excluded.when_from,
+  when_to = excluded.when_to,
+  when_precision = excluded.when_precision,
+  address = excluded.address,
+  district = excluded.district,
+  lat = excluded.lat,
+  lon = excluded.lon,
+  age_min_months = excluded.age_min_months,
+  age_max_months = excluded.age_max_months,
+  age_class = excluded.age_class,
+  colors = excluded.colors,
+  coat = excluded.coat,
+  gear = excluded.gear,
+  marks = excluded.marks,
+  breed_id = excluded.breed_id,
+  breed_mixed = excluded.breed_mixed,
+  resolved = excluded.resolved,
+  species = excluded.species,
+  size = excluded.size,
+  weight_min_kg = excluded.weight_min_kg,
+  weight_max_kg = excluded.weight_max_kg,
+  height_min_cm = excluded.height_min_cm,
+  height_max_cm = excluded.height_max_cm,
+  build = excluded.build,
+  urgency = excluded.urgency,
+  health = excluded.health,
+  reward = excluded.reward,
+  reward_amount = excluded.reward_amount,
+  scam_risk = excluded.scam_risk,
+  role = excluded.role,
+  role_source = excluded.role_source,
+  repost = excluded.repost
 `
 
 type UpsertPostParams struct {
@@ -274,11 +1345,51 @@ type UpsertPostParams struct {
 	Name          *string           `json:"name"`
 	Location      *string           `json:"location"`
 	When          *string           `json:"when"`
-	Phones        types.StringSlice `json:"phones"`
 	ContactNames  types.StringSlice `json:"contact_names"`
-	VkAccounts    types.StringSlice `json:"vk_accounts"`
 	Photos        types.StringSlice `json:"photos"`
 	StatusDetails *string           `json:"status_details"`
+	Breed         *string           `json:"breed"`
+	Age           *string           `json:"age"`
+	Sterilized    bool              `json:"sterilized"`
+	Vaccinated    bool              `json:"vaccinated"`
+	Chipped       bool              `json:"chipped"`
+	LitterOk      bool              `json:"litter_ok"`
+	ParserVersion *string           `json:"parser_version"`
+	Explain       *string           `json:"explain"`
+	TypeSource    *string           `json:"type_source"`
+	AnimalSource  *string           `json:"animal_source"`
+	WhenFrom      *int64            `json:"when_from"`
+	WhenTo        *int64            `json:"when_to"`
+	WhenPrecision *string           `json:"when_precision"`
+	Address       *string           `json:"address"`
+	District      *string           `json:"district"`
+	Lat           *float64          `json:"lat"`
+	Lon           *float64          `json:"lon"`
+	AgeMinMonths  *int64            `json:"age_min_months"`
+	AgeMaxMonths  *int64            `json:"age_max_months"`
+	AgeClass      *string           `json:"age_class"`
+	Colors        types.StringSlice `json:"colors"`
+	Coat          *string           `json:"coat"`
+	Gear          types.StringSlice `json:"gear"`
+	Marks         types.StringSlice `json:"marks"`
+	BreedID       *string           `json:"breed_id"`
+	BreedMixed    bool              `json:"breed_mixed"`
+	Resolved      bool              `json:"resolved"`
+	Species       *string           `json:"species"`
+	Size          *string           `json:"size"`
+	WeightMinKg   *float64          `json:"weight_min_kg"`
+	WeightMaxKg   *float64          `json:"weight_max_kg"`
+	HeightMinCm   *int64            `json:"height_min_cm"`
+	HeightMaxCm   *int64            `json:"height_max_cm"`
+	Build         *string           `json:"build"`
+	Urgency       *string           `json:"urgency"`
+	Health        types.StringSlice `json:"health"`
+	Reward        bool              `json:"reward"`
+	RewardAmount  *int64            `json:"reward_amount"`
+	ScamRisk      bool              `json:"scam_risk"`
+	Role          *string           `json:"role"`
+	RoleSource    *string           `json:"role_source"`
+	Repost        bool              `json:"repost"`
 }
 
 // Insert or update a post with all parsed fields
@@ -295,11 +1406,51 @@ func (q *Queries) UpsertPost(ctx context.Context, arg UpsertPostParams) error {
 		arg.Name,
 		arg.Location,
 		arg.When,
-		arg.Phones,
 		arg.ContactNames,
-		arg.VkAccounts,
 		arg.Photos,
 		arg.StatusDetails,
+		arg.Breed,
+		arg.Age,
+		arg.Sterilized,
+		arg.Vaccinated,
+		arg.Chipped,
+		arg.LitterOk,
+		arg.ParserVersion,
+		arg.Explain,
+		arg.TypeSource,
+		arg.AnimalSource,
+		arg.WhenFrom,
+		arg.WhenTo,
+		arg.WhenPrecision,
+		arg.Address,
+		arg.District,
+		arg.Lat,
+		arg.Lon,
+		arg.AgeMinMonths,
+		arg.AgeMaxMonths,
+		arg.AgeClass,
+		arg.Colors,
+		arg.Coat,
+		arg.Gear,
+		arg.Marks,
+		arg.BreedID,
+		arg.BreedMixed,
+		arg.Resolved,
+		arg.Species,
+		arg.Size,
+		arg.WeightMinKg,
+		arg.WeightMaxKg,
+		arg.HeightMinCm,
+		arg.HeightMaxCm,
+		arg.Build,
+		arg.Urgency,
+		arg.Health,
+		arg.Reward,
+		arg.RewardAmount,
+		arg.ScamRisk,
+		arg.Role,
+		arg.RoleSource,
+		arg.Repost,
 	)
 	return err
 }
@@ -7,15 +7,19 @@ import (
 // Hand-written extensions for VK outbox, to avoid regenerating sqlc now.
 
 type EnqueueOutboxVKParams struct {
-	OwnerID int64 `json:"owner_id"`
-	PostID  int64 `json:"post_id"`
+	OwnerID  int64 `json:"owner_id"`
+	PostID   int64 `json:"post_id"`
+	Priority int64 `json:"priority"`
 }
 
+// EnqueueOutboxVK mirrors EnqueueOutbox: a pending row only ever gains
+// priority.
 func (q *Queries) EnqueueOutboxVK(ctx context.Context, arg EnqueueOutboxVKParams) error {
-	const stmt = `INSERT INTO outbox_vk (owner_id, post_id)
-VALUES (?, ?)
-ON CONFLICT(owner_id, post_id) DO NOTHING`
-	_, err := q.db.ExecContext(ctx, stmt, arg.OwnerID, arg.PostID)
+	const stmt = `INSERT INTO outbox_vk (owner_id, post_id, priority)
+VALUES (?, ?, ?)
+ON CONFLICT(owner_id, post_id) DO UPDATE SET priority = excluded.priority
+WHERE outbox_vk.status = 'pending' AND excluded.priority > outbox_vk.priority`
+	_, err := q.db.ExecContext(ctx, stmt, arg.OwnerID, arg.PostID, arg.Priority)
 	return err
 }
 
@@ -30,7 +34,7 @@ SET status='sending', leased_until=?, updated_at=CURRENT_TIMESTAMP
 WHERE id IN (
   SELECT id FROM outbox_vk
   WHERE status='pending' AND (leased_until IS NULL OR leased_until < strftime('%s','now'))
-  ORDER BY created_at ASC
+  ORDER BY priority DESC, created_at ASC
   LIMIT ?
 )`
 	_, err := q.db.ExecContext(ctx, stmt, arg.Lease, arg.Limit)
@@ -47,7 +51,7 @@ func (q *Queries) ListSendingByLeaseVK(ctx context.Context, lease *int64) ([]Lis
 	const stmt = `SELECT id, owner_id, post_id
 FROM outbox_vk
 WHERE status='sending' AND leased_until=?
-ORDER BY created_at ASC`
+ORDER BY priority DESC, created_at ASC`
 	rows, err := q.db.QueryContext(ctx, stmt, lease)
 	if err != nil {
 		return nil, err
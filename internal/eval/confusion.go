@@ -0,0 +1,130 @@
+// Package eval computes classification metrics for comparing parser output
+// against gold labels.
+package eval
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Confusion is a confusion matrix over string labels: counts[gold][predicted].
+type Confusion struct {
+	counts map[string]map[string]int
+	total  int
+}
+
+func NewConfusion() *Confusion {
+	return &Confusion{counts: map[string]map[string]int{}}
+}
+
+func (c *Confusion) Add(gold, pred string) {
+	row := c.counts[gold]
+	if row == nil {
+		row = map[string]int{}
+		c.counts[gold] = row
+	}
+	row[pred]++
+	c.total++
+}
+
+func (c *Confusion) Total() int { return c.total }
+
+func (c *Confusion) Count(gold, pred string) int { return c.counts[gold][pred] }
+
+// Labels returns every label seen as gold or prediction, sorted.
+func (c *Confusion) Labels() []string {
+	seen := map[string]bool{}
+	for g, row := range c.counts {
+		seen[g] = true
+		for p := range row {
+			seen[p] = true
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for l := range seen {
+		out = append(out, l)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (c *Confusion) Accuracy() float64 {
+	if c.total == 0 {
+		return 0
+	}
+	ok := 0
+	for g, row := range c.counts {
+		ok += row[g]
+	}
+	return float64(ok) / float64(c.total)
+}
+
+// Precision of label l: correct l predictions / all l predictions.
+func (c *Confusion) Precision(l string) float64 {
+	predicted := 0
+	for _, row := range c.counts {
+		predicted += row[l]
+	}
+	return ratio(c.counts[l][l], predicted)
+}
+
+// Recall of label l: correct l predictions / all gold l.
+func (c *Confusion) Recall(l string) float64 {
+	return ratio(c.counts[l][l], c.Support(l))
+}
+
+func (c *Confusion) F1(l string) float64 {
+	p, r := c.Precision(l), c.Recall(l)
+	if p+r == 0 {
+		return 0
+	}
+	return 2 * p * r / (p + r)
+}
+
+// Support is the number of gold examples of label l.
+func (c *Confusion) Support(l string) int {
+	n := 0
+	for _, v := range c.counts[l] {
+		n += v
+	}
+	return n
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+// Fprint writes per-label precision/recall/F1 followed by the matrix with
+// gold labels as rows and predictions as columns.
+func (c *Confusion) Fprint(w io.Writer) {
+	labels := c.Labels()
+	width := 8
+	for _, l := range labels {
+		width = max(width, len(l)+1)
+	}
+
+	fmt.Fprintf(w, "accuracy %.3f over %d\n", c.Accuracy(), c.total)
+	fmt.Fprintf(w, "%-*s %9s %9s %9s %8s\n", width, "label", "precision", "recall", "f1", "support")
+	for _, l := range labels {
+		fmt.Fprintf(w, "%-*s %9.3f %9.3f %9.3f %8d\n", width, l, c.Precision(l), c.Recall(l), c.F1(l), c.Support(l))
+	}
+
+	fmt.Fprintf(w, "\n%-*s", width, "gold\\pred")
+	for _, l := range labels {
+		fmt.Fprintf(w, " %*s", width, l)
+	}
+	fmt.Fprintln(w)
+	for _, g := range labels {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%-*s", width, g)
+		for _, p := range labels {
+			fmt.Fprintf(&b, " %*d", width, c.counts[g][p])
+		}
+		fmt.Fprintln(w, b.String())
+	}
+}
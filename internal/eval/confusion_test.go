@@ -0,0 +1,38 @@
+package eval
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfusion(t *testing.T) {
+	c := NewConfusion()
+	for _, gp := range [][2]string{
+		{"lost", "lost"}, {"lost", "lost"}, {"lost", "found"},
+		{"found", "found"}, {"found", "lost"},
+		{"unknown", "lost"},
+	} {
+		c.Add(gp[0], gp[1])
+	}
+
+	require.Equal(t, 6, c.Total())
+	assert.Equal(t, []string{"found", "lost", "unknown"}, c.Labels())
+	assert.InDelta(t, 3.0/6, c.Accuracy(), 1e-9)
+
+	assert.InDelta(t, 2.0/4, c.Precision("lost"), 1e-9)
+	assert.InDelta(t, 2.0/3, c.Recall("lost"), 1e-9)
+	assert.InDelta(t, 2*(0.5*2.0/3)/(0.5+2.0/3), c.F1("lost"), 1e-9)
+	assert.Equal(t, 3, c.Support("lost"))
+
+	assert.Zero(t, c.Precision("unknown"), "never predicted")
+	assert.Zero(t, c.Recall("unknown"))
+	assert.Zero(t, c.F1("unknown"))
+
+	var b strings.Builder
+	c.Fprint(&b)
+	assert.Contains(t, b.String(), "accuracy 0.500 over 6")
+	assert.Contains(t, b.String(), "gold\\pred")
+}
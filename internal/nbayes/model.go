@@ -0,0 +1,89 @@
+package nbayes
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Model bundles one classifier per parsed field ("type", "animal") and is
+// stored as gzip-compressed JSON.
+type Model struct {
+	Fields  map[string]*Classifier `json:"fields"`
+	version string
+}
+
+func NewModel() *Model {
+	return &Model{Fields: map[string]*Classifier{}}
+}
+
+// Classify implements lostdogs.Classifier.
+func (m *Model) Classify(field, text string) (string, float64, bool) {
+	c := m.Fields[field]
+	if c == nil || len(c.Docs) == 0 {
+		return "", 0, false
+	}
+	l, p := c.Predict(text)
+	return l, p, true
+}
+
+// Version is a short hash of the serialized model, set by Load.
+func (m *Model) Version() string { return m.version }
+
+func (m *Model) Write(w io.Writer) error {
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(m); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func (m *Model) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := m.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func Read(r io.Reader) (*Model, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("nbayes: %w", err)
+	}
+	defer zr.Close()
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("nbayes: %w", err)
+	}
+	m := NewModel()
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("nbayes: decode model: %w", err)
+	}
+	for field, c := range m.Fields {
+		if c == nil || c.MinN < 1 || c.MaxN < c.MinN {
+			return nil, fmt.Errorf("nbayes: field %q: invalid n-gram range", field)
+		}
+		c.rebuildVocab()
+	}
+	sum := sha256.Sum256(b)
+	m.version = hex.EncodeToString(sum[:4])
+	return m, nil
+}
+
+func Load(path string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
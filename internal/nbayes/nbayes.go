@@ -0,0 +1,136 @@
+// Package nbayes is a multinomial naive Bayes text classifier over character
+// n-grams. It is small, dependency-free and deterministic, which makes it a
+// good fallback for keyword rules on short, noisy Russian posts.
+package nbayes
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Classifier holds the counts for one labeled field. The zero value is not
+// usable; see New.
+type Classifier struct {
+	MinN   int                       `json:"min_n"`
+	MaxN   int                       `json:"max_n"`
+	Docs   map[string]int            `json:"docs"`   // label -> training documents
+	Counts map[string]map[string]int `json:"counts"` // label -> feature -> occurrences
+	Totals map[string]int            `json:"totals"` // label -> sum of Counts[label]
+	Vocab  map[string]struct{}       `json:"-"`      // rebuilt from Counts on load
+}
+
+// New returns an empty classifier using character n-grams of length minN..maxN.
+func New(minN, maxN int) *Classifier {
+	return &Classifier{
+		MinN:   minN,
+		MaxN:   maxN,
+		Docs:   map[string]int{},
+		Counts: map[string]map[string]int{},
+		Totals: map[string]int{},
+		Vocab:  map[string]struct{}{},
+	}
+}
+
+// Train adds one labeled document.
+func (c *Classifier) Train(text, label string) {
+	c.Docs[label]++
+	counts := c.Counts[label]
+	if counts == nil {
+		counts = map[string]int{}
+		c.Counts[label] = counts
+	}
+	for _, f := range Features(text, c.MinN, c.MaxN) {
+		counts[f]++
+		c.Totals[label]++
+		c.Vocab[f] = struct{}{}
+	}
+}
+
+// Labels returns the trained labels, sorted.
+func (c *Classifier) Labels() []string {
+	out := make([]string, 0, len(c.Docs))
+	for l := range c.Docs {
+		out = append(out, l)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Predict returns the most probable label and its posterior probability.
+// It returns "", 0 for an untrained classifier.
+func (c *Classifier) Predict(text string) (string, float64) {
+	labels := c.Labels()
+	if len(labels) == 0 {
+		return "", 0
+	}
+	docs := 0
+	for _, n := range c.Docs {
+		docs += n
+	}
+	feats := Features(text, c.MinN, c.MaxN)
+	v := float64(len(c.Vocab))
+
+	scores := make([]float64, len(labels))
+	for i, l := range labels {
+		// Laplace-smoothed log P(label) + sum log P(feature|label)
+		score := math.Log(float64(c.Docs[l]) / float64(docs))
+		denom := math.Log(float64(c.Totals[l]) + v)
+		counts := c.Counts[l]
+		for _, f := range feats {
+			score += math.Log(float64(counts[f])+1) - denom
+		}
+		scores[i] = score
+	}
+
+	best := 0
+	for i := range scores {
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+	// Normalize via log-sum-exp to get a posterior.
+	sum := 0.0
+	for _, s := range scores {
+		sum += math.Exp(s - scores[best])
+	}
+	return labels[best], 1 / sum
+}
+
+// rebuildVocab restores the derived vocabulary after decoding.
+func (c *Classifier) rebuildVocab() {
+	c.Vocab = map[string]struct{}{}
+	for _, counts := range c.Counts {
+		for f := range counts {
+			c.Vocab[f] = struct{}{}
+		}
+	}
+}
+
+// Features splits text into lowercased words (digits folded to 0) and returns
+// the character n-grams of each word padded with spaces, so prefixes and
+// endings like " пропал" or "ла " become features of their own.
+func Features(text string, minN, maxN int) []string {
+	var out []string
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		r := []rune(" " + w + " ")
+		for i, x := range r {
+			if unicode.IsDigit(x) {
+				r[i] = '0'
+			}
+			if x == 'ё' {
+				r[i] = 'е'
+			}
+		}
+		for n := minN; n <= maxN; n++ {
+			for i := 0; i+n <= len(r); i++ {
+				out = append(out, string(r[i:i+n]))
+			}
+		}
+	}
+	return out
+}
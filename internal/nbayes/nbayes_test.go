@@ -0,0 +1,56 @@
+package nbayes
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatures(t *testing.T) {
+	got := Features("Ёж 12!", 2, 3)
+	assert.Equal(t, []string{" е", "еж", "ж ", " еж", "еж ", " 0", "00", "0 ", " 00", "00 "}, got)
+}
+
+func TestClassifier_PredictAndRoundTrip(t *testing.T) {
+	m := NewModel()
+	c := New(2, 4)
+	for _, d := range []struct{ text, label string }{
+		{"потерялась наша девочка помогите найти", "lost"},
+		{"потерялся пес ушел из дома", "lost"},
+		{"ушла из дома и не вернулась", "lost"},
+		{"на остановке прибился пес ищем хозяев", "found"},
+		{"прибилась кошечка хозяева отзовитесь", "found"},
+		{"сидит у подъезда ищем хозяина", "found"},
+	} {
+		c.Train(d.text, d.label)
+	}
+	m.Fields["type"] = c
+
+	label, p := c.Predict("пес ушел с дачи, помогите")
+	assert.Equal(t, "lost", label)
+	assert.Greater(t, p, 0.5)
+	label, _ = c.Predict("прибился котик, хозяева где вы")
+	assert.Equal(t, "found", label)
+
+	var buf bytes.Buffer
+	require.NoError(t, m.Write(&buf))
+	loaded, err := Read(&buf)
+	require.NoError(t, err)
+	assert.NotEmpty(t, loaded.Version())
+
+	l1, p1, ok := loaded.Classify("type", "пес ушел с дачи, помогите")
+	require.True(t, ok)
+	assert.Equal(t, "lost", l1)
+	assert.InDelta(t, p, p1, 1e-12)
+
+	_, _, ok = loaded.Classify("animal", "кот")
+	assert.False(t, ok, "untrained field")
+}
+
+func TestClassifier_Empty(t *testing.T) {
+	l, p := New(2, 3).Predict("что угодно")
+	assert.Empty(t, l)
+	assert.Zero(t, p)
+}
@@ -0,0 +1,70 @@
+package ru
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStem(t *testing.T) {
+	// From the Snowball Russian sample vocabulary.
+	snowball := map[string]string{
+		"в":            "в",
+		"вавиловка":    "вавиловк",
+		"вагона":       "вагон",
+		"вагонов":      "вагон",
+		"важная":       "важн",
+		"важнее":       "важн",
+		"важнейшие":    "важн",
+		"важнейшими":   "важн",
+		"важничал":     "важнича",
+		"важности":     "важност",
+		"важностью":    "важност",
+		"валандался":   "валанда",
+		"валерию":      "валер",
+		"валетами":     "валет",
+		"валериановых": "валерианов",
+	}
+	for w, want := range snowball {
+		assert.Equal(t, want, Stem(w), w)
+	}
+}
+
+func TestStem_Inflections(t *testing.T) {
+	same := [][]string{
+		{"пропал", "пропала", "пропали", "пропавшая", "Пропавший"},
+		{"потерялся", "потерялась", "потерялись", "потерявшийся"},
+		{"найден", "найдена", "найдены", "нашла"},
+		{"нашлась", "нашёлся", "нашлись"},
+		{"пёс", "пес", "пса", "псу", "псом"},
+		{"котёнок", "котенка", "котята", "котятами"},
+		{"кот", "кота", "коту", "котом", "коты"},
+		{"кошка", "кошки", "кошку", "кошкой", "кошек"},
+		{"кобель", "кобеля", "кобелем", "кобели"},
+		{"добрые", "добрых", "добрым"},
+	}
+	for _, forms := range same {
+		for _, f := range forms[1:] {
+			assert.Equal(t, Stem(forms[0]), Stem(f), "%s ~ %s", forms[0], f)
+		}
+	}
+	assert.NotEqual(t, Stem("найден"), Stem("найдите"))
+	assert.NotEqual(t, Stem("найден"), Stem("нашлась"))
+	assert.NotEqual(t, Stem("кот"), Stem("котейка"))
+	assert.Equal(t, "hello", Stem("Hello"))
+}
+
+func TestTokenize(t *testing.T) {
+	s := "Пропал пёс, черно-белый; тел. 8912"
+	ts := Tokenize(s)
+	require.Len(t, ts, 5)
+	var words []string
+	for _, tk := range ts {
+		words = append(words, s[tk.Start:tk.End])
+		assert.Equal(t, tk.Text, s[tk.Start:tk.End])
+	}
+	assert.Equal(t, []string{"Пропал", "пёс", "черно", "белый", "тел"}, words)
+	assert.Equal(t, "пес", ts[1].Stem)
+	assert.Equal(t, []string{"в", "добр", "рук"}, Stems("в добрые руки"))
+}
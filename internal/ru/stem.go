@@ -0,0 +1,177 @@
+// Package ru is a small Russian text toolkit for the parser: a word tokenizer
+// and the Snowball Russian stemmer with an exception dictionary for the
+// irregular forms pet posts are full of (пёс/пса, котёнок/котята, нашла).
+// It is pure Go and allocation-light enough to run on every post.
+package ru
+
+import "strings"
+
+// Suffix groups of the Snowball Russian stemmer,
+// https://snowballstem.org/algorithms/russian/stemmer.html. Endings of a
+// "group 1" must follow а or я, which stays.
+var (
+	gerund1 = []string{"в", "вши", "вшись"}
+	gerund2 = []string{"ив", "ивши", "ившись", "ыв", "ывши", "ывшись"}
+
+	adjective = []string{"ее", "ие", "ые", "ое", "ими", "ыми", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом",
+		"его", "ого", "ему", "ому", "их", "ых", "ую", "юю", "ая", "яя", "ою", "ею"}
+
+	participle1 = []string{"ем", "нн", "вш", "ющ", "щ"}
+	participle2 = []string{"ивш", "ывш", "ующ"}
+
+	reflexive = []string{"ся", "сь"}
+
+	verb1 = []string{"ла", "на", "ете", "йте", "ли", "й", "л", "ем", "н", "ло", "но", "ет", "ют", "ны", "ть", "ешь", "нно"}
+	verb2 = []string{"ила", "ыла", "ена", "ейте", "уйте", "ите", "или", "ыли", "ей", "уй", "ил", "ыл", "им", "ым", "ен",
+		"ило", "ыло", "ено", "ят", "ует", "уют", "ит", "ыт", "ены", "ить", "ыть", "ишь", "ую", "ю"}
+
+	noun = []string{"а", "ев", "ов", "ие", "ье", "е", "иями", "ями", "ами", "еи", "ии", "и", "ией", "ей", "ой", "ий", "й",
+		"иям", "ям", "ием", "ем", "ам", "ом", "о", "у", "ах", "иях", "ях", "ы", "ь", "ию", "ью", "ю", "ия", "ья", "я"}
+
+	superlative   = []string{"ейш", "ейше"}
+	derivational  = []string{"ост", "ость"}
+	russianVowels = "аеиоуыэюя"
+)
+
+// exceptions map irregular forms (after ё→е) straight to the stem of their
+// regular relatives, so that rules written for "пёс" or "найден" also match
+// "пса" or "нашла".
+var exceptions = map[string]string{}
+
+func init() {
+	add := func(stem string, forms ...string) {
+		for _, f := range forms {
+			exceptions[f] = stem
+		}
+	}
+	add("пес", "пес", "пса", "псу", "псом", "псе", "псы", "псов", "псам", "псами", "псах")
+	add("котенок", "котенок", "котенка", "котенку", "котенком", "котенке",
+		"котята", "котят", "котятам", "котятами", "котятах")
+	add("щенок", "щенок", "щенка", "щенку", "щенком", "щенке", "щенки", "щенков", "щенкам", "щенками", "щенках",
+		"щенята", "щенят", "щенятам", "щенятами", "щенятах")
+	add("кошк", "кошек")
+	add("девочк", "девочек")
+	add("найд", "нашел", "нашла", "нашло", "нашли")
+	add("нашл", "нашелся", "нашлась", "нашлось", "нашлись") // the pet came back: not "найден"
+	add("найдит", "найдите")                                // "помогите найти/найдите" is not "найден"
+	add("ушел", "ушел", "ушла", "ушло", "ушли")
+	add("вышел", "вышел", "вышла", "вышло", "вышли")
+	add("пришел", "пришел", "пришла", "пришло", "пришли")
+}
+
+// Stem returns the stem of a single word. The word is lowercased and ё is
+// folded to е; non-Cyrillic words come back lowercased and otherwise intact.
+func Stem(word string) string {
+	w := strings.ReplaceAll(strings.ToLower(word), "ё", "е")
+	if s, ok := exceptions[w]; ok {
+		return s
+	}
+	rs := []rune(w)
+	rv, r2 := regions(rs)
+	if rv < 0 {
+		return w
+	}
+
+	// Step 1.
+	if end, ok := cut(rs, rv, gerund1, gerund2); ok {
+		rs = rs[:end]
+	} else {
+		if end, ok := cut(rs, rv, nil, reflexive); ok {
+			rs = rs[:end]
+		}
+		if end, ok := cut(rs, rv, nil, adjective); ok {
+			rs = rs[:end]
+			if end, ok := cut(rs, rv, participle1, participle2); ok {
+				rs = rs[:end]
+			}
+		} else if end, ok := cut(rs, rv, verb1, verb2); ok {
+			rs = rs[:end]
+		} else if end, ok := cut(rs, rv, nil, noun); ok {
+			rs = rs[:end]
+		}
+	}
+
+	// Step 2.
+	if len(rs) > rv && rs[len(rs)-1] == 'и' {
+		rs = rs[:len(rs)-1]
+	}
+
+	// Step 3: derivational endings must lie in R2.
+	if end, ok := cut(rs, r2, nil, derivational); ok {
+		rs = rs[:end]
+	}
+
+	// Step 4.
+	undouble := func() {
+		if n := len(rs); n-2 >= rv && rs[n-1] == 'н' && rs[n-2] == 'н' {
+			rs = rs[:n-1]
+		}
+	}
+	if end, ok := cut(rs, rv, nil, superlative); ok {
+		rs = rs[:end]
+		undouble()
+	} else if n := len(rs); n-2 >= rv && rs[n-1] == 'н' && rs[n-2] == 'н' {
+		undouble()
+	} else if n-1 >= rv && rs[n-1] == 'ь' {
+		rs = rs[:n-1]
+	}
+	return string(rs)
+}
+
+// regions returns the start of RV (after the first vowel) and R2, or -1 when
+// the word has no vowel.
+func regions(rs []rune) (rv, r2 int) {
+	rv = -1
+	for i, r := range rs {
+		if isVowel(r) {
+			rv = i + 1
+			break
+		}
+	}
+	if rv < 0 {
+		return -1, len(rs)
+	}
+	r1 := afterVowelConsonant(rs, 0)
+	return rv, afterVowelConsonant(rs, r1)
+}
+
+// afterVowelConsonant is the position after the first non-vowel following a
+// vowel, starting at from.
+func afterVowelConsonant(rs []rune, from int) int {
+	for i := from + 1; i < len(rs); i++ {
+		if !isVowel(rs[i]) && isVowel(rs[i-1]) {
+			return i + 1
+		}
+	}
+	return len(rs)
+}
+
+func isVowel(r rune) bool { return strings.ContainsRune(russianVowels, r) }
+
+// cut finds the longest ending from group1 or group2 that lies in rs[min:]
+// and returns where it starts. A group 1 ending must follow а or я, also in
+// the region; when the longest ending fails that test nothing is cut, as in
+// the reference implementation.
+func cut(rs []rune, min int, group1, group2 []string) (int, bool) {
+	best, inGroup1 := -1, false
+	for g, list := range [][]string{group1, group2} {
+		for _, e := range list {
+			start := len(rs) - len([]rune(e))
+			if start < min || (best >= 0 && start >= best) {
+				continue
+			}
+			if string(rs[start:]) == e {
+				best, inGroup1 = start, g == 0
+			}
+		}
+	}
+	if best < 0 {
+		return 0, false
+	}
+	if inGroup1 {
+		if best-1 < min || (rs[best-1] != 'а' && rs[best-1] != 'я') {
+			return 0, false
+		}
+	}
+	return best, true
+}
@@ -0,0 +1,48 @@
+package ru
+
+import "unicode"
+
+// Token is a word of the input with its stem. Start and End are byte offsets
+// into the tokenized string (End exclusive).
+type Token struct {
+	Text       string
+	Stem       string
+	Start, End int
+}
+
+// Tokenize splits s into words: maximal runs of letters. Digits, punctuation
+// and hyphens separate words, so "черно-белый" is two tokens.
+func Tokenize(s string) []Token {
+	var out []Token
+	start := -1
+	for i, r := range s {
+		if unicode.IsLetter(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			out = append(out, token(s, start, i))
+			start = -1
+		}
+	}
+	if start >= 0 {
+		out = append(out, token(s, start, len(s)))
+	}
+	return out
+}
+
+func token(s string, start, end int) Token {
+	return Token{Text: s[start:end], Stem: Stem(s[start:end]), Start: start, End: end}
+}
+
+// Stems returns the stems of the words of s, e.g. to compile a phrase.
+func Stems(s string) []string {
+	ts := Tokenize(s)
+	out := make([]string, len(ts))
+	for i, t := range ts {
+		out[i] = t.Stem
+	}
+	return out
+}
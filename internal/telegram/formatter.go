@@ -3,6 +3,7 @@ package telegram
 import (
 	"fmt"
 	"html"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -10,18 +11,25 @@ import (
 )
 
 var msgTmpl = template.Must(template.New("tgmsg").Parse(`{{- if .Title -}}{{.Title}}
+{{end}}{{- if .Reward -}}{{.Reward}}
+{{end}}{{- if .ScamRisk -}}⚠️ Автор просит деньги за возврат питомца. Не переводите оплату заранее.
 {{end}}{{- if .Text -}}
 {{.Text}}
+{{end}}{{- range .Contacts -}}{{.}}
 {{end}}<a href="{{.Link}}">Источник VK</a>`))
 
 type tmplData struct {
-	Title string
-	Text  string // already HTML-escaped
-	Link  string // raw URL
+	Title    string
+	Reward   string // empty when no reward is promised
+	ScamRisk bool
+	Text     string   // already HTML-escaped
+	Contacts []string // one line per contact, HTML
+	Link     string   // raw URL
 }
 
-// BuildMessage builds a Telegram-ready HTML message body from a stored post using text/template.
-func BuildMessage(p sqldb.GetPostRow) string {
+// BuildMessage builds a Telegram-ready HTML message body from a stored post
+// and its contacts using text/template.
+func BuildMessage(p sqldb.GetPostRow, contacts []sqldb.PostContact) string {
 	// Prepare values
 	title := typeTitle(p.Type)
 	body := p.Text
@@ -29,9 +37,12 @@ func BuildMessage(p sqldb.GetPostRow) string {
 		body = body[:3500] + "…"
 	}
 	data := tmplData{
-		Title: title,
-		Text:  html.EscapeString(body),
-		Link:  vkLink(p.OwnerID, p.PostID),
+		Title:    title,
+		Reward:   rewardLine(p),
+		ScamRisk: p.ScamRisk,
+		Text:     html.EscapeString(body),
+		Contacts: contactLines(contacts),
+		Link:     vkLink(p.OwnerID, p.PostID),
 	}
 
 	var b strings.Builder
@@ -59,3 +70,66 @@ func typeTitle(t string) string {
 		return ""
 	}
 }
+
+// rewardLine shows the promised reward, "5 000 ₽" with thousands separated.
+func rewardLine(p sqldb.GetPostRow) string {
+	if !p.Reward {
+		return ""
+	}
+	if p.RewardAmount == nil || *p.RewardAmount <= 0 {
+		return "💰 Вознаграждение"
+	}
+	digits := strconv.FormatInt(*p.RewardAmount, 10)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteRune(' ')
+		}
+		b.WriteRune(d)
+	}
+	return "💰 Вознаграждение: " + b.String() + " ₽"
+}
+
+// contactLines renders contacts as links where Telegram can open them.
+func contactLines(cs []sqldb.PostContact) []string {
+	var out []string
+	for _, c := range cs {
+		v := html.EscapeString(c.Value)
+		switch c.Kind {
+		case "phone":
+			out = append(out, "📞 "+formatPhone(c.Value))
+		case "whatsapp":
+			out = append(out, fmt.Sprintf(`💬 WhatsApp: <a href="https://wa.me/%s">%s</a>`, strings.TrimPrefix(c.Value, "+"), formatPhone(c.Value)))
+		case "telegram":
+			if strings.HasPrefix(c.Value, "@") {
+				out = append(out, "✈️ Telegram: "+v)
+			} else {
+				out = append(out, "✈️ Telegram: "+formatPhone(c.Value))
+			}
+		case "vk":
+			switch {
+			case c.Value == "":
+				out = append(out, "✉️ Пишите автору в личные сообщения VK")
+			case strings.HasPrefix(c.Value, "vk.cc/"):
+				out = append(out, fmt.Sprintf(`👤 VK: <a href="https://%s">%s</a>`, v, v))
+			default:
+				name := v
+				if c.Name != nil && *c.Name != "" {
+					name = html.EscapeString(*c.Name)
+				}
+				out = append(out, fmt.Sprintf(`👤 VK: <a href="https://vk.com/%s">%s</a>`, v, name))
+			}
+		case "email":
+			out = append(out, "✉️ "+v)
+		}
+	}
+	return out
+}
+
+// formatPhone spaces a "+79120281683" phone as "+7 912 028-16-83".
+func formatPhone(ph string) string {
+	if len(ph) != 12 || !strings.HasPrefix(ph, "+7") {
+		return ph
+	}
+	return fmt.Sprintf("+7 %s %s-%s-%s", ph[2:5], ph[5:8], ph[8:10], ph[10:12])
+}
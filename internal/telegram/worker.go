@@ -78,8 +78,16 @@ func (w *Worker) tick() error {
 			slog.Error("tg worker: load post failed", "owner_id", r.OwnerID, "post_id", r.PostID, "err", err)
 			continue
 		}
+		// Load contacts
+		contacts, err := w.q.ListPostContacts(ctx, sqldb.ListPostContactsParams{OwnerID: r.OwnerID, PostID: r.PostID})
+		if err != nil {
+			msg := "list contacts: " + err.Error()
+			_ = w.q.MarkFailed(ctx, sqldb.MarkFailedParams{MaxRetries: int64(w.opt.MaxRetries), LastError: &msg, ID: r.ID})
+			slog.Error("tg worker: load contacts failed", "owner_id", r.OwnerID, "post_id", r.PostID, "err", err)
+			continue
+		}
 		// Build message
-		text := BuildMessage(post)
+		text := BuildMessage(post, contacts)
 		// Ensure valid UTF-8 to avoid Telegram "text must be encoded in UTF-8"
 		text = strings.ToValidUTF8(text, "")
 		// Send with HTML parse mode
@@ -2,6 +2,7 @@ package vk
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -9,27 +10,37 @@ import (
 )
 
 var msgTmpl = template.Must(template.New("vkmsg").Parse(`{{- if .Title -}}{{.Title}}
+{{end}}{{- if .Reward -}}{{.Reward}}
+{{end}}{{- if .ScamRisk -}}⚠️ Автор просит деньги за возврат питомца. Не переводите оплату заранее.
 {{end}}{{- if .Text -}}
 {{.Text}}
+{{end}}{{- range .Contacts -}}{{.}}
 {{end}}Источник VK: {{.Link}}`))
 
 type tmplData struct {
-	Title string
-	Text  string
-	Link  string
+	Title    string
+	Reward   string // empty when no reward is promised
+	ScamRisk bool
+	Text     string
+	Contacts []string // one line per contact
+	Link     string
 }
 
-// BuildMessage builds a plain-text message for wall.post using text/template.
-func BuildMessage(p sqldb.GetPostRow) string {
+// BuildMessage builds a plain-text message for wall.post from a stored post
+// and its contacts using text/template.
+func BuildMessage(p sqldb.GetPostRow, contacts []sqldb.PostContact) string {
 	title := typeTitle(p.Type)
 	body := p.Text
 	if len(body) > 3500 {
 		body = body[:3500] + "…"
 	}
 	data := tmplData{
-		Title: title,
-		Text:  body,
-		Link:  vkLink(p.OwnerID, p.PostID),
+		Title:    title,
+		Reward:   rewardLine(p),
+		ScamRisk: p.ScamRisk,
+		Text:     body,
+		Contacts: contactLines(contacts),
+		Link:     vkLink(p.OwnerID, p.PostID),
 	}
 
 	var b strings.Builder
@@ -57,3 +68,63 @@ func typeTitle(t string) string {
 		return ""
 	}
 }
+
+// rewardLine shows the promised reward, "5 000 ₽" with thousands separated.
+func rewardLine(p sqldb.GetPostRow) string {
+	if !p.Reward {
+		return ""
+	}
+	if p.RewardAmount == nil || *p.RewardAmount <= 0 {
+		return "💰 Вознаграждение"
+	}
+	digits := strconv.FormatInt(*p.RewardAmount, 10)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteRune(' ')
+		}
+		b.WriteRune(d)
+	}
+	return "💰 Вознаграждение: " + b.String() + " ₽"
+}
+
+// contactLines renders contacts for a wall post; VK pages become mentions.
+func contactLines(cs []sqldb.PostContact) []string {
+	var out []string
+	for _, c := range cs {
+		switch c.Kind {
+		case "phone":
+			out = append(out, "📞 "+formatPhone(c.Value))
+		case "whatsapp":
+			out = append(out, "💬 WhatsApp: wa.me/"+strings.TrimPrefix(c.Value, "+"))
+		case "telegram":
+			if strings.HasPrefix(c.Value, "@") {
+				out = append(out, "✈️ Telegram: t.me/"+strings.TrimPrefix(c.Value, "@"))
+			} else {
+				out = append(out, "✈️ Telegram: "+formatPhone(c.Value))
+			}
+		case "vk":
+			switch {
+			case c.Value == "":
+				out = append(out, "✉️ Пишите автору в личные сообщения")
+			case strings.HasPrefix(c.Value, "vk.cc/"):
+				out = append(out, "👤 VK: "+c.Value)
+			case c.Name != nil && *c.Name != "":
+				out = append(out, fmt.Sprintf("👤 VK: [%s|%s]", c.Value, *c.Name))
+			default:
+				out = append(out, "👤 VK: @"+c.Value)
+			}
+		case "email":
+			out = append(out, "✉️ "+c.Value)
+		}
+	}
+	return out
+}
+
+// formatPhone spaces a "+79120281683" phone as "+7 912 028-16-83".
+func formatPhone(ph string) string {
+	if len(ph) != 12 || !strings.HasPrefix(ph, "+7") {
+		return ph
+	}
+	return fmt.Sprintf("+7 %s %s-%s-%s", ph[2:5], ph[5:8], ph[8:10], ph[10:12])
+}
@@ -72,7 +72,14 @@ func (w *Worker) tick() error {
 			slog.Error("vk worker: load post failed", "owner_id", r.OwnerID, "post_id", r.PostID, "err", err)
 			continue
 		}
-		text := BuildMessage(post)
+		contacts, err := w.q.ListPostContacts(ctx, sqldb.ListPostContactsParams{OwnerID: r.OwnerID, PostID: r.PostID})
+		if err != nil {
+			msg := "list contacts: " + err.Error()
+			_ = w.q.MarkFailedVK(ctx, sqldb.MarkFailedVKParams{MaxRetries: int64(w.opt.MaxRetries), LastError: &msg, ID: r.ID})
+			slog.Error("vk worker: load contacts failed", "owner_id", r.OwnerID, "post_id", r.PostID, "err", err)
+			continue
+		}
+		text := BuildMessage(post, contacts)
 		text = strings.ToValidUTF8(text, "")
 
 		params := vkapi.Params{
@@ -0,0 +1,608 @@
+package lostdogs
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The keyword index replaces most of a scan's regexp work. Every regexp of
+// the rule set, the breed catalogue and the gazetteer is reduced to keywords,
+// literal strings one of which each of its matches contains: "пропал" for
+// `пропал\p{L}*`, "пёс" or "пес" for `п[её]с`. One Aho-Corasick pass over the
+// text finds all keywords at once. A regexp none of whose keywords occur
+// cannot match, so it is not run. When a match can start only a bounded
+// number of runes before its keyword, the regexp runs anchored at those few
+// positions instead of over the whole text (see keywordHits.find). Regexps
+// without keywords (`\d{15}`) or with an unbounded lead (`\s*дома`) run as
+// before. Text and keywords are case-folded alike, so the index never changes
+// a result.
+
+// Limits of keyword extraction: a character class is spelled out up to
+// maxClassRunes letters, a sub-expression up to maxSpellings strings, and a
+// regexp needing more than maxKeywords keywords always runs.
+const (
+	maxClassRunes = 4
+	maxSpellings  = 64
+	maxKeywords   = 256
+	maxSplits     = 2 // nested alternations split into branches, see requiredConcat
+	minLeadRunes  = 3 // see better
+)
+
+// structuralRegexps are the package's own regexps that run over whole texts;
+// they are gated like the rules' ones.
+var structuralRegexps = []*regexp.Regexp{
+	reAgeWords, reAgeStage, reName1, reName2,
+	reEmail, reTelegramURL, reWhatsAppURL, reVKPage, reVKShort, reVKBracket, reHandle,
+	reChipGrouped, reTattoo, reWeight, reHeight,
+	reDate, reDayMonth, reRelDay, reWeekday, reNight, reDaysAgo,
+}
+
+type keywordIndex struct {
+	res      map[*regexp.Regexp]*keyed // regexps that have keywords
+	nodes    []acNode
+	root     [256]int32 // transitions from the root, dense
+	maxRunes int        // of the longest keyword
+}
+
+// keyed is an indexed regexp. When lead is known, its matches are found by
+// running it anchored at the few positions its keywords allow, see find.
+type keyed struct {
+	id   int32
+	lead int            // most runes a keyword starts into a match; -1 unknown
+	at0  *regexp.Regexp // the regexp anchored at the text start
+	atN  *regexp.Regexp // anchored after a rune, see anchored
+}
+
+// acNode is a state of the automaton: a prefix of some keyword.
+type acNode struct {
+	next []acEdge // sorted by byte
+	fail int32    // the longest proper suffix that is also a prefix
+	out  []acOut  // keywords that are a suffix of this prefix
+}
+
+type acEdge struct {
+	b  byte
+	to int32
+}
+
+type acOut struct {
+	id    int32 // of the regexp
+	runes int32 // length of the keyword
+}
+
+func newKeywordIndex(res ...[]*regexp.Regexp) *keywordIndex {
+	ki := &keywordIndex{res: map[*regexp.Regexp]*keyed{}, nodes: []acNode{{}}, maxRunes: 1}
+	for _, re := range slices.Concat(res...) {
+		if _, dup := ki.res[re]; re == nil || dup {
+			continue
+		}
+		kws, lead, ok := keywords(re)
+		if !ok {
+			continue
+		}
+		k := &keyed{id: int32(len(ki.res)), lead: -1}
+		if lead >= 0 {
+			if k.at0, k.atN = anchored(re); k.at0 != nil {
+				k.lead = lead
+			}
+		}
+		ki.res[re] = k
+		for _, kw := range kws {
+			ki.add(kw, k.id)
+		}
+	}
+	ki.link()
+	return ki
+}
+
+func (ki *keywordIndex) add(kw string, id int32) {
+	n := int32(0)
+	for i := 0; i < len(kw); i++ {
+		to, ok := ki.child(n, kw[i])
+		if !ok {
+			to = int32(len(ki.nodes))
+			ki.nodes = append(ki.nodes, acNode{})
+			j, _ := slices.BinarySearchFunc(ki.nodes[n].next, kw[i], cmpEdge)
+			ki.nodes[n].next = slices.Insert(ki.nodes[n].next, j, acEdge{kw[i], to})
+		}
+		n = to
+	}
+	runes := utf8.RuneCountInString(kw)
+	ki.maxRunes = max(ki.maxRunes, runes)
+	if o := (acOut{id, int32(runes)}); !slices.Contains(ki.nodes[n].out, o) {
+		ki.nodes[n].out = append(ki.nodes[n].out, o)
+	}
+}
+
+func cmpEdge(e acEdge, b byte) int { return int(e.b) - int(b) }
+
+func (ki *keywordIndex) child(n int32, b byte) (int32, bool) {
+	next := ki.nodes[n].next
+	if j, ok := slices.BinarySearchFunc(next, b, cmpEdge); ok {
+		return next[j].to, true
+	}
+	return 0, false
+}
+
+// link sets the failure links breadth first, so each node also reports the
+// keywords ending in its failure node.
+func (ki *keywordIndex) link() {
+	var queue []int32
+	for _, e := range ki.nodes[0].next {
+		ki.root[e.b] = e.to
+		queue = append(queue, e.to)
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, e := range ki.nodes[n].next {
+			fail := ki.step(ki.nodes[n].fail, e.b)
+			ki.nodes[e.to].fail = fail
+			for _, o := range ki.nodes[fail].out {
+				if !slices.Contains(ki.nodes[e.to].out, o) {
+					ki.nodes[e.to].out = append(ki.nodes[e.to].out, o)
+				}
+			}
+			queue = append(queue, e.to)
+		}
+	}
+}
+
+func (ki *keywordIndex) step(n int32, b byte) int32 {
+	for n != 0 {
+		if to, ok := ki.child(n, b); ok {
+			return to
+		}
+		n = ki.nodes[n].fail
+	}
+	return ki.root[b]
+}
+
+// scan finds where the keywords occur in s. Keywords are whole runes, so
+// they start and end on rune boundaries of s.
+func (ki *keywordIndex) scan(s string) keywordHits {
+	if ki == nil {
+		return keywordHits{}
+	}
+	h := keywordHits{ki: ki, at: make([][]int, len(ki.res))}
+	starts := make([]int, ki.maxRunes) // byte offsets of the last runes, a ring
+	var buf [utf8.UTFMax]byte
+	n, k := int32(0), 0
+	for i, r := range s {
+		starts[k%len(starts)] = i
+		k++
+		w := utf8.EncodeRune(buf[:], foldRune(r))
+		for _, b := range buf[:w] {
+			n = ki.step(n, b)
+		}
+		for _, o := range ki.nodes[n].out {
+			h.at[o.id] = append(h.at[o.id], starts[(k-int(o.runes))%len(starts)])
+		}
+	}
+	return h
+}
+
+// keywordHits is what a keyword index found in a text: for each regexp,
+// where its keywords start. The zero value lets every regexp run.
+type keywordHits struct {
+	ki *keywordIndex
+	at [][]int
+}
+
+// may reports whether re can match the text: it has no keywords or one of
+// them occurs.
+func (h keywordHits) may(re *regexp.Regexp) bool {
+	if h.ki == nil {
+		return true
+	}
+	k, ok := h.ki.res[re]
+	return !ok || len(h.at[k.id]) > 0
+}
+
+// find returns what re.FindAllStringSubmatchIndex(s, n) does, s being the
+// scanned text. A match starts at most lead runes before one of its
+// keywords, so only those positions are tried. ok is false when re has to be
+// run over s instead.
+func (h keywordHits) find(re *regexp.Regexp, s string, n int) (ms [][]int, ok bool) {
+	if h.ki == nil {
+		return nil, false
+	}
+	k, indexed := h.ki.res[re]
+	switch {
+	case !indexed:
+		return nil, false
+	case len(h.at[k.id]) == 0:
+		return nil, true
+	case k.lead < 0:
+		return nil, false
+	}
+	var starts []int
+	for _, p := range h.at[k.id] {
+		starts = append(starts, p)
+		for range k.lead {
+			if p == 0 {
+				break
+			}
+			_, w := utf8.DecodeLastRuneInString(s[:p])
+			p -= w
+			starts = append(starts, p)
+		}
+	}
+	slices.Sort(starts)
+	end := 0 // matches do not overlap and are never empty
+	for _, p := range slices.Compact(starts) {
+		if p < end {
+			continue
+		}
+		if n >= 0 && len(ms) >= n {
+			break
+		}
+		var m []int
+		q := 0
+		if p == 0 {
+			m = k.at0.FindStringSubmatchIndex(s)
+		} else {
+			_, w := utf8.DecodeLastRuneInString(s[:p])
+			q = p - w
+			m = k.atN.FindStringSubmatchIndex(s[q:])
+		}
+		if m == nil {
+			continue
+		}
+		for i := range m {
+			if m[i] >= 0 {
+				m[i] += q
+			}
+		}
+		m[0] = p
+		ms = append(ms, m)
+		end = m[1]
+	}
+	return ms, true
+}
+
+// anchored compiles re anchored at the start of the text it runs on: at0
+// for the start of the scanned text, atN for a later position p, run from
+// the rune before p so that ^, \b and the like see what precedes p.
+func anchored(re *regexp.Regexp) (at0, atN *regexp.Regexp) {
+	at0, err := regexp.Compile(`^(?:` + re.String() + `)`)
+	if err != nil {
+		return nil, nil
+	}
+	atN, err = regexp.Compile(`^(?s:.)(?:` + re.String() + `)`)
+	if err != nil {
+		return nil, nil
+	}
+	return at0, atN
+}
+
+// keywords returns folded strings one of which every match of re contains,
+// and lead, the most runes into a match one of them can start (-1 when
+// unbounded). ok is false when there are none or too many.
+func keywords(re *regexp.Regexp) (kws []string, lead int, ok bool) {
+	t, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return nil, 0, false
+	}
+	kws, lead, ok = required(t.Simplify(), 0)
+	return kws, lead, ok && len(kws) <= maxKeywords
+}
+
+// required returns non-empty strings one of which every match of re
+// contains, and their lead.
+func required(re *syntax.Regexp, depth int) ([]string, int, bool) {
+	if ss, ok := spell(re); ok {
+		kws, lead := minimal(ss)
+		return kws, lead, !slices.Contains(ss, "")
+	}
+	switch re.Op {
+	case syntax.OpCapture, syntax.OpPlus:
+		return required(re.Sub[0], depth)
+	case syntax.OpAlternate:
+		var out []string
+		lead := 0
+		for _, sub := range re.Sub {
+			ss, l, ok := required(sub, depth)
+			if !ok {
+				return nil, 0, false
+			}
+			out, lead = union(out, ss), maxLead(lead, l)
+		}
+		kws, extra := minimal(out)
+		return kws, addLead(lead, extra), true
+	case syntax.OpConcat:
+		return requiredConcat(re.Sub, depth)
+	}
+	return nil, 0, false
+}
+
+// requiredConcat picks the longest keywords of a sequence: the spellings of
+// a run of consecutive spellable elements, the keywords of one element, or,
+// for an alternation, those of the sequence through each of its branches:
+// the parser factors common prefixes out of alternations, leaving "на" of
+// "найден|нашли".
+func requiredConcat(subs []*syntax.Regexp, depth int) ([]string, int, bool) {
+	var best []string
+	bestLead := 0
+	consider := func(ss []string, lead int) {
+		if len(ss) == 0 || len(ss) > maxKeywords || slices.Contains(ss, "") {
+			return
+		}
+		ss, extra := minimal(ss)
+		lead = addLead(lead, extra)
+		if best == nil || better(ss, lead, best, bestLead) {
+			best, bestLead = ss, lead
+		}
+	}
+	pos := 0 // most runes the elements before the current one take
+	run, runAt := []string{""}, 0
+	for i, sub := range subs {
+		ss, ok := spell(sub)
+		if ok && len(run)*len(ss) <= maxSpellings {
+			run = cross(run, ss)
+			pos = addLead(pos, maxRunes(sub))
+			continue
+		}
+		consider(run, runAt)
+		if alt := uncapture(sub); !ok && alt.Op == syntax.OpAlternate && depth < maxSplits {
+			var pre []*syntax.Regexp
+			at := pos
+			if len(run) > 1 || run[0] != "" {
+				pre, at = []*syntax.Regexp{literals(run)}, runAt
+			}
+			var out []string
+			lead := 0
+			for _, b := range alt.Sub {
+				ss, l, ok := requiredConcat(slices.Concat(pre, flatten(b), subs[i+1:]), depth+1)
+				if !ok {
+					out = nil
+					break
+				}
+				out, lead = union(out, ss), maxLead(lead, l)
+			}
+			consider(out, addLead(at, lead))
+		}
+		if ok {
+			run, runAt = ss, pos
+			pos = addLead(pos, maxRunes(sub))
+			continue
+		}
+		if ss, l, ok := required(sub, depth); ok {
+			consider(ss, addLead(pos, l))
+		}
+		pos = addLead(pos, maxRunes(sub))
+		run, runAt = []string{""}, pos
+	}
+	consider(run, runAt)
+	return best, bestLead, best != nil
+}
+
+// better prefers keywords of at least minLeadRunes runes with a known lead,
+// which find can drive, then longer keywords, then a known lead, then fewer
+// keywords, then a smaller lead.
+func better(a []string, aLead int, b []string, bLead int) bool {
+	sa, sb := shortest(a), shortest(b)
+	if da, db := aLead >= 0 && sa >= minLeadRunes, bLead >= 0 && sb >= minLeadRunes; da != db {
+		return da
+	}
+	if sa != sb {
+		return sa > sb
+	}
+	if (aLead >= 0) != (bLead >= 0) {
+		return aLead >= 0
+	}
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return aLead >= 0 && aLead < bLead
+}
+
+func uncapture(re *syntax.Regexp) *syntax.Regexp {
+	for re.Op == syntax.OpCapture {
+		re = re.Sub[0]
+	}
+	return re
+}
+
+func flatten(re *syntax.Regexp) []*syntax.Regexp {
+	if re.Op == syntax.OpConcat {
+		return re.Sub
+	}
+	return []*syntax.Regexp{re}
+}
+
+// literals matches exactly the (folded) strings ss.
+func literals(ss []string) *syntax.Regexp {
+	alt := &syntax.Regexp{Op: syntax.OpAlternate}
+	for _, s := range ss {
+		alt.Sub = append(alt.Sub, &syntax.Regexp{Op: syntax.OpLiteral, Rune: []rune(s)})
+	}
+	if len(alt.Sub) == 1 {
+		return alt.Sub[0]
+	}
+	return alt
+}
+
+// maxRunes is the most runes a match of re takes, -1 when unbounded.
+func maxRunes(re *syntax.Regexp) int {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return len(re.Rune)
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return 1
+	case syntax.OpCapture, syntax.OpQuest:
+		return maxRunes(re.Sub[0])
+	case syntax.OpStar, syntax.OpPlus:
+		return -1
+	case syntax.OpRepeat:
+		if re.Max < 0 {
+			return -1
+		}
+		n := maxRunes(re.Sub[0])
+		if n < 0 {
+			return -1
+		}
+		return re.Max * n
+	case syntax.OpConcat:
+		n := 0
+		for _, sub := range re.Sub {
+			n = addLead(n, maxRunes(sub))
+		}
+		return n
+	case syntax.OpAlternate:
+		n := 0
+		for _, sub := range re.Sub {
+			n = maxLead(n, maxRunes(sub))
+		}
+		return n
+	}
+	return 0 // empty-width
+}
+
+// addLead and maxLead combine rune counts where -1 is unbounded.
+func addLead(a, b int) int {
+	if a < 0 || b < 0 {
+		return -1
+	}
+	return a + b
+}
+
+func maxLead(a, b int) int {
+	if a < 0 || b < 0 {
+		return -1
+	}
+	return max(a, b)
+}
+
+// spell returns every string re matches, folded, when there are at most
+// maxSpellings of them. Zero-width assertions spell "".
+func spell(re *syntax.Regexp) ([]string, bool) {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return []string{""}, true
+	case syntax.OpLiteral:
+		return []string{foldString(string(re.Rune))}, true
+	case syntax.OpCharClass:
+		return spellClass(re.Rune)
+	case syntax.OpCapture:
+		return spell(re.Sub[0])
+	case syntax.OpQuest:
+		ss, ok := spell(re.Sub[0])
+		return union([]string{""}, ss), ok
+	case syntax.OpAlternate:
+		var out []string
+		for _, sub := range re.Sub {
+			ss, ok := spell(sub)
+			if out = union(out, ss); !ok || len(out) > maxSpellings {
+				return nil, false
+			}
+		}
+		return out, true
+	case syntax.OpConcat:
+		out := []string{""}
+		for _, sub := range re.Sub {
+			ss, ok := spell(sub)
+			if !ok || len(out)*len(ss) > maxSpellings {
+				return nil, false
+			}
+			out = cross(out, ss)
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// spellClass returns the folded letters of a character class given as
+// ranges, when there are at most maxClassRunes of them.
+func spellClass(ranges []rune) ([]string, bool) {
+	var out []string
+	for i := 0; i+1 < len(ranges); i += 2 {
+		if ranges[i+1]-ranges[i] >= 4*maxClassRunes {
+			return nil, false
+		}
+		for r := ranges[i]; r <= ranges[i+1]; r++ {
+			if out = union(out, []string{string(foldRune(r))}); len(out) > maxClassRunes {
+				return nil, false
+			}
+		}
+	}
+	return out, len(out) > 0
+}
+
+func cross(a, b []string) []string {
+	var out []string
+	for _, x := range a {
+		for _, y := range b {
+			out = union(out, []string{x + y})
+		}
+	}
+	return out
+}
+
+func union(a, b []string) []string {
+	for _, s := range b {
+		if !slices.Contains(a, s) {
+			a = append(a, s)
+		}
+	}
+	return a
+}
+
+// minimal drops the strings containing another one of ss: a match containing
+// them contains that one too, extra runes further in at most.
+func minimal(ss []string) (out []string, extra int) {
+	ss = slices.Clone(ss)
+	slices.SortStableFunc(ss, func(a, b string) int { return len(a) - len(b) })
+	for _, s := range ss {
+		i := slices.IndexFunc(out, func(t string) bool { return strings.Contains(s, t) })
+		if i < 0 {
+			out = append(out, s)
+			continue
+		}
+		extra = max(extra, utf8.RuneCountInString(s[:strings.Index(s, out[i])]))
+	}
+	return out, extra
+}
+
+// shortest is the length in letters of the shortest of ss.
+func shortest(ss []string) int {
+	n := -1
+	for _, s := range ss {
+		if l := utf8.RuneCountInString(s); n < 0 || l < n {
+			n = l
+		}
+	}
+	return n
+}
+
+// foldRune maps r to the smallest rune it is case-insensitively equal to, as
+// (?i) regexps compare them: 'ё' and 'Ё' → 'Ё'.
+func foldRune(r rune) rune {
+	if r >= 0 && int(r) < len(foldTable) {
+		return foldTable[r]
+	}
+	return foldOrbit(r)
+}
+
+// foldTable caches foldRune for Latin and Cyrillic.
+var foldTable = func() (t [0x500]rune) {
+	for r := range t {
+		t[r] = foldOrbit(rune(r))
+	}
+	return t
+}()
+
+func foldOrbit(r rune) rune {
+	m := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		m = min(m, f)
+	}
+	return m
+}
+
+func foldString(s string) string { return strings.Map(foldRune, s) }
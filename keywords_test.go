@@ -0,0 +1,119 @@
+package lostdogs
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeywords(t *testing.T) {
+	cases := []struct {
+		re   string
+		kws  []string // nil: the regexp always runs
+		lead int
+	}{
+		{`(?i)пропал\p{L}*`, []string{foldString("пропал")}, 0},
+		{`(?i)п[её]с`, []string{foldString("пес"), foldString("пёс")}, 0},
+		{`(?i)(?:^|[^\p{L}])(собак\p{L}*|пс[ау])(?:[^\p{L}]|$)`, []string{foldString("собак"), foldString("пса"), foldString("псу")}, 1},
+		{`(?i)в\s+холке`, []string{foldString("холке")}, -1},
+		{`(?i)найден(?:а|о|ы)?`, []string{foldString("найден")}, 0},
+		// the parser factors out the common "на"; the branches are better keywords
+		{`(?i)найден|нашли`, []string{foldString("найден"), foldString("нашли")}, 0},
+		{`(?i)\d+\s*(?:кг|килограмм)`, []string{foldString("кг"), foldString("килограмм")}, -1},
+		{`\d{15}`, nil, 0},
+		{`(?i)кот|\d+`, nil, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.re, func(t *testing.T) {
+			kws, lead, ok := keywords(regexp.MustCompile(tc.re))
+			assert.Equal(t, tc.kws != nil, ok)
+			assert.ElementsMatch(t, tc.kws, kws)
+			if ok {
+				assert.Equal(t, tc.lead, lead)
+			}
+		})
+	}
+}
+
+func TestKeywordIndex(t *testing.T) {
+	lost := regexp.MustCompile(`(?i)пропал\p{L}*`)
+	dog := regexp.MustCompile(`(?i)п[её]с`)
+	chip := regexp.MustCompile(`\d{15}`)
+	ki := newKeywordIndex([]*regexp.Regexp{lost, dog, chip})
+
+	h := ki.scan("ПРОПАЛ рыжий кот")
+	assert.True(t, h.may(lost))
+	assert.False(t, h.may(dog))
+	assert.True(t, h.may(chip), "regexps without keywords always run")
+
+	h = ki.scan("Найден ПЕС")
+	assert.False(t, h.may(lost))
+	assert.True(t, h.may(dog))
+
+	ms, ok := h.find(dog, "Найден ПЕС", -1)
+	assert.True(t, ok)
+	assert.Equal(t, [][]int{{len("Найден "), len("Найден ПЕС")}}, ms)
+	_, ok = h.find(chip, "Найден ПЕС", -1)
+	assert.False(t, ok, "regexps without keywords run over the text")
+
+	// matches are found from the keywords' positions, seeing what precedes them
+	word := regexp.MustCompile(`(?i)(?:^|[^\p{L}])(пропал\p{L}*)\b`)
+	ki = newKeywordIndex([]*regexp.Regexp{word})
+	for _, s := range []string{"Пропал пёс", "пес пропал, ПРОПАЛА кошка", "непропал", "спропала пропали"} {
+		ms, ok := ki.scan(s).find(word, s, -1)
+		assert.True(t, ok)
+		assert.Equal(t, word.FindAllStringSubmatchIndex(s, -1), ms, s)
+	}
+
+	var none *keywordIndex
+	assert.True(t, none.scan("кот").may(lost))
+}
+
+// keywordTexts are the fixture texts plus their upper-case forms, which the
+// index must fold like the regexps do.
+func keywordTexts(t *testing.T) []string {
+	texts := fixtureTexts(t)
+	for _, s := range texts[:len(texts):len(texts)] {
+		texts = append(texts, strings.ToUpper(s))
+	}
+	return texts
+}
+
+// TestKeywordIndex_Sound checks that no regexp matching a text is skipped and
+// that the matches the index finds are the regexp's own.
+func TestKeywordIndex_Sound(t *testing.T) {
+	ps := DefaultParser()
+	for _, s := range keywordTexts(t) {
+		s = normalizeText(s)
+		h := ps.keywords.scan(s)
+		for re := range ps.keywords.res {
+			if re.MatchString(s) {
+				require.True(t, h.may(re), "%s skipped on %q", re, s)
+			}
+			if ms, ok := h.find(re, s, -1); ok {
+				require.Equal(t, re.FindAllStringSubmatchIndex(s, -1), ms, "%s on %q", re, s)
+			}
+			if ms, ok := h.find(re, s, 1); ok {
+				require.Equal(t, re.FindAllStringSubmatchIndex(s, 1), ms, "%s on %q", re, s)
+			}
+		}
+	}
+}
+
+// TestParse_KeywordIndex checks that the index never changes a result.
+func TestParse_KeywordIndex(t *testing.T) {
+	ps := DefaultParser()
+	plain := *ps
+	plain.keywords = nil
+	posted := time.Date(2025, 7, 8, 12, 0, 0, 0, Izhevsk)
+	for i, s := range keywordTexts(t) {
+		p, ex := ps.ParseExplainAt(i, s, posted)
+		wantP, wantEx := plain.ParseExplainAt(i, s, posted)
+		require.Equal(t, wantP, p, s)
+		require.Equal(t, wantEx, ex, s)
+	}
+}
@@ -0,0 +1,233 @@
+package lostdogs
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// normalizeText prepares raw post text for the extractors. Post.Raw keeps the
+// original for display; match spans in an Explanation refer to this text.
+//
+//   - emoji and decorative symbols are dropped, emoji exclamation marks become
+//     "!", and runs of punctuation or separator lines are collapsed;
+//   - Latin look-alikes inside Cyrillic words are folded ("cобака" with a
+//     Latin c, "с0бака");
+//   - ALL CAPS Cyrillic words are lowercased;
+//   - obfuscated phone numbers are rewritten as digits ("8 9I2 ЗЗЗ-44-55",
+//     "восемь девятьсот двенадцать ...");
+//   - whitespace is collapsed.
+func normalizeText(raw string) string {
+	s := stripDecorations(raw)
+	s = reWordish.ReplaceAllStringFunc(s, foldHomoglyphs)
+	s = reCyrWord.ReplaceAllStringFunc(s, func(w string) string {
+		if strings.ToUpper(w) != w {
+			return w
+		}
+		return strings.ToLower(w)
+	})
+	s = deobfuscatePhones(s)
+	s = spellNumbers(s)
+	return normalizeSpace(s)
+}
+
+var (
+	reWordish = regexp.MustCompile(`[\p{L}\d]+`)
+	reCyrWord = regexp.MustCompile(`\p{Cyrillic}{4,}`)
+
+	reRepeatBang  = regexp.MustCompile(`[!?]{2,}`)
+	reRepeatDots  = regexp.MustCompile(`\.{4,}`)
+	reRepeatComma = regexp.MustCompile(`,{2,}`)
+	reRuleLine    = regexp.MustCompile(`[*=_~#\-]{3,}`)
+)
+
+// Emoji that stand for punctuation.
+var emojiPunct = map[rune]string{
+	'❗': "!", '❕': "!", '‼': "!", '⁉': "?", '❓': "?", '❔': "?",
+}
+
+// stripDecorations removes emoji and collapses decorative punctuation.
+func stripDecorations(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case emojiPunct[r] != "":
+			b.WriteString(emojiPunct[r])
+		case r == '︎' || r == '️' || r == '‍' || r == '⃣' || (r >= 0x1F3FB && r <= 0x1F3FF):
+			// variation selectors, joiners, keycaps ("8️⃣") and skin tones
+		case r >= 0x2190 && unicode.Is(unicode.So, r):
+			b.WriteByte(' ') // arrows, dingbats, emoji; keeps "№" and "°"
+		default:
+			b.WriteRune(r)
+		}
+	}
+	s = reRepeatBang.ReplaceAllStringFunc(b.String(), func(m string) string {
+		if strings.Contains(m, "?") {
+			return "?"
+		}
+		return "!"
+	})
+	s = reRepeatDots.ReplaceAllString(s, "...")
+	s = reRepeatComma.ReplaceAllString(s, ",")
+	return reRuleLine.ReplaceAllString(s, " ")
+}
+
+// Latin letters and digits that look like Cyrillic letters.
+var (
+	latinToCyrillic = map[rune]rune{
+		'a': 'а', 'c': 'с', 'e': 'е', 'o': 'о', 'p': 'р', 'x': 'х', 'y': 'у', 'k': 'к',
+		'A': 'А', 'B': 'В', 'C': 'С', 'E': 'Е', 'H': 'Н', 'K': 'К', 'M': 'М', 'O': 'О',
+		'P': 'Р', 'T': 'Т', 'X': 'Х', 'Y': 'У',
+	}
+	digitToCyrillic = map[rune]rune{'0': 'о', '3': 'з'}
+)
+
+// foldHomoglyphs rewrites a word mixing Cyrillic with Latin look-alikes, or
+// with 0 and 3 between Cyrillic letters, as Cyrillic. Words that would keep a
+// Latin letter ("iPhone", links) are left alone.
+func foldHomoglyphs(w string) string {
+	if !strings.ContainsFunc(w, isCyrillic) {
+		return w
+	}
+	rs := []rune(w)
+	changed := false
+	for i, r := range rs {
+		if c, ok := latinToCyrillic[r]; ok {
+			rs[i], changed = c, true
+			continue
+		}
+		if r < unicode.MaxASCII && unicode.IsLetter(r) {
+			return w
+		}
+		if c, ok := digitToCyrillic[r]; ok && i > 0 && i < len(rs)-1 && isCyrillic(rs[i-1]) && isCyrillic(rs[i+1]) {
+			rs[i], changed = c, true
+		}
+	}
+	if !changed {
+		return w
+	}
+	return string(rs)
+}
+
+func isCyrillic(r rune) bool { return unicode.Is(unicode.Cyrillic, r) }
+
+// Characters people type instead of digits in phone numbers.
+var digitLookalikes = map[rune]rune{
+	'О': '0', 'о': '0', 'O': '0', 'o': '0',
+	'З': '3', 'з': '3',
+	'I': '1', 'l': '1', '|': '1',
+	'Б': '6', 'б': '6',
+	'Ч': '4', 'ч': '4',
+}
+
+// rePhoneish is a run of digits, look-alikes and separators standing apart
+// from words; group 1 is the run.
+var rePhoneish = regexp.MustCompile(`(?:^|[^\p{L}\d])(\+?[\dОоOoЗзIl|БбЧч][\dОоOoЗзIl|БбЧч \-().]{8,}[\dОоOoЗзIl|БбЧч])(?:[^\p{L}\d]|$)`)
+
+// deobfuscatePhones rewrites runs of digits and digit look-alikes that spell
+// a Russian mobile number once the look-alikes are read as digits. A run must
+// already hold most of its digits so ordinary words never qualify.
+func deobfuscatePhones(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range rePhoneish.FindAllStringSubmatchIndex(s, -1) {
+		run := s[m[2]:m[3]]
+		var digits []rune
+		real := 0
+		for _, r := range run {
+			switch {
+			case r >= '0' && r <= '9':
+				digits = append(digits, r)
+				real++
+			case digitLookalikes[r] != 0:
+				digits = append(digits, digitLookalikes[r])
+			}
+		}
+		if real == len(digits) || real < 6 || !phoneDigits(string(digits)) {
+			continue
+		}
+		b.WriteString(s[last:m[2]])
+		b.WriteString(string(digits))
+		last = m[3]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// phoneDigits reports whether d looks like a Russian mobile number.
+func phoneDigits(d string) bool {
+	switch len(d) {
+	case 11:
+		return (d[0] == '8' || d[0] == '7') && d[1] == '9'
+	case 10:
+		return d[0] == '9'
+	}
+	return false
+}
+
+// Number words: value and order (1 units, 2 tens and teens, 3 hundreds).
+var numberWords = map[string][2]int{
+	"ноль": {0, 1}, "один": {1, 1}, "одна": {1, 1}, "два": {2, 1}, "две": {2, 1}, "три": {3, 1},
+	"четыре": {4, 1}, "пять": {5, 1}, "шесть": {6, 1}, "семь": {7, 1}, "восемь": {8, 1}, "девять": {9, 1},
+	"десять": {10, 2}, "одиннадцать": {11, 2}, "двенадцать": {12, 2}, "тринадцать": {13, 2},
+	"четырнадцать": {14, 2}, "пятнадцать": {15, 2}, "шестнадцать": {16, 2}, "семнадцать": {17, 2},
+	"восемнадцать": {18, 2}, "девятнадцать": {19, 2},
+	"двадцать": {20, 2}, "тридцать": {30, 2}, "сорок": {40, 2}, "пятьдесят": {50, 2},
+	"шестьдесят": {60, 2}, "семьдесят": {70, 2}, "восемьдесят": {80, 2}, "девяносто": {90, 2},
+	"сто": {100, 3}, "двести": {200, 3}, "триста": {300, 3}, "четыреста": {400, 3}, "пятьсот": {500, 3},
+	"шестьсот": {600, 3}, "семьсот": {700, 3}, "восемьсот": {800, 3}, "девятьсот": {900, 3},
+}
+
+var (
+	reLetters   = regexp.MustCompile(`\p{L}+`)
+	reNumberGap = regexp.MustCompile(`^[\s,\-]*$`)
+)
+
+// spellNumbers rewrites a phone number spelled in words ("восемь девятьсот
+// двенадцать триста тридцать три сорок четыре пятьдесят пять") as digits.
+// Words of decreasing order make up one number: "девятьсот двенадцать" is 912,
+// "восемь девятьсот" is 8 then 900; a teen or "десять" takes no units after
+// it and "ноль" stands alone.
+func spellNumbers(s string) string {
+	words := reLetters.FindAllStringIndex(s, -1)
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(words); {
+		var digits strings.Builder
+		group, order, prev := 0, 4, 0
+		flush := func() {
+			if order < 4 {
+				digits.WriteString(strconv.Itoa(group))
+			}
+			group, order = 0, 4
+		}
+		j := i
+		for ; j < len(words); j++ {
+			nw, ok := numberWords[strings.ToLower(s[words[j][0]:words[j][1]])]
+			if !ok || (j > i && !reNumberGap.MatchString(s[words[j-1][1]:words[j][0]])) {
+				break
+			}
+			v, o := nw[0], nw[1]
+			if v == 0 {
+				flush()
+				digits.WriteByte('0')
+				continue
+			}
+			if o >= order || (o == 1 && prev >= 10 && prev < 20) {
+				flush()
+			}
+			group, order, prev = group+v, o, v
+		}
+		flush()
+		if j-i >= 3 && phoneDigits(digits.String()) {
+			b.WriteString(s[last:words[i][0]])
+			b.WriteString(digits.String())
+			last = words[j-1][1]
+		}
+		i = max(j, i+1)
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
@@ -0,0 +1,46 @@
+package lostdogs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeText(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"latin look-alikes", "Пропала cобака, кoшка дома", "Пропала собака, кошка дома"},
+		{"digits in words", "Найдена с0бака, зв0ните", "Найдена собака, звоните"},
+		{"latin words stay", "Пишите в WhatsApp или vk.com/id123", "Пишите в WhatsApp или vk.com/id123"},
+		{"emoji", "📢 Пропал кот 🐱 ☎️ 89121234567 🙏❤️", "Пропал кот 89121234567"},
+		{"emoji punctuation", "Помогите‼️ Срочно❗❗❗", "Помогите! Срочно!"},
+		{"repeated punctuation", "Пропал!!! Где??!! Ищем.......", "Пропал! Где? Ищем..."},
+		{"separator lines", "Пропал кот\n=========\nЗвоните", "Пропал кот Звоните"},
+		{"caps", "ПРОПАЛА СОБАКА у ТЦ", "пропала собака у ТЦ"},
+		{"keycaps", "8️⃣9️⃣1️⃣2️⃣1️⃣2️⃣3️⃣4️⃣5️⃣6️⃣7️⃣", "89121234567"},
+		{"phone look-alikes", "Звоните 8 9I2 ЗЗЗ-44-55, Ольга", "Звоните 89123334455, Ольга"},
+		{"phone words", "тел восемь девятьсот двенадцать триста тридцать три сорок четыре пятьдесят пять", "тел 89123334455"},
+		{"phone words with zero", "девять ноль два сто двадцать три сорок пять шестьдесят семь", "9021234567"},
+		{"not a phone", "два года, три месяца, около пяти кг", "два года, три месяца, около пяти кг"},
+		{"age stays", "Возраст 2 года, вес 3 кг", "Возраст 2 года, вес 3 кг"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, normalizeText(tc.in))
+		})
+	}
+}
+
+func TestParse_Obfuscated(t *testing.T) {
+	raw := "ПРОПАЛА cобака❗️ Кобель, рыжий. Звоните 8 9I2 ЗЗЗ-44-55"
+	p := Parse(1, raw)
+	assert.Equal(t, raw, p.Raw)
+	assert.Equal(t, TypeLost, p.Type)
+	assert.Equal(t, AnimalDog, p.Animal)
+	require.Len(t, p.Phones(), 1)
+	assert.Contains(t, p.Phones()[0], "9123334455")
+}
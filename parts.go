@@ -0,0 +1,133 @@
+package lostdogs
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jehaby/lostdogs/internal/ru"
+)
+
+// Multi-animal posts. Shelters and volunteers often list several animals in
+// one post: "Ищут дом: 1) кот Барсик, 2 года... 2) собака Найда, 5 мес...".
+// Parse still fills the Post from the whole text, so single-row consumers keep
+// working, and sets Post.Parts to one Post per listed animal, parsed from its
+// item alone. What an item does not say (the type, the animal of a list
+// introduced by "котята ищут дом", contacts, the place) comes from the post.
+
+// reItem is a list marker: "1)", "2.", "№3". Group 1 is the marker.
+var (
+	reItem   = regexp.MustCompile(`(?:^|[\s:;.,!?])(№\s*\d{1,2}[).]?|\d{1,2}\s*[).])\s`)
+	reDigits = regexp.MustCompile(`\d+`)
+)
+
+// splitItems returns the items of a numbered list in s, without their
+// markers, or nil when s has fewer than two. Markers must count up from 1;
+// others ("ул. Ленина 5.") are ignored. Text before the first marker is not
+// part of any item.
+func splitItems(s string) []string {
+	type marker struct{ start, end int }
+	var ms []marker
+	next := 1
+	for _, m := range reItem.FindAllStringSubmatchIndex(s, -1) {
+		if n, _ := strconv.Atoi(reDigits.FindString(s[m[2]:m[3]])); n != next {
+			continue
+		}
+		ms = append(ms, marker{m[2], m[3]})
+		next++
+	}
+	if len(ms) < 2 {
+		return nil
+	}
+	items := make([]string, len(ms))
+	for i, m := range ms {
+		end := len(s)
+		if i+1 < len(ms) {
+			end = ms[i+1].start
+		}
+		items[i] = strings.TrimRight(strings.TrimSpace(s[m.end:end]), ",; ")
+	}
+	return items
+}
+
+// parts parses the items of an enumerated post. It returns nil unless every
+// item names an animal or an age, so lists of needs ("1) корм 2) лекарства")
+// or of rules stay whole.
+func (ps *Parser) parts(p Post, s string, posted time.Time) []Post {
+	items := splitItems(s)
+	if items == nil {
+		return nil
+	}
+	out := make([]Post, 0, len(items))
+	for _, it := range items {
+		sc := ps.newScan(it)
+		a := Post{ID: p.ID, Raw: it, ParserVersion: p.ParserVersion}
+		ps.extract(sc, &a, posted)
+		if a.Animal == AnimalUnknown && a.Age == "" {
+			return nil
+		}
+		if a.Name == "" {
+			a.Name = ps.itemName(it)
+		}
+		inherit(&a, p)
+		out = append(out, a)
+	}
+	return out
+}
+
+// inherit fills the fields a part leaves empty from the whole post.
+func inherit(a *Post, p Post) {
+	if a.Type == TypeUnknown {
+		a.Type, a.TypeSource = p.Type, p.TypeSource
+	}
+	if a.Animal == AnimalUnknown {
+		a.Animal, a.AnimalSource = p.Animal, p.AnimalSource
+	}
+	if len(a.Contacts) == 0 {
+		a.Contacts = p.Contacts
+	}
+	if len(a.ContactNames) == 0 {
+		a.ContactNames = p.ContactNames
+	}
+	if a.Location == "" && a.Address == "" {
+		a.Location, a.Address, a.District, a.Lat, a.Lon = p.Location, p.Address, p.District, p.Lat, p.Lon
+	}
+	if a.When == "" {
+		a.When, a.WhenFrom, a.WhenTo, a.WhenPrecision = p.When, p.WhenFrom, p.WhenTo, p.WhenPrecision
+	}
+}
+
+// itemName is the name an item opens with, optionally after the animal noun:
+// "Барсик, 2 года", "Кот Барсик — 2 года". Words the rules or the sex cues
+// know ("Мальчик, 2 мес", "Рыжая кошка") are not names.
+func (ps *Parser) itemName(it string) string {
+	toks := ru.Tokenize(it)
+	votes := func(t ru.Token, cr classRules) bool {
+		return len(newScan(ps.rules, t.Text).vote("", cr).votes) > 0
+	}
+	i := 0
+	if len(toks) > 1 && votes(toks[0], ps.rules.animal) && strings.TrimSpace(it[toks[0].End:toks[1].Start]) == "" {
+		i = 1
+	}
+	if i >= len(toks) {
+		return ""
+	}
+	t := toks[i]
+	r := []rune(t.Text)
+	if len(r) < 3 || !unicode.IsUpper(r[0]) {
+		return ""
+	}
+	if rest := strings.TrimSpace(it[t.End:]); rest != "" {
+		if next, _ := utf8.DecodeRuneInString(rest); !strings.ContainsRune(",.:;!(—-", next) {
+			return ""
+		}
+	}
+	w := strings.ReplaceAll(strings.ToLower(t.Text), "ё", "е")
+	if _, ok := sexCues[w]; ok || votes(t, ps.rules.animal) || votes(t, ps.rules.sex) || votes(t, ps.rules.typ) {
+		return ""
+	}
+	return titleCase(t.Text)
+}
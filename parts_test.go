@@ -0,0 +1,72 @@
+package lostdogs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_MultiAnimal(t *testing.T) {
+	text := "Ищут дом! 1) Кот Барсик, 2 года, рыжий, кастрирован. 2) Собака Жучка, 5 месяцев, девочка, " +
+		"стерилизована. 3) Котенок Уголек, 3 мес. Звоните 8 912 028 16 83 Юрий"
+	p := Parse(0, text)
+	assert.Equal(t, TypeAdoption, p.Type)
+	require.Len(t, p.Parts, 3)
+
+	type part struct {
+		animal AnimalType
+		sex    SexType
+		name   string
+		age    string
+	}
+	var got []part
+	for _, a := range p.Parts {
+		got = append(got, part{a.Animal, a.Sex, a.Name, a.Age})
+		assert.Equal(t, TypeAdoption, a.Type)
+		assert.Equal(t, []string{"+79120281683"}, a.Phones(), "items without a phone use the post's")
+	}
+	assert.Equal(t, []part{
+		{AnimalCat, SexM, "Барсик", "2 год"},
+		{AnimalDog, SexF, "Жучка", "5 мес"},
+		{AnimalCat, SexUnknown, "Уголек", "3 мес"},
+	}, got)
+	assert.Equal(t, "Кот Барсик, 2 года, рыжий, кастрирован.", p.Parts[0].Raw)
+}
+
+func TestParse_MultiAnimalInheritsAnimal(t *testing.T) {
+	p := Parse(0, "Котята ищут дом: 1. Рыжик, 2 месяца, мальчик 2. Муся, 3 месяца, девочка. Тел 89120281683")
+	require.Len(t, p.Parts, 2)
+	for _, a := range p.Parts {
+		assert.Equal(t, AnimalCat, a.Animal)
+	}
+	assert.Equal(t, "Рыжик", p.Parts[0].Name)
+	assert.Equal(t, SexF, p.Parts[1].Sex)
+}
+
+func TestParse_MultiAnimalNamedNaida(t *testing.T) {
+	// "Найда" shares the stem of "найден" but is a name, not a found vote.
+	p := Parse(0, "Ищут дом! 1) Кот Барсик, 2 года, кастрирован. 2) Собака Найда, 3 года, стерилизована. Звоните 89120281683")
+	assert.Equal(t, TypeAdoption, p.Type)
+	require.Len(t, p.Parts, 2)
+	for _, a := range p.Parts {
+		assert.Equal(t, TypeAdoption, a.Type)
+	}
+	assert.Equal(t, "Найда", p.Parts[1].Name)
+}
+
+func TestParse_NotMultiAnimal(t *testing.T) {
+	for _, text := range []string{
+		"Пропала собака 12.05.2024, ул. Ленина 1. Звоните 89120281683, рыжая",
+		"Приюту нужны: 1) корм для собак, 2) лекарства, 3) пеленки. Карта 89120281683",
+		"Пропал кот, 2) рыжий, без ошейника. Звоните 89120281683",
+	} {
+		assert.Nil(t, Parse(0, text).Parts, text)
+	}
+}
+
+func TestSplitItems(t *testing.T) {
+	assert.Equal(t, []string{"кот", "собака", "№5 кошка"}, splitItems("Ищут дом: 1) кот 2. собака 3) №5 кошка"))
+	assert.Equal(t, []string{"кот", "собака"}, splitItems("№1 кот; №2) собака"))
+	assert.Nil(t, splitItems("2) кот 3) собака"))
+}
@@ -0,0 +1,21 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Persist the remaining fields produced by lostdogs.Parse.
+
+ALTER TABLE posts ADD COLUMN breed          TEXT    DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN age            TEXT    DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN sterilized     BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN vaccinated     BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN chipped        BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN litter_ok      BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN parser_version TEXT    DEFAULT NULL; -- lostdogs.ParserVersion of the producing rule set
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE posts DROP COLUMN parser_version;
+ALTER TABLE posts DROP COLUMN litter_ok;
+ALTER TABLE posts DROP COLUMN chipped;
+ALTER TABLE posts DROP COLUMN vaccinated;
+ALTER TABLE posts DROP COLUMN sterilized;
+ALTER TABLE posts DROP COLUMN age;
+ALTER TABLE posts DROP COLUMN breed;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- lostdogs.Explanation as JSON: deciding rule, matched spans and confidence per field.
+
+ALTER TABLE posts ADD COLUMN explain TEXT DEFAULT NULL;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE posts DROP COLUMN explain;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Human-verified ("gold") labels used to evaluate the parser. Labels keep their own
+-- copy of the text so posts from dump-wall fixture files can be labeled too.
+
+CREATE TABLE IF NOT EXISTS labels (
+  owner_id    INTEGER   NOT NULL,
+  post_id     INTEGER   NOT NULL,
+  raw         TEXT      NOT NULL,
+  source      TEXT      NOT NULL DEFAULT 'db', -- 'db' or the fixture file name
+  -- NULL means the field was not labeled
+  type        TEXT      DEFAULT NULL CHECK (type IN ('unknown','lost','found','sighting','adoption','fundraising','news','link','empty')),
+  animal      TEXT      DEFAULT NULL CHECK (animal IN ('unknown','cat','dog','other')),
+  sex         TEXT      DEFAULT NULL CHECK (sex IN ('unknown','m','f')),
+  note        TEXT      DEFAULT NULL,
+  created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (owner_id, post_id)
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE IF EXISTS labels;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Which stage decided type/animal: 'rules', 'model' (statistical fallback) or NULL.
+
+ALTER TABLE posts ADD COLUMN type_source TEXT DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN animal_source TEXT DEFAULT NULL;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE posts DROP COLUMN animal_source;
+ALTER TABLE posts DROP COLUMN type_source;
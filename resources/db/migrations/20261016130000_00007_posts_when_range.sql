@@ -0,0 +1,17 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Resolved "when" as a [when_from, when_to) interval of unix seconds plus its precision
+-- (minute, hour, part_of_day, day); NULL when the expression could not be resolved.
+
+ALTER TABLE posts ADD COLUMN when_from INTEGER DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN when_to INTEGER DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN when_precision TEXT DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_posts_when_from ON posts(when_from);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_posts_when_from;
+ALTER TABLE posts DROP COLUMN when_precision;
+ALTER TABLE posts DROP COLUMN when_to;
+ALTER TABLE posts DROP COLUMN when_from;
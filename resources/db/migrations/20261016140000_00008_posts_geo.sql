@@ -0,0 +1,19 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Gazetteer-resolved place: normalized address, administrative district and
+-- approximate coordinates (see resources/gazetteer).
+
+ALTER TABLE posts ADD COLUMN address TEXT DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN district TEXT DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN lat REAL DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN lon REAL DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_posts_district ON posts(district);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_posts_district;
+ALTER TABLE posts DROP COLUMN lon;
+ALTER TABLE posts DROP COLUMN lat;
+ALTER TABLE posts DROP COLUMN district;
+ALTER TABLE posts DROP COLUMN address;
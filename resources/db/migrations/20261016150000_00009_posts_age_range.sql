@@ -0,0 +1,14 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Age as a range in months plus a life-stage class (baby, young, adult, senior);
+-- posts.age keeps the phrase as written.
+
+ALTER TABLE posts ADD COLUMN age_min_months INTEGER DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN age_max_months INTEGER DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN age_class TEXT DEFAULT NULL CHECK (age_class IN ('baby','young','adult','senior'));
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE posts DROP COLUMN age_class;
+ALTER TABLE posts DROP COLUMN age_max_months;
+ALTER TABLE posts DROP COLUMN age_min_months;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Appearance for lost/found matching: canonical colors, coat length, worn gear
+-- (collar, harness, clothing as written) and distinctive marks. Lists are JSON arrays.
+
+ALTER TABLE posts ADD COLUMN colors TEXT DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN coat TEXT DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN gear TEXT DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN marks TEXT DEFAULT NULL;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE posts DROP COLUMN marks;
+ALTER TABLE posts DROP COLUMN gear;
+ALTER TABLE posts DROP COLUMN coat;
+ALTER TABLE posts DROP COLUMN colors;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Canonical breed from resources/breeds/breeds.yml, for filtering and matching
+-- by breed; breed keeps the display name.
+
+ALTER TABLE posts ADD COLUMN breed_id    TEXT    DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN breed_mixed BOOLEAN NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_posts_breed_id ON posts(breed_id);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_posts_breed_id;
+ALTER TABLE posts DROP COLUMN breed_mixed;
+ALTER TABLE posts DROP COLUMN breed_id;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Resolved cases ("нашлась", "хозяева найдены") are stored but never enqueued.
+
+ALTER TABLE posts ADD COLUMN resolved BOOLEAN NOT NULL DEFAULT 0;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE posts DROP COLUMN resolved;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- One row per animal of a multi-animal post ("1) кот Барсик... 2) собака Жучка...").
+-- The posts row keeps the fields parsed from the whole text; posts with a
+-- single animal have no rows here.
+
+CREATE TABLE IF NOT EXISTS post_animals (
+  owner_id        INTEGER   NOT NULL,
+  post_id         INTEGER   NOT NULL,
+  idx             INTEGER   NOT NULL, -- 1-based position in the post's list
+  text            TEXT      NOT NULL, -- the list item, normalized
+  type            TEXT      NOT NULL DEFAULT 'unknown' CHECK (type IN ('unknown','lost','found','sighting','adoption','fundraising','news','link','empty')),
+  animal          TEXT      NOT NULL DEFAULT 'unknown' CHECK (animal IN ('unknown','cat','dog','other')),
+  sex             TEXT      NOT NULL DEFAULT 'unknown' CHECK (sex IN ('unknown','m','f')),
+  name            TEXT               DEFAULT NULL,
+  breed           TEXT               DEFAULT NULL,
+  breed_id        TEXT               DEFAULT NULL,
+  age             TEXT               DEFAULT NULL,
+  age_min_months  INTEGER            DEFAULT NULL,
+  age_max_months  INTEGER            DEFAULT NULL,
+  phones          TEXT               DEFAULT NULL, -- string[]
+  PRIMARY KEY (owner_id, post_id, idx),
+  FOREIGN KEY (owner_id, post_id) REFERENCES posts(owner_id, post_id) ON DELETE CASCADE
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE IF EXISTS post_animals;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Species of animals other than cats and dogs ("parrot", "ferret", "goat").
+
+ALTER TABLE posts ADD COLUMN species TEXT DEFAULT NULL;
+ALTER TABLE post_animals ADD COLUMN species TEXT DEFAULT NULL;
+CREATE INDEX IF NOT EXISTS idx_posts_species ON posts(species);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_posts_species;
+ALTER TABLE post_animals DROP COLUMN species;
+ALTER TABLE posts DROP COLUMN species;
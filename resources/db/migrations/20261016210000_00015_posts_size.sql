@@ -0,0 +1,20 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Size for lost/found matching: size class (small, medium, large, giant), weight
+-- range in kg, height at the withers in cm and build (slim, stocky, fat, muscular).
+
+ALTER TABLE posts ADD COLUMN size TEXT DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN weight_min_kg REAL DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN weight_max_kg REAL DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN height_min_cm INTEGER DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN height_max_cm INTEGER DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN build TEXT DEFAULT NULL;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE posts DROP COLUMN build;
+ALTER TABLE posts DROP COLUMN height_max_cm;
+ALTER TABLE posts DROP COLUMN height_min_cm;
+ALTER TABLE posts DROP COLUMN weight_max_kg;
+ALTER TABLE posts DROP COLUMN weight_min_kg;
+ALTER TABLE posts DROP COLUMN size;
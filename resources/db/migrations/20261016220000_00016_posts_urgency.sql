@@ -0,0 +1,22 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Urgency (normal, high, critical) and health flags (JSON array, e.g.
+-- ["hit_by_car","limp"]) of a post. Outbox rows carry the urgency rank as
+-- priority so workers deliver urgent posts first.
+
+ALTER TABLE posts ADD COLUMN urgency TEXT DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN health TEXT DEFAULT NULL;
+
+ALTER TABLE outbox ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE outbox_vk ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_outbox_status_priority ON outbox(status, priority DESC, created_at);
+CREATE INDEX IF NOT EXISTS idx_outbox_vk_status_priority ON outbox_vk(status, priority DESC, created_at);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_outbox_vk_status_priority;
+DROP INDEX IF EXISTS idx_outbox_status_priority;
+ALTER TABLE outbox_vk DROP COLUMN priority;
+ALTER TABLE outbox DROP COLUMN priority;
+ALTER TABLE posts DROP COLUMN health;
+ALTER TABLE posts DROP COLUMN urgency;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Rewards promised by owners (amount in rubles when given) and found posts
+-- that ask to pay for the pet's return, a known scam pattern.
+
+ALTER TABLE posts ADD COLUMN reward BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN reward_amount INTEGER DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN scam_risk BOOLEAN NOT NULL DEFAULT 0;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE posts DROP COLUMN scam_risk;
+ALTER TABLE posts DROP COLUMN reward_amount;
+ALTER TABLE posts DROP COLUMN reward;
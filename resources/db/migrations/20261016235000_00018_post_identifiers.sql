@@ -0,0 +1,37 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Chip numbers, tattoo codes and collar-tag phones found in posts, and the
+-- lost/found pairs that share one. A match is a strong hint the found animal
+-- is the lost one, so it waits for a moderator to confirm or reject it.
+
+CREATE TABLE IF NOT EXISTS post_identifiers (
+  owner_id  INTEGER NOT NULL,
+  post_id   INTEGER NOT NULL,
+  kind      TEXT    NOT NULL CHECK (kind IN ('chip','tattoo','tag_phone')),
+  value     TEXT    NOT NULL, -- normalized: digits, an upper-case code or a +7 phone
+  PRIMARY KEY (owner_id, post_id, kind, value),
+  FOREIGN KEY (owner_id, post_id) REFERENCES posts(owner_id, post_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_identifiers_value ON post_identifiers(kind, value);
+
+CREATE TABLE IF NOT EXISTS post_matches (
+  lost_owner_id   INTEGER   NOT NULL,
+  lost_post_id    INTEGER   NOT NULL,
+  found_owner_id  INTEGER   NOT NULL, -- a found or sighting post
+  found_post_id   INTEGER   NOT NULL,
+  kind            TEXT      NOT NULL CHECK (kind IN ('chip','tattoo','tag_phone')),
+  value           TEXT      NOT NULL,
+  status          TEXT      NOT NULL DEFAULT 'new' CHECK (status IN ('new','confirmed','rejected')),
+  created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (lost_owner_id, lost_post_id, found_owner_id, found_post_id, kind, value),
+  FOREIGN KEY (lost_owner_id, lost_post_id) REFERENCES posts(owner_id, post_id) ON DELETE CASCADE,
+  FOREIGN KEY (found_owner_id, found_post_id) REFERENCES posts(owner_id, post_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_matches_status ON post_matches(status, created_at);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE IF EXISTS post_matches;
+DROP TABLE IF EXISTS post_identifiers;
@@ -0,0 +1,52 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Typed contact channels of a post: phone, vk, telegram, whatsapp, email.
+-- They replace posts.phones and posts.vk_accounts; existing values are copied
+-- over, `lostdogs reparse` fills in the new channels.
+
+CREATE TABLE IF NOT EXISTS post_contacts (
+  owner_id  INTEGER NOT NULL,
+  post_id   INTEGER NOT NULL,
+  idx       INTEGER NOT NULL, -- 1-based position in the post
+  kind      TEXT    NOT NULL CHECK (kind IN ('phone','vk','telegram','whatsapp','email')),
+  value     TEXT    NOT NULL, -- "+79120281683", "id123", "@user", "user@mail.ru"; '' for "пишите в личку"
+  name      TEXT             DEFAULT NULL, -- display name of a VK mention
+  PRIMARY KEY (owner_id, post_id, idx),
+  FOREIGN KEY (owner_id, post_id) REFERENCES posts(owner_id, post_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_contacts_value ON post_contacts(kind, value);
+
+INSERT INTO post_contacts (owner_id, post_id, idx, kind, value)
+SELECT p.owner_id, p.post_id, j.key + 1, 'phone', j.value
+FROM posts p, json_each(p.phones) j
+WHERE p.phones IS NOT NULL;
+
+-- "[id123|Юрий]" mentions keep their name; "vk.com/durov" links become "durov".
+INSERT INTO post_contacts (owner_id, post_id, idx, kind, value, name)
+SELECT p.owner_id, p.post_id, coalesce(json_array_length(p.phones), 0) + j.key + 1, 'vk',
+       CASE WHEN j.value LIKE '[%|%]' THEN substr(j.value, 2, instr(j.value, '|') - 2)
+            ELSE substr(j.value, instr(lower(j.value), 'vk.com/') + 7) END,
+       CASE WHEN j.value LIKE '[%|%]' THEN substr(j.value, instr(j.value, '|') + 1, length(j.value) - instr(j.value, '|') - 1) END
+FROM posts p, json_each(p.vk_accounts) j
+WHERE p.vk_accounts IS NOT NULL;
+
+ALTER TABLE posts DROP COLUMN phones;
+ALTER TABLE posts DROP COLUMN vk_accounts;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE posts ADD COLUMN phones TEXT DEFAULT NULL; -- string[]
+ALTER TABLE posts ADD COLUMN vk_accounts TEXT DEFAULT NULL; -- JSON array string
+
+-- Phones are restored; VK accounts come back with the next reparse.
+UPDATE posts SET phones = (
+  SELECT json_group_array(value) FROM (
+    SELECT value FROM post_contacts c
+    WHERE c.owner_id = posts.owner_id AND c.post_id = posts.post_id AND c.kind = 'phone'
+    ORDER BY idx
+  )
+)
+WHERE EXISTS (SELECT 1 FROM post_contacts c WHERE c.owner_id = posts.owner_id AND c.post_id = posts.post_id AND c.kind = 'phone');
+
+DROP TABLE IF EXISTS post_contacts;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Who wrote the post (owner, finder, volunteer, shelter or unknown) and what
+-- decided it: a cue in the text, the post type, a repost or the history of
+-- the post's phones. Reposts are kept so a reparse can decide the role again.
+
+ALTER TABLE posts ADD COLUMN role TEXT DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN role_source TEXT DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN repost BOOLEAN NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_posts_role ON posts(role);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_posts_role;
+ALTER TABLE posts DROP COLUMN repost;
+ALTER TABLE posts DROP COLUMN role_source;
+ALTER TABLE posts DROP COLUMN role;
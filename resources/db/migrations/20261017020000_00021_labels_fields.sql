@@ -0,0 +1,16 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Gold labels for the fields parsed since the labels table was added, so eval
+-- can score rule changes to them too. NULL means the field was not labeled.
+
+ALTER TABLE labels ADD COLUMN age_class TEXT DEFAULT NULL CHECK (age_class IN ('unknown','baby','young','adult','senior'));
+ALTER TABLE labels ADD COLUMN size TEXT DEFAULT NULL CHECK (size IN ('unknown','small','medium','large','giant'));
+ALTER TABLE labels ADD COLUMN urgency TEXT DEFAULT NULL CHECK (urgency IN ('unknown','normal','high','critical'));
+ALTER TABLE labels ADD COLUMN role TEXT DEFAULT NULL CHECK (role IN ('unknown','owner','finder','volunteer','shelter'));
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE labels DROP COLUMN role;
+ALTER TABLE labels DROP COLUMN urgency;
+ALTER TABLE labels DROP COLUMN size;
+ALTER TABLE labels DROP COLUMN age_class;
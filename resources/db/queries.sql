@@ -12,11 +12,51 @@ INSERT INTO posts (
   name,
   location,
   "when",
-  phones,
   contact_names,
-  vk_accounts,
   photos,
-  status_details
+  status_details,
+  breed,
+  age,
+  sterilized,
+  vaccinated,
+  chipped,
+  litter_ok,
+  parser_version,
+  explain,
+  type_source,
+  animal_source,
+  when_from,
+  when_to,
+  when_precision,
+  address,
+  district,
+  lat,
+  lon,
+  age_min_months,
+  age_max_months,
+  age_class,
+  colors,
+  coat,
+  gear,
+  marks,
+  breed_id,
+  breed_mixed,
+  resolved,
+  species,
+  size,
+  weight_min_kg,
+  weight_max_kg,
+  height_min_cm,
+  height_max_cm,
+  build,
+  urgency,
+  health,
+  reward,
+  reward_amount,
+  scam_risk,
+  role,
+  role_source,
+  repost
 )
 VALUES (
   @owner_id,
@@ -30,11 +70,51 @@ VALUES (
   @name,
   @location,
   @when,
-  @phones,
   @contact_names,
-  @vk_accounts,
   @photos,
-  @status_details
+  @status_details,
+  @breed,
+  @age,
+  @sterilized,
+  @vaccinated,
+  @chipped,
+  @litter_ok,
+  @parser_version,
+  @explain,
+  @type_source,
+  @animal_source,
+  @when_from,
+  @when_to,
+  @when_precision,
+  @address,
+  @district,
+  @lat,
+  @lon,
+  @age_min_months,
+  @age_max_months,
+  @age_class,
+  @colors,
+  @coat,
+  @gear,
+  @marks,
+  @breed_id,
+  @breed_mixed,
+  @resolved,
+  @species,
+  @size,
+  @weight_min_kg,
+  @weight_max_kg,
+  @height_min_cm,
+  @height_max_cm,
+  @build,
+  @urgency,
+  @health,
+  @reward,
+  @reward_amount,
+  @scam_risk,
+  @role,
+  @role_source,
+  @repost
 )
 ON CONFLICT(owner_id, post_id) DO UPDATE SET
   date = excluded.date,
@@ -46,37 +126,210 @@ ON CONFLICT(owner_id, post_id) DO UPDATE SET
   name = excluded.name,
   location = excluded.location,
   "when" = excluded."when",
-  phones = excluded.phones,
   contact_names = excluded.contact_names,
-  vk_accounts = excluded.vk_accounts,
   photos = excluded.photos,
-  status_details = excluded.status_details;
+  status_details = excluded.status_details,
+  breed = excluded.breed,
+  age = excluded.age,
+  sterilized = excluded.sterilized,
+  vaccinated = excluded.vaccinated,
+  chipped = excluded.chipped,
+  litter_ok = excluded.litter_ok,
+  parser_version = excluded.parser_version,
+  explain = excluded.explain,
+  type_source = excluded.type_source,
+  animal_source = excluded.animal_source,
+  when_from = excluded.when_from,
+  when_to = excluded.when_to,
+  when_precision = excluded.when_precision,
+  address = excluded.address,
+  district = excluded.district,
+  lat = excluded.lat,
+  lon = excluded.lon,
+  age_min_months = excluded.age_min_months,
+  age_max_months = excluded.age_max_months,
+  age_class = excluded.age_class,
+  colors = excluded.colors,
+  coat = excluded.coat,
+  gear = excluded.gear,
+  marks = excluded.marks,
+  breed_id = excluded.breed_id,
+  breed_mixed = excluded.breed_mixed,
+  resolved = excluded.resolved,
+  species = excluded.species,
+  size = excluded.size,
+  weight_min_kg = excluded.weight_min_kg,
+  weight_max_kg = excluded.weight_max_kg,
+  height_min_cm = excluded.height_min_cm,
+  height_max_cm = excluded.height_max_cm,
+  build = excluded.build,
+  urgency = excluded.urgency,
+  health = excluded.health,
+  reward = excluded.reward,
+  reward_amount = excluded.reward_amount,
+  scam_risk = excluded.scam_risk,
+  role = excluded.role,
+  role_source = excluded.role_source,
+  repost = excluded.repost;
 
 -- name: ExistsPost :one
 SELECT EXISTS(
   SELECT 1 FROM posts WHERE owner_id = ?1 AND post_id = ?2
 );
 
+-- Animals of multi-animal posts
+
+-- name: DeletePostAnimals :exec
+DELETE FROM post_animals WHERE owner_id = ?1 AND post_id = ?2;
+
+-- name: InsertPostAnimal :exec
+INSERT INTO post_animals (
+  owner_id, post_id, idx, text, type, animal, sex, name,
+  breed, breed_id, age, age_min_months, age_max_months, phones, species
+) VALUES (
+  @owner_id, @post_id, @idx, @text, @type, @animal, @sex, @name,
+  @breed, @breed_id, @age, @age_min_months, @age_max_months, @phones, @species
+);
+
+-- name: ListPostAnimals :many
+SELECT owner_id, post_id, idx, text, type, animal, sex, name,
+       breed, breed_id, age, age_min_months, age_max_months, phones, species
+FROM post_animals
+WHERE owner_id = ?1 AND post_id = ?2
+ORDER BY idx;
+
+-- Contact channels
+
+-- name: DeletePostContacts :exec
+DELETE FROM post_contacts WHERE owner_id = ?1 AND post_id = ?2;
+
+-- name: InsertPostContact :exec
+INSERT INTO post_contacts (owner_id, post_id, idx, kind, value, name)
+VALUES (@owner_id, @post_id, @idx, @kind, @value, @name);
+
+-- name: ListPostContacts :many
+SELECT owner_id, post_id, idx, kind, value, name
+FROM post_contacts
+WHERE owner_id = ?1 AND post_id = ?2
+ORDER BY idx;
+
+-- name: PhoneHistory :one
+-- Distinct animals in other posts from [@since, @until) with the phone, and how
+-- many of them were up for adoption.
+SELECT COUNT(DISTINCT p.animal || '|' || p.sex || '|' || COALESCE(p.breed_id, '') || '|' || COALESCE(p.name, '')) AS cases,
+       COUNT(DISTINCT CASE WHEN p.type = 'adoption'
+         THEN p.animal || '|' || p.sex || '|' || COALESCE(p.breed_id, '') || '|' || COALESCE(p.name, '') END) AS adoptions
+FROM post_contacts c
+JOIN posts p ON p.owner_id = c.owner_id AND p.post_id = c.post_id
+WHERE c.kind = 'phone' AND c.value = @value AND p.date >= @since AND p.date < @until
+  AND p.type NOT IN ('empty', 'link')
+  AND NOT (c.owner_id = @owner_id AND c.post_id = @post_id);
+
+-- Identifiers and lost/found matches
+
+-- name: DeletePostIdentifiers :exec
+DELETE FROM post_identifiers WHERE owner_id = ?1 AND post_id = ?2;
+
+-- name: InsertPostIdentifier :exec
+INSERT INTO post_identifiers (owner_id, post_id, kind, value)
+VALUES (@owner_id, @post_id, @kind, @value)
+ON CONFLICT DO NOTHING;
+
+-- name: ListPostIdentifiers :many
+SELECT owner_id, post_id, kind, value
+FROM post_identifiers
+WHERE owner_id = ?1 AND post_id = ?2
+ORDER BY rowid;
+
+-- name: FindPostsByIdentifier :many
+-- Other posts carrying the same identifier, oldest first.
+SELECT p.owner_id, p.post_id, p.type
+FROM post_identifiers i
+JOIN posts p ON p.owner_id = i.owner_id AND p.post_id = i.post_id
+WHERE i.kind = @kind AND i.value = @value
+  AND NOT (i.owner_id = @owner_id AND i.post_id = @post_id)
+ORDER BY p.date, p.owner_id, p.post_id;
+
+-- name: InsertPostMatch :execrows
+INSERT INTO post_matches (lost_owner_id, lost_post_id, found_owner_id, found_post_id, kind, value)
+VALUES (@lost_owner_id, @lost_post_id, @found_owner_id, @found_post_id, @kind, @value)
+ON CONFLICT DO NOTHING;
+
+-- name: ListPostMatches :many
+SELECT lost_owner_id, lost_post_id, found_owner_id, found_post_id, kind, value, status, created_at
+FROM post_matches
+WHERE status = ?1
+ORDER BY created_at, lost_owner_id, lost_post_id, found_owner_id, found_post_id;
+
+-- name: SetPostMatchStatus :execrows
+UPDATE post_matches SET status = @status
+WHERE lost_owner_id = @lost_owner_id AND lost_post_id = @lost_post_id
+  AND found_owner_id = @found_owner_id AND found_post_id = @found_post_id;
+
 -- Outbox queries
 
 -- name: EnqueueOutbox :exec
-INSERT INTO outbox (owner_id, post_id)
-VALUES (@owner_id, @post_id)
-ON CONFLICT(owner_id, post_id) DO NOTHING;
+-- Re-enqueueing a pending post raises its priority if it became more urgent.
+INSERT INTO outbox (owner_id, post_id, priority)
+VALUES (@owner_id, @post_id, @priority)
+ON CONFLICT(owner_id, post_id) DO UPDATE SET priority = excluded.priority
+WHERE outbox.status = 'pending' AND excluded.priority > outbox.priority;
 
 -- name: GetPost :one
 SELECT owner_id, post_id, date, text, raw, type, animal, sex, name, location, "when",
-       phones, contact_names, vk_accounts, status_details, created_at
+       contact_names, photos, status_details,
+       breed, age, sterilized, vaccinated, chipped, litter_ok, parser_version,
+       type_source, animal_source,
+       when_from, when_to, when_precision,
+       address, district, lat, lon,
+       age_min_months, age_max_months, age_class,
+       colors, coat, gear, marks,
+       breed_id, breed_mixed,
+       resolved,
+       species,
+       size, weight_min_kg, weight_max_kg, height_min_cm, height_max_cm, build,
+       urgency, health,
+       reward, reward_amount, scam_risk,
+       role, role_source, repost, created_at
 FROM posts
 WHERE owner_id = ?1 AND post_id = ?2;
 
+-- name: GetPostExplain :one
+SELECT raw, date, parser_version, explain
+FROM posts
+WHERE owner_id = ?1 AND post_id = ?2;
+
+-- name: ListPostsPage :many
+-- Keyset-paginated scan over stored posts, used by the reparse command.
+SELECT owner_id, post_id, date, text, raw, type, animal, sex, name, location, "when",
+       contact_names, photos, status_details,
+       breed, age, sterilized, vaccinated, chipped, litter_ok, parser_version,
+       type_source, animal_source,
+       when_from, when_to, when_precision,
+       address, district, lat, lon,
+       age_min_months, age_max_months, age_class,
+       colors, coat, gear, marks,
+       breed_id, breed_mixed,
+       resolved,
+       species,
+       size, weight_min_kg, weight_max_kg, height_min_cm, height_max_cm, build,
+       urgency, health,
+       reward, reward_amount, scam_risk,
+       role, role_source, repost, created_at
+FROM posts
+WHERE (owner_id > @after_owner_id OR (owner_id = @after_owner_id AND post_id > @after_post_id))
+  AND date >= @date_from AND date < @date_to
+  AND (sqlc.narg('owner_id') IS NULL OR owner_id = sqlc.narg('owner_id'))
+ORDER BY owner_id, post_id
+LIMIT @limit;
+
 -- name: ClaimPendingMark :exec
 UPDATE outbox
 SET status='sending', leased_until=@lease, updated_at=CURRENT_TIMESTAMP
 WHERE id IN (
   SELECT id FROM outbox
   WHERE status='pending' AND (leased_until IS NULL OR leased_until < strftime('%s','now'))
-  ORDER BY created_at ASC
+  ORDER BY priority DESC, created_at ASC
   LIMIT @limit
 );
 
@@ -84,7 +337,7 @@ WHERE id IN (
 SELECT id, owner_id, post_id
 FROM outbox
 WHERE status='sending' AND leased_until=@lease
-ORDER BY created_at ASC;
+ORDER BY priority DESC, created_at ASC;
 
 -- name: MarkSent :exec
 UPDATE outbox
@@ -104,3 +357,41 @@ WHERE id=@id;
 UPDATE outbox
 SET status='pending', leased_until=NULL, updated_at=CURRENT_TIMESTAMP
 WHERE status='sending' AND leased_until < strftime('%s','now');
+
+-- Gold labels
+
+-- name: UpsertLabel :exec
+INSERT INTO labels (owner_id, post_id, raw, source, type, animal, sex, age_class, size, urgency, role, note)
+VALUES (@owner_id, @post_id, @raw, @source, @type, @animal, @sex, @age_class, @size, @urgency, @role, @note)
+ON CONFLICT(owner_id, post_id) DO UPDATE SET
+  raw = excluded.raw,
+  source = excluded.source,
+  type = excluded.type,
+  animal = excluded.animal,
+  sex = excluded.sex,
+  age_class = excluded.age_class,
+  size = excluded.size,
+  urgency = excluded.urgency,
+  role = excluded.role,
+  note = excluded.note,
+  updated_at = CURRENT_TIMESTAMP;
+
+-- name: ExistsLabel :one
+SELECT EXISTS(
+  SELECT 1 FROM labels WHERE owner_id = ?1 AND post_id = ?2
+);
+
+-- name: ListLabels :many
+SELECT owner_id, post_id, raw, source, type, animal, sex, age_class, size, urgency, role, note
+FROM labels
+ORDER BY owner_id, post_id;
+
+-- name: ListUnlabeledPosts :many
+-- Newest stored posts that have no gold label yet, optionally of one parsed type.
+SELECT p.owner_id, p.post_id, p.raw
+FROM posts p
+LEFT JOIN labels l ON l.owner_id = p.owner_id AND l.post_id = p.post_id
+WHERE l.post_id IS NULL
+  AND (sqlc.narg('type') IS NULL OR p.type = sqlc.narg('type'))
+ORDER BY p.date DESC
+LIMIT @limit;
@@ -0,0 +1,149 @@
+package lostdogs
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rewards and payment demands. Owners promise "вознаграждение 5000 ₽"; a
+// "finder" asking to be paid before returning the pet ("верну за 3000",
+// "переведите на карту за передержку") is a known scam, so such found posts
+// are flagged.
+
+// RewardSpec is the optional `reward` section of a rule set.
+type RewardSpec struct {
+	Patterns []string `yaml:"patterns"` // mentions of a reward
+	Payment  []string `yaml:"payment"`  // demands to pay for the pet's return
+}
+
+type rewardRules struct {
+	reward  *regexp.Regexp // whole words; nil when unset
+	payment *regexp.Regexp
+}
+
+func (c *compiler) reward(path string, spec RewardSpec) rewardRules {
+	return rewardRules{
+		reward:  c.optionalWords(path+".patterns", spec.Patterns),
+		payment: c.optionalWords(path+".payment", spec.Payment),
+	}
+}
+
+var (
+	// Money: "5000 ₽", "5 000 руб.", "10 тыс", "3т.р.". Group 1 is the
+	// number, group 2 the thousands multiplier, group 3 the currency; an
+	// amount needs one of the last two.
+	reMoney = regexp.MustCompile(`(?i)(?:^|[^\d\p{L}])(\d{1,3}(?:[ \x{00A0}]\d{3})+|\d{1,7})(?:[.,]\d+)?\s*(тыс\p{L}*\.?|т\.?\s*р\.?)?\s*(₽|руб\p{L}*\.?|р\.?|rub)?(?:[^\p{L}\d]|$)`)
+
+	// A bare amount right after the reward word: "вознаграждение: 5000",
+	// "награда в размере 10 тыс".
+	reAmountAfter = regexp.MustCompile(`(?i)^[\s:–-]*(?:в\s+размере\s+|до\s+|от\s+)?(\d{1,3}(?:[ \x{00A0}]\d{3})+|\d{1,7})\s*(тыс\p{L}*\.?|т\.?\s*р\.?|к)?(?:[^\p{L}\d]|$)`)
+)
+
+// rewardWindow is how far around a reward mention an amount is looked for.
+const rewardWindow = 80
+
+// Rewards are promised by owners; in a found post "вознаграждение" is either
+// declined ("вознаграждение не нужно") or demanded, which is payment.
+var rewardTypes = []PostType{TypeLost, TypeUnknown}
+
+// Post types whose payment demands are suspicious: a finder has no reason to
+// ask for money up front.
+var scamTypes = []PostType{TypeFound, TypeSighting, TypeUnknown}
+
+// extractReward reports whether the post offers a reward, its amount in
+// rubles (0 when not given) and whether it asks to pay for the pet's return.
+func (sc *scan) extractReward(t PostType) (reward bool, amount int, scam bool) {
+	rr := sc.rules.reward
+	if rr.reward != nil && slices.Contains(rewardTypes, t) {
+		if m := sc.firstAffirmed(rr.reward); m != nil {
+			reward = true
+			sc.record("reward", "reward", m[2], m[3])
+			amount = sc.rewardAmount(m[2], m[3])
+		}
+	}
+	if rr.payment != nil && slices.Contains(scamTypes, t) {
+		if m := sc.firstAffirmed(rr.payment); m != nil {
+			scam = true
+			sc.record("scam_risk", "payment", m[2], m[3])
+			sc.decide("scam_risk", "payment", 0.7)
+		}
+	}
+	return reward, amount, scam
+}
+
+// rewardAmount finds the amount promised by the reward mention at
+// [start, end): a bare number right after it, else money next to it.
+func (sc *scan) rewardAmount(start, end int) int {
+	if m := reAmountAfter.FindStringSubmatchIndex(sc.s[end:]); m != nil {
+		if v := parseMoney(group(sc.s[end:], m, 2), group(sc.s[end:], m, 4)); v > 0 {
+			sc.record("reward", "amount", end+m[2], end+amountEnd(m))
+			return v
+		}
+	}
+	from, to := max(0, start-rewardWindow), min(len(sc.s), end+rewardWindow)
+	for from > 0 && !utf8.RuneStart(sc.s[from]) {
+		from--
+	}
+	for to < len(sc.s) && !utf8.RuneStart(sc.s[to]) {
+		to++
+	}
+	best, dist, at := 0, len(sc.s), [2]int{}
+	for _, m := range reMoney.FindAllStringSubmatchIndex(sc.s[from:to], -1) {
+		if m[4] < 0 && m[6] < 0 {
+			continue // a bare number: a date, a house, a phone part
+		}
+		v := parseMoney(group(sc.s[from:to], m, 2), group(sc.s[from:to], m, 4))
+		if v == 0 {
+			continue
+		}
+		d := from + m[2] - end
+		if d < 0 {
+			d = start - (from + m[3])
+		}
+		if d < dist {
+			best, dist, at = v, d, [2]int{from + m[2], from + amountEnd(m)}
+		}
+	}
+	if best > 0 {
+		sc.record("reward", "amount", at[0], at[1])
+	}
+	return best
+}
+
+// amountEnd is where an amount match ends, including its multiplier and
+// currency.
+func amountEnd(m []int) int {
+	end := m[3]
+	for i := 5; i < len(m); i += 2 {
+		end = max(end, m[i])
+	}
+	return end
+}
+
+// parseMoney parses "5 000" with an optional thousands word; 0 when the
+// amount is not a plausible reward.
+func parseMoney(num, mult string) int {
+	v, err := strconv.Atoi(strings.NewReplacer(" ", "", " ", "").Replace(num))
+	if err != nil {
+		return 0
+	}
+	if mult != "" {
+		v *= 1000
+	}
+	if v < 100 || v > 10_000_000 {
+		return 0
+	}
+	return v
+}
+
+// rewardText is the reward for explanations: "5000", "true" without an
+// amount, "false" without a reward.
+func rewardText(p Post) string {
+	if p.RewardAmount > 0 {
+		return strconv.Itoa(p.RewardAmount)
+	}
+	return boolStr(p.Reward)
+}
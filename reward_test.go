@@ -0,0 +1,37 @@
+package lostdogs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_Reward(t *testing.T) {
+	cases := []struct {
+		text   string
+		reward bool
+		amount int
+		scam   bool
+	}{
+		{text: "Пропала собака, рыжий кобель. Вознаграждение 5000 ₽. 89120281683", reward: true, amount: 5000},
+		{text: "Пропала собака, 10 тыс руб вознаграждение нашедшему", reward: true, amount: 10000},
+		{text: "Вознаграждение: 15 000, пропал пёс в районе Буммаша", reward: true, amount: 15000},
+		{text: "Потерялся кобель у вокзала, нашедшему гарантирую вознаграждение 10 т. р!!! 89501677061", reward: true, amount: 10000},
+		{text: "Пропал кот! Гарантируем вознаграждение! Звоните 89120281683", reward: true},
+		{text: "Пропал пёс 12 мая 2025, ул Ленина 5, вознаграждение", reward: true},
+		{text: "Найдена собака, ищем хозяина, вознаграждение не нужно"},
+		{text: "Найдена собака, верну за 3000 рублей, пишите в личку", scam: true},
+		{text: "Найдена кошка, переведите деньги на карту за передержку и заберёте", scam: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.text, func(t *testing.T) {
+			p, ex := ParseExplain(1, tc.text)
+			assert.Equal(t, tc.reward, p.Reward)
+			assert.Equal(t, tc.amount, p.RewardAmount)
+			assert.Equal(t, tc.scam, p.ScamRisk)
+			if tc.amount > 0 {
+				assert.Len(t, ex.Field("reward").Matches, 2)
+			}
+		})
+	}
+}
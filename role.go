@@ -0,0 +1,126 @@
+package lostdogs
+
+// Role is who wrote the post; empty for empty and link-only posts. An owner's
+// lost post and a volunteer's repost of it read alike, but only the owner
+// knows the dog; a shelter's phone is not a finder's.
+type Role string
+
+const (
+	RoleUnknown   Role = "unknown"
+	RoleOwner     Role = "owner"
+	RoleFinder    Role = "finder"
+	RoleVolunteer Role = "volunteer" // posts someone else's case
+	RoleShelter   Role = "shelter"   // a shelter or fund posting its animals
+)
+
+var roleLabels = []string{string(RoleOwner), string(RoleFinder), string(RoleVolunteer), string(RoleShelter)}
+
+// RoleSource is what decided the role.
+type RoleSource string
+
+const (
+	RoleFromText    RoleSource = "text"    // a cue in the text, see the `role` rules
+	RoleFromType    RoleSource = "type"    // the post type: lost by the owner, found by the finder
+	RoleFromRepost  RoleSource = "repost"  // the post shares another one
+	RoleFromHistory RoleSource = "history" // the phone is in posts about other animals
+)
+
+// Post types whose role follows from the type when the text has no cue.
+var roleByType = map[PostType]Role{
+	TypeLost:     RoleOwner,
+	TypeFound:    RoleFinder,
+	TypeSighting: RoleFinder,
+}
+
+// detectRole returns the role the text implies. Shelter and volunteer cues
+// win, since shelters and volunteers also write "наша собака"; between owner
+// and finder cues the one agreeing with the type wins, else the first.
+func (sc *scan) detectRole(t PostType) (Role, RoleSource) {
+	at := map[Role]int{}
+	for _, r := range sc.rules.role {
+		if m := sc.firstAffirmed(r.re); m != nil {
+			sc.record("role", r.name, m[2], m[3])
+			at[Role(r.name)] = m[2]
+		}
+	}
+	pick := func(r Role) (Role, RoleSource) {
+		sc.decide("role", string(r), 0.7)
+		return r, RoleFromText
+	}
+	for _, r := range []Role{RoleShelter, RoleVolunteer} {
+		if _, ok := at[r]; ok {
+			return pick(r)
+		}
+	}
+	byType, typed := roleByType[t]
+	if _, ok := at[byType]; typed && ok {
+		return pick(byType)
+	}
+	first, firstAt := RoleUnknown, -1
+	for r, i := range at {
+		if firstAt < 0 || i < firstAt {
+			first, firstAt = r, i
+		}
+	}
+	if first != RoleUnknown {
+		return pick(first)
+	}
+	if typed {
+		sc.decide("role", "type", 0.5)
+		return byType, RoleFromType
+	}
+	return RoleUnknown, ""
+}
+
+// PosterInfo is what is known about the poster beyond the text.
+type PosterInfo struct {
+	Repost    bool // the post shares another one (VK copy_history)
+	Cases     int  // distinct animals in other recent posts with one of the post's phones
+	Adoptions int  // of them, animals put up for adoption
+}
+
+// A phone in posts about this many other animals is a volunteer's; when most
+// of them are up for adoption, a shelter's.
+const (
+	volunteerMinCases = 3
+	shelterMinCases   = 3
+)
+
+// PosterRole refines the role read from the text with what is known about the
+// poster. A shelter cue in the text stands; a phone mostly seen in adoption
+// posts makes a shelter; a repost or a phone seen with other animals makes a
+// volunteer of an apparent owner or finder.
+func PosterRole(p Post, info PosterInfo) (Role, RoleSource) {
+	if p.Role == RoleShelter || p.Type == TypeEmpty || p.Type == TypeLink {
+		return p.Role, p.RoleSource
+	}
+	switch {
+	case info.Adoptions >= shelterMinCases && info.Adoptions*2 >= info.Cases:
+		return RoleShelter, RoleFromHistory
+	case p.Role == RoleVolunteer:
+		return p.Role, p.RoleSource
+	case info.Repost:
+		return RoleVolunteer, RoleFromRepost
+	case info.Cases >= volunteerMinCases:
+		return RoleVolunteer, RoleFromHistory
+	}
+	return p.Role, p.RoleSource
+}
+
+// posterRoleConfidence is the confidence of a role PosterRole changed.
+const posterRoleConfidence = 0.6
+
+// SetPosterRole sets the role of p to what PosterRole settles on. When that
+// differs from the role read from the text, the role field of ex follows,
+// with the source ("repost", "history") as its rule, so the stored
+// explanation explains the stored role.
+func SetPosterRole(p *Post, ex *Explanation, info PosterInfo) {
+	role, source := PosterRole(*p, info)
+	if role == p.Role && source == p.RoleSource {
+		return
+	}
+	p.Role, p.RoleSource = role, source
+	if fe := ex.Field("role"); fe != nil {
+		fe.Value, fe.Rule, fe.Confidence = string(role), string(source), posterRoleConfidence
+	}
+}
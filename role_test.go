@@ -0,0 +1,84 @@
+package lostdogs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Role(t *testing.T) {
+	cases := []struct {
+		text   string
+		role   Role
+		source RoleSource
+	}{
+		{text: "Пропала наша собака, рыжий кобель, район Буммаш. 89120281683", role: RoleOwner, source: RoleFromText},
+		{text: "Помогите! У нас убежал кот с Пушкинской, серый, 89120281683", role: RoleOwner, source: RoleFromText},
+		{text: "Пропал пёс, рыжий кобель, район Буммаша, 89120281683", role: RoleOwner, source: RoleFromType},
+		{text: "Нашли собаку у остановки Буммаш, рыжий кобель, ищем хозяев", role: RoleFinder, source: RoleFromText},
+		{text: "Найден кот на Пушкинской, сейчас у меня находится, 89120281683", role: RoleFinder, source: RoleFromText},
+		{text: "Найдена собака у вокзала, рыжая сука, 89120281683", role: RoleFinder, source: RoleFromType},
+		{text: "Пропала моя собака у вокзала, рыжая. Кто нашёл, звоните 89120281683", role: RoleOwner, source: RoleFromText},
+		{text: "Пишу по просьбе хозяйки: пропала собака, рыжая сука, 89120281683", role: RoleVolunteer, source: RoleFromText},
+		{text: "Пропала собака у вокзала, хозяева очень ждут, 89120281683", role: RoleVolunteer, source: RoleFromText},
+		{text: "Наша подопечная Луна ищет дом, стерилизована, привита, 89120281683", role: RoleShelter, source: RoleFromText},
+		{text: "Приют Ковчег ищет хозяев для щенков, привиты, 89120281683", role: RoleShelter, source: RoleFromText},
+		{text: "Приют «Верный друг» пристраивает собак, стерилизованы, 89120281683", role: RoleShelter, source: RoleFromText},
+		{text: "Благотворительный фонд собирает на лечение сбитой собаки, карта 89120281683", role: RoleShelter, source: RoleFromText},
+		{text: "Фонд помощи бездомным животным: пёс Рекс ищет дом, 89120281683", role: RoleShelter, source: RoleFromText},
+		{text: "Нашли собаку у вокзала, отвезли в приют, хозяева отзовитесь", role: RoleFinder, source: RoleFromText},
+		{text: "Отдам в добрые руки котят, 2 месяца, к лотку приучены", role: RoleUnknown},
+	}
+	for _, tc := range cases {
+		t.Run(tc.text, func(t *testing.T) {
+			p := Parse(1, tc.text)
+			assert.Equal(t, tc.role, p.Role)
+			assert.Equal(t, tc.source, p.RoleSource)
+		})
+	}
+	assert.Empty(t, Parse(1, "https://vk.com/wall-1_2").Role)
+}
+
+func TestPosterRole(t *testing.T) {
+	owner := Parse(1, "Пропала наша собака, рыжий кобель, район Буммаш. 89120281683")
+	shelter := Parse(1, "Наша подопечная Луна ищет дом, стерилизована, привита, 89120281683")
+	cases := []struct {
+		name   string
+		p      Post
+		info   PosterInfo
+		role   Role
+		source RoleSource
+	}{
+		{name: "no history", p: owner, role: RoleOwner, source: RoleFromText},
+		{name: "one other case", p: owner, info: PosterInfo{Cases: 1}, role: RoleOwner, source: RoleFromText},
+		{name: "repost", p: owner, info: PosterInfo{Repost: true}, role: RoleVolunteer, source: RoleFromRepost},
+		{name: "many cases", p: owner, info: PosterInfo{Cases: 4, Adoptions: 1}, role: RoleVolunteer, source: RoleFromHistory},
+		{name: "many adoptions", p: owner, info: PosterInfo{Cases: 5, Adoptions: 3}, role: RoleShelter, source: RoleFromHistory},
+		{name: "shelter text", p: shelter, info: PosterInfo{Repost: true}, role: RoleShelter, source: RoleFromText},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			role, source := PosterRole(tc.p, tc.info)
+			assert.Equal(t, tc.role, role)
+			assert.Equal(t, tc.source, source)
+		})
+	}
+}
+
+func TestSetPosterRole(t *testing.T) {
+	text := "Пропала наша собака, рыжий кобель, район Буммаш. 89120281683"
+	p, ex := ParseExplain(1, text)
+	require.Equal(t, RoleOwner, p.Role)
+
+	SetPosterRole(&p, &ex, PosterInfo{Cases: 1})
+	assert.Equal(t, RoleOwner, p.Role)
+	assert.Equal(t, "owner", ex.Field("role").Value, "an unchanged role keeps its explanation")
+	assert.NotEqual(t, "history", ex.Field("role").Rule)
+
+	SetPosterRole(&p, &ex, PosterInfo{Repost: true})
+	assert.Equal(t, RoleVolunteer, p.Role)
+	assert.Equal(t, RoleFromRepost, p.RoleSource)
+	assert.Equal(t, "volunteer", ex.Field("role").Value)
+	assert.Equal(t, "repost", ex.Field("role").Rule)
+}
@@ -0,0 +1,455 @@
+package lostdogs
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	yaml "github.com/goccy/go-yaml"
+	"github.com/jehaby/lostdogs/internal/ru"
+)
+
+//go:embed resources/rules/default.yml
+var defaultRulesYAML []byte
+
+// RuleSpec is the YAML form of a rule set; see resources/rules/default.yml.
+type RuleSpec struct {
+	Version  string    `yaml:"version"`
+	Type     ClassSpec `yaml:"type"`
+	Animal   ClassSpec `yaml:"animal"`
+	Sex      ClassSpec `yaml:"sex"`
+	Location struct {
+		Triggers []string `yaml:"triggers"`
+	} `yaml:"location"`
+	Extras struct {
+		Sterilized []string `yaml:"sterilized"`
+		Vaccinated []string `yaml:"vaccinated"`
+		Chipped    []string `yaml:"chipped"`
+		LitterOK   []string `yaml:"litter_ok"`
+	} `yaml:"extras"`
+	Status     PatternsSpec    `yaml:"status"`
+	Resolved   PatternsSpec    `yaml:"resolved"`
+	Appearance AppearanceSpec  `yaml:"appearance"`
+	Species    []NamedPatterns `yaml:"species"`
+	Size       SizeSpec        `yaml:"size"`
+	Urgency    UrgencySpec     `yaml:"urgency"`
+	Reward     RewardSpec      `yaml:"reward"`
+	Contacts   ContactsSpec    `yaml:"contacts"`
+	Role       []NamedPatterns `yaml:"role"`
+
+	TypeContext TypeContextSpec `yaml:"type_context"`
+
+	// Breed is ignored: breeds come from the catalogue in
+	// resources/breeds/breeds.yml. Older rule files with a breed section still
+	// load, with a warning; see Rules.Warnings.
+	Breed any `yaml:"breed"`
+}
+
+// ClassSpec declares how one enum field (type, animal, sex) is decided.
+type ClassSpec struct {
+	Priority []string    `yaml:"priority"`
+	Rules    []LabelRule `yaml:"rules"`
+	Ties     []TieRule   `yaml:"ties"`
+}
+
+// LabelRule votes for Label when any of Patterns or Lemmas matches. Lemmas
+// are words or phrases matched by stem, so "пропал" also covers "пропала" and
+// "пропавший"; see internal/ru. Weak rules lower the confidence when they are
+// the only vote for their label.
+type LabelRule struct {
+	Name     string   `yaml:"name"`
+	Label    string   `yaml:"label"`
+	Weak     bool     `yaml:"weak"`
+	Patterns []string `yaml:"patterns"`
+	Lemmas   []string `yaml:"lemmas"`
+}
+
+// TieRule resolves the case when all Labels got votes: Then if Pattern
+// matches, Otherwise if not.
+type TieRule struct {
+	Name      string   `yaml:"name"`
+	Labels    []string `yaml:"labels"`
+	Pattern   string   `yaml:"pattern"`
+	Then      string   `yaml:"then"`
+	Otherwise string   `yaml:"otherwise"`
+}
+
+type PatternsSpec struct {
+	Patterns []string `yaml:"patterns"`
+}
+
+// Rules is a validated, compiled rule set. It is immutable and safe for
+// concurrent use.
+type Rules struct {
+	version string
+
+	typ    classRules
+	animal classRules
+	sex    classRules
+
+	locTrigger *regexp.Regexp
+	sterilized *regexp.Regexp
+	vaccinated *regexp.Regexp
+	chipped    *regexp.Regexp
+	litter     *regexp.Regexp
+	status     *regexp.Regexp
+	resolved   *regexp.Regexp // nil when the rule set has no resolved section
+
+	appearance appearanceRules
+	species    []namedRule
+	size       sizeRules
+	urgency    urgencyRules
+	reward     rewardRules
+	contacts   contactRules
+	role       []namedRule
+	context    typeContext
+
+	regexps  []*regexp.Regexp // all of the above, for the keyword index
+	warnings []string         // see Warnings
+}
+
+type classRules struct {
+	priority []string
+	rules    []labelRule
+	ties     []tieRule
+}
+
+type labelRule struct {
+	name   string
+	label  string
+	weak   bool
+	re     *regexp.Regexp // nil when the rule has only lemmas
+	lemmas [][]string     // stem sequences
+}
+
+type tieRule struct {
+	name      string
+	labels    []string
+	re        *regexp.Regexp
+	then      string
+	otherwise string
+}
+
+var defaultRules = MustLoadRules(defaultRulesYAML)
+
+// DefaultRules returns the rule set embedded from resources/rules/default.yml.
+func DefaultRules() *Rules { return defaultRules }
+
+// DefaultRulesYAML returns the embedded default rules file, e.g. as a starting
+// point for a custom RULES_FILE.
+func DefaultRulesYAML() []byte { return slices.Clone(defaultRulesYAML) }
+
+// MustLoadRules is LoadRules that panics on error.
+func MustLoadRules(b []byte) *Rules {
+	r, err := LoadRules(b)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// LoadRules parses and validates a YAML rule set. All problems are reported
+// at once, each prefixed with its path in the document.
+func LoadRules(b []byte) (*Rules, error) {
+	var spec RuleSpec
+	if err := yaml.UnmarshalWithOptions(b, &spec, yaml.DisallowUnknownField()); err != nil {
+		return nil, fmt.Errorf("rules: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return compileRules(spec, hex.EncodeToString(sum[:4]))
+}
+
+// Version identifies the rule set: the declared version plus a short content
+// hash, so an edited file is distinguishable even if the version wasn't bumped.
+func (r *Rules) Version() string { return r.version }
+
+// Warnings lists the deprecated sections of the rule set, which were ignored.
+func (r *Rules) Warnings() []string { return r.warnings }
+
+func compileRules(spec RuleSpec, hash string) (*Rules, error) {
+	c := &compiler{}
+	if strings.TrimSpace(spec.Version) == "" {
+		c.errorf("version", "must be set")
+	}
+	r := &Rules{
+		version:    spec.Version + "-" + hash,
+		typ:        c.class("type", spec.Type, postTypeLabels),
+		animal:     c.class("animal", spec.Animal, animalLabels),
+		sex:        c.class("sex", spec.Sex, sexLabels),
+		locTrigger: c.patterns("location.triggers", spec.Location.Triggers),
+		sterilized: c.patterns("extras.sterilized", spec.Extras.Sterilized),
+		vaccinated: c.patterns("extras.vaccinated", spec.Extras.Vaccinated),
+		chipped:    c.patterns("extras.chipped", spec.Extras.Chipped),
+		litter:     c.patterns("extras.litter_ok", spec.Extras.LitterOK),
+		status:     c.patterns("status.patterns", spec.Status.Patterns),
+		resolved:   c.optional("resolved.patterns", spec.Resolved.Patterns),
+		appearance: c.appearance("appearance", spec.Appearance),
+		species:    c.named("species", spec.Species, nil),
+		size:       c.size("size", spec.Size),
+		urgency:    c.urgency("urgency", spec.Urgency),
+		reward:     c.reward("reward", spec.Reward),
+		contacts:   c.contacts("contacts", spec.Contacts),
+		role:       c.named("role", spec.Role, roleLabels),
+		context:    c.typeContext("type_context", spec.TypeContext),
+	}
+	if len(c.errs) > 0 {
+		return nil, fmt.Errorf("rules: %w", errors.Join(c.errs...))
+	}
+	r.regexps = c.regexps
+	if spec.Breed != nil {
+		r.warnings = append(r.warnings, "breed: deprecated and ignored; breeds come from resources/breeds/breeds.yml")
+	}
+	return r, nil
+}
+
+// Labels a rule may vote for. Unknown/empty/link are decided by Parse itself.
+var (
+	postTypeLabels = []string{string(TypeLost), string(TypeFound), string(TypeSighting), string(TypeAdoption), string(TypeFundraising), string(TypeNews)}
+	animalLabels   = []string{string(AnimalCat), string(AnimalDog), string(AnimalOther)}
+	sexLabels      = []string{string(SexM), string(SexF)}
+)
+
+type compiler struct {
+	errs    []error
+	regexps []*regexp.Regexp // everything compiled, see compile
+}
+
+func (c *compiler) errorf(path, format string, args ...any) {
+	c.errs = append(c.errs, fmt.Errorf("%s: "+format, append([]any{path}, args...)...))
+}
+
+// patterns compiles a list of alternatives into one case-insensitive regexp.
+func (c *compiler) patterns(path string, ps []string) *regexp.Regexp {
+	if !c.valid(path, ps) {
+		return nil
+	}
+	return c.compile(`(?i)(?:` + strings.Join(ps, `|`) + `)`)
+}
+
+// valid reports whether ps is a non-empty list of valid patterns.
+func (c *compiler) valid(path string, ps []string) bool {
+	if len(ps) == 0 {
+		c.errorf(path, "at least one pattern is required")
+		return false
+	}
+	ok := true
+	for i, p := range ps {
+		if strings.TrimSpace(p) == "" {
+			c.errorf(fmt.Sprintf("%s[%d]", path, i), "empty pattern")
+			ok = false
+			continue
+		}
+		if _, err := regexp.Compile(p); err != nil {
+			c.errorf(fmt.Sprintf("%s[%d]", path, i), "%v", err)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// compile compiles a validated expression and keeps it for the keyword
+// index, see keywords.go.
+func (c *compiler) compile(expr string) *regexp.Regexp {
+	re := regexp.MustCompile(expr)
+	c.regexps = append(c.regexps, re)
+	return re
+}
+
+// optional is patterns for a section older rule files may lack; it returns
+// nil when ps is empty.
+func (c *compiler) optional(path string, ps []string) *regexp.Regexp {
+	if len(ps) == 0 {
+		return nil
+	}
+	return c.patterns(path, ps)
+}
+
+// lemmas stems every word of each phrase.
+func (c *compiler) lemmas(path string, ls []string) [][]string {
+	var out [][]string
+	for i, l := range ls {
+		st := ru.Stems(l)
+		if len(st) == 0 {
+			c.errorf(fmt.Sprintf("%s[%d]", path, i), "no words in %q", l)
+			continue
+		}
+		out = append(out, st)
+	}
+	return out
+}
+
+// words is patterns matched as whole words; group 1 is the mention.
+func (c *compiler) words(path string, ps []string) *regexp.Regexp {
+	if !c.valid(path, ps) {
+		return nil
+	}
+	return c.compile(`(?i)(?:^|[^\p{L}\d])(` + strings.Join(ps, `|`) + `)(?:[^\p{L}]|$)`)
+}
+
+func (c *compiler) class(path string, spec ClassSpec, allowed []string) classRules {
+	cr := classRules{priority: spec.Priority}
+	if len(spec.Rules) == 0 {
+		c.errorf(path+".rules", "at least one rule is required")
+	}
+	label := func(p, l string) {
+		if !slices.Contains(allowed, l) {
+			c.errorf(p, "unknown label %q (allowed: %s)", l, strings.Join(allowed, ", "))
+		}
+	}
+
+	names := map[string]bool{}
+	used := map[string]bool{}
+	for i, rs := range spec.Rules {
+		p := fmt.Sprintf("%s.rules[%d]", path, i)
+		if rs.Name == "" {
+			c.errorf(p+".name", "must be set")
+		} else if names[rs.Name] {
+			c.errorf(p+".name", "duplicate rule name %q", rs.Name)
+		}
+		names[rs.Name] = true
+		label(p+".label", rs.Label)
+		used[rs.Label] = true
+		lr := labelRule{name: rs.Name, label: rs.Label, weak: rs.Weak}
+		if len(rs.Patterns) > 0 || len(rs.Lemmas) == 0 {
+			lr.re = c.patterns(p+".patterns", rs.Patterns)
+		}
+		lr.lemmas = c.lemmas(p+".lemmas", rs.Lemmas)
+		cr.rules = append(cr.rules, lr)
+	}
+
+	seen := map[string]bool{}
+	for i, l := range spec.Priority {
+		p := fmt.Sprintf("%s.priority[%d]", path, i)
+		label(p, l)
+		if seen[l] {
+			c.errorf(p, "duplicate label %q", l)
+		}
+		seen[l] = true
+	}
+	for l := range used {
+		if !seen[l] {
+			c.errorf(path+".priority", "label %q is used by a rule but has no priority", l)
+		}
+	}
+
+	for i, ts := range spec.Ties {
+		p := fmt.Sprintf("%s.ties[%d]", path, i)
+		if ts.Name == "" {
+			c.errorf(p+".name", "must be set")
+		} else if names[ts.Name] {
+			c.errorf(p+".name", "duplicate rule name %q", ts.Name)
+		}
+		names[ts.Name] = true
+		if len(ts.Labels) < 2 {
+			c.errorf(p+".labels", "a tie needs at least two labels")
+		}
+		for j, l := range ts.Labels {
+			label(fmt.Sprintf("%s.labels[%d]", p, j), l)
+		}
+		if !slices.Contains(ts.Labels, ts.Then) {
+			c.errorf(p+".then", "%q is not one of the tied labels", ts.Then)
+		}
+		if !slices.Contains(ts.Labels, ts.Otherwise) {
+			c.errorf(p+".otherwise", "%q is not one of the tied labels", ts.Otherwise)
+		}
+		cr.ties = append(cr.ties, tieRule{
+			name:      ts.Name,
+			labels:    ts.Labels,
+			re:        c.patterns(p+".pattern", []string{ts.Pattern}),
+			then:      ts.Then,
+			otherwise: ts.Otherwise,
+		})
+	}
+	return cr
+}
+
+// classify runs every rule of cr against the scan text, records the hits under
+// field and returns the decided label ("" when nothing matched).
+func (sc *scan) classify(field string, cr classRules) string {
+	return sc.pick(field, cr, sc.vote(field, cr))
+}
+
+// ballot is the outcome of running the rules of one field.
+type ballot struct {
+	votes  map[string]bool      // label -> any rule matched
+	strong map[string]bool      // label -> a non-weak rule matched
+	first  map[string]string    // label -> first matching rule name
+	hits   map[string][]ruleHit // label -> every hit, in rule order
+}
+
+type ruleHit struct {
+	start int
+	weak  bool
+}
+
+func (sc *scan) vote(field string, cr classRules) ballot {
+	b := ballot{votes: map[string]bool{}, strong: map[string]bool{}, first: map[string]string{}, hits: map[string][]ruleHit{}}
+	for _, r := range cr.rules {
+		starts := sc.matchRule(field, r)
+		if len(starts) == 0 {
+			continue
+		}
+		for _, st := range starts {
+			b.hits[r.label] = append(b.hits[r.label], ruleHit{start: st, weak: r.weak})
+		}
+		b.votes[r.label] = true
+		if !r.weak && !b.strong[r.label] {
+			b.strong[r.label] = true
+			b.first[r.label] = r.name
+		}
+		if _, ok := b.first[r.label]; !ok {
+			b.first[r.label] = r.name
+		}
+	}
+	return b
+}
+
+// pick applies the ties of cr, then its priority, to a ballot.
+func (sc *scan) pick(field string, cr classRules, b ballot) string {
+	if len(b.votes) == 0 {
+		return ""
+	}
+
+	for _, t := range cr.ties {
+		if !allVoted(b.votes, t.labels) {
+			continue
+		}
+		if sc.find(field, t.name, t.re) {
+			sc.decide(field, t.name, 0.6)
+			return t.then
+		}
+		sc.decide(field, t.name, 0.5)
+		return t.otherwise
+	}
+
+	// A single label is a clearer signal than a priority pick.
+	conf := 0.9
+	if len(b.votes) > 1 {
+		conf = 0.7
+	}
+	for _, l := range cr.priority {
+		if !b.votes[l] {
+			continue
+		}
+		if !b.strong[l] {
+			conf -= 0.2
+		}
+		sc.decide(field, b.first[l], conf)
+		return l
+	}
+	return ""
+}
+
+func allVoted(votes map[string]bool, labels []string) bool {
+	for _, l := range labels {
+		if !votes[l] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package lostdogs
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	yaml "github.com/goccy/go-yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultRules(t *testing.T) {
+	r, err := LoadRules(DefaultRulesYAML())
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRules().Version(), r.Version())
+	var spec struct {
+		Version string `yaml:"version"`
+	}
+	require.NoError(t, yaml.Unmarshal(DefaultRulesYAML(), &spec))
+	require.NotEmpty(t, spec.Version)
+	assert.Regexp(t, `^`+regexp.QuoteMeta(spec.Version)+`-[0-9a-f]+$`, r.Version())
+	assert.Equal(t, ParserVersion+"+"+r.Version(), Parse(0, "Пропала собака на улице Ленина").ParserVersion)
+}
+
+func TestLoadRules_CustomVocabulary(t *testing.T) {
+	text := "Потеряшка! Рыжий пёс у ТЦ Италмас, звоните"
+	require.Equal(t, TypeUnknown, Parse(0, text).Type)
+
+	y := strings.Replace(string(DefaultRulesYAML()),
+		`lemmas: [пропал,`,
+		`lemmas: [потеряшка, пропал,`, 1)
+	r, err := LoadRules([]byte(y))
+	require.NoError(t, err)
+	assert.NotEqual(t, DefaultRules().Version(), r.Version(), "edited rules must get a new version")
+
+	p, ex := NewParser(r).ParseExplain(0, text)
+	assert.Equal(t, TypeLost, p.Type)
+	assert.Equal(t, "lost", ex.Field("type").Rule)
+}
+
+func TestLoadRules_DeprecatedBreed(t *testing.T) {
+	assert.Empty(t, DefaultRules().Warnings())
+
+	// Rule files from before the breed catalogue still load.
+	y := string(DefaultRulesYAML()) + "\nbreed:\n  patterns: ['хаски', 'такс\\p{L}*']\n"
+	r, err := LoadRules([]byte(y))
+	require.NoError(t, err)
+	require.Len(t, r.Warnings(), 1)
+	assert.Contains(t, r.Warnings()[0], "breed")
+	assert.Equal(t, "husky", NewParser(r).Parse(0, "Пропала собака, хаски, голубые глаза").BreedID)
+}
+
+func TestLoadRules_Validation(t *testing.T) {
+	cases := []struct {
+		name    string
+		yaml    string
+		wantErr []string
+	}{
+		{
+			name:    "unknown field",
+			yaml:    "version: '1'\nbogus: true\n",
+			wantErr: []string{"bogus"},
+		},
+		{
+			name: "bad regexp and label",
+			yaml: `
+version: "1"
+type:
+  priority: [lost]
+  rules:
+    - {name: lost, label: lost, patterns: ['пропал(']}
+    - {name: gone, label: gone, patterns: ['ушел']}
+animal:
+  priority: [cat]
+  rules: [{name: cat, label: cat, patterns: ['кот']}]
+sex:
+  priority: [m]
+  rules: [{name: male, label: m, patterns: ['мальчик']}]
+location: {triggers: ['улиц']}
+extras: {sterilized: [a], vaccinated: [b], chipped: [c], litter_ok: [d]}
+status: {patterns: ['рыж']}
+`,
+			wantErr: []string{
+				"type.rules[0].patterns[0]: error parsing regexp",
+				`type.rules[1].label: unknown label "gone"`,
+				`type.priority: label "gone" is used by a rule but has no priority`,
+			},
+		},
+		{
+			name: "missing sections",
+			yaml: "version: ''\n",
+			wantErr: []string{
+				"version: must be set",
+				"type.rules: at least one rule is required",
+				"location.triggers: at least one pattern is required",
+			},
+		},
+		{
+			name:    "lemma without words",
+			yaml:    "version: '1'\ntype:\n  priority: [lost]\n  rules: [{name: lost, label: lost, lemmas: ['!!']}]\n",
+			wantErr: []string{`type.rules[0].lemmas[0]: no words in "!!"`},
+		},
+		{
+			name:    "context words",
+			yaml:    "version: '1'\ntype:\n  priority: [lost]\n  rules: [{name: lost, label: lost, patterns: ['пропал']}]\ntype_context:\n  negation: ['не', 'пока не']\n  owner: ['(']\n",
+			wantErr: []string{`type_context.negation[1]: "пока не" is not a single word`, "type_context.owner[0]: error parsing regexp"},
+		},
+		{
+			name: "tie referencing other labels",
+			yaml: `
+version: "1"
+type:
+  priority: [lost, found]
+  rules:
+    - {name: lost, label: lost, patterns: ['пропал']}
+    - {name: found, label: found, patterns: ['найден']}
+  ties:
+    - {name: lost, labels: [found], pattern: 'x', then: sighting, otherwise: lost}
+`,
+			wantErr: []string{
+				`type.ties[0].name: duplicate rule name "lost"`,
+				"type.ties[0].labels: a tie needs at least two labels",
+				`type.ties[0].then: "sighting" is not one of the tied labels`,
+				`type.ties[0].otherwise: "lost" is not one of the tied labels`,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadRules([]byte(tc.yaml))
+			require.Error(t, err)
+			for _, want := range tc.wantErr {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
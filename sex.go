@@ -0,0 +1,174 @@
+package lostdogs
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Morphological sex signals. Explicit words from the rule set ("кобелек",
+// "девочка") always win; otherwise the animal noun (кот/кошка) and words that
+// agree with it (пропал/пропала, найден/найдена, ласковый/ласковая) vote.
+//
+// Epicene nouns have a fixed grammatical gender whatever the animal's sex
+// ("пропала собака", "найден щенок"), so when the post uses one, agreement
+// tells nothing and only nouns decide.
+
+// Vote weights: a sexed noun is the strongest hint, adjectives the weakest
+// since they may describe something else ("белая грудка").
+const (
+	sexNounWeight      = 2.0
+	sexVerbWeight      = 1.0
+	sexAdjectiveWeight = 0.5
+)
+
+type sexCue struct {
+	sex    SexType
+	kind   string // "noun", "verb", "adjective"; "epicene" nouns carry no sex
+	weight float64
+}
+
+var reSexWord = regexp.MustCompile(`\p{L}+`)
+
+// sexCues maps a lowercase word (ё as е) to its cue; built by init from the
+// lists below.
+var sexCues = map[string]sexCue{}
+
+func init() {
+	add := func(kind string, w float64, sex SexType, words ...string) {
+		for _, x := range words {
+			sexCues[x] = sexCue{sex: sex, kind: kind, weight: w}
+		}
+	}
+	add("noun", sexNounWeight, SexM,
+		"кот", "кота", "коту", "котом", "коте", "котик", "котика", "котику", "котиком",
+		"котяра", "котяру", "котейка", "котофей", "котэ",
+		"пес", "пса", "псу", "псом", "песик", "песика", "песику", "песиком", "песель")
+	add("noun", sexNounWeight, SexF,
+		"кошка", "кошку", "кошкой", "кошке", "кошечка", "кошечку", "кошечкой",
+		"киса", "кису", "кисонька", "кисоньку", "кисуля", "кисулю", "кошара")
+	add("epicene", 0, SexUnknown,
+		"собака", "собаку", "собакой", "собаке", "собачка", "собачку", "собачкой", "собачке",
+		"собачонка", "псина", "псину",
+		"щенок", "щенка", "щенку", "щенком", "щеночек", "щеночка",
+		"котенок", "котенка", "котенку", "котенком", "котеночек", "котеночка",
+		"животное", "животного", "питомец", "питомца", "зверек", "зверька")
+
+	// Past tense: stem / stem+"ся" is masculine, stem+"а" / stem+"ась"
+	// feminine. Transitive verbs are only taken in the reflexive form, since
+	// "потеряла", "нашла" agree with the poster, not the pet.
+	for _, st := range []string{
+		"пропал", "убежал", "сбежал", "выбежал", "убегал", "выскочил", "выпрыгнул",
+		"выпал", "бегал", "сидел", "был", "жил", "улетел", "вылетел",
+	} {
+		add("verb", sexVerbWeight, SexM, st)
+		add("verb", sexVerbWeight, SexF, st+"а")
+	}
+	for _, st := range []string{
+		"потерял", "сорвал", "появил", "прибил", "откликал", "испугал", "заблудил",
+		"вернул", "оказал", "спрятал", "прятал",
+	} {
+		add("verb", sexVerbWeight, SexM, st+"ся")
+		add("verb", sexVerbWeight, SexF, st+"ась")
+	}
+	for _, mf := range [][2]string{{"ушел", "ушла"}, {"вышел", "вышла"}, {"пришел", "пришла"}, {"нашелся", "нашлась"}, {"вылез", "вылезла"}, {"залез", "залезла"}} {
+		add("verb", sexVerbWeight, SexM, mf[0])
+		add("verb", sexVerbWeight, SexF, mf[1])
+	}
+
+	// Short participles: stem is masculine, stem+"а" feminine.
+	for _, st := range []string{
+		"найден", "замечен", "подобран", "стерилизован", "кастрирован", "вакцинирован",
+		"привит", "чипирован", "приучен", "обработан", "спасен", "оставлен", "выброшен",
+		"подброшен", "ранен", "сбит", "напуган", "испуган", "истощен",
+	} {
+		add("verb", sexVerbWeight, SexM, st)
+		add("verb", sexVerbWeight, SexF, st+"а")
+	}
+
+	// Adjectives a post typically says about the pet itself, nominative only;
+	// "-ой" is left out as it is also feminine oblique ("с белой грудкой").
+	for _, st := range []string{
+		"ласков", "пуглив", "домашн", "добр", "спокойн", "игрив", "худ", "голодн",
+		"молод", "стар", "рыж", "черн", "бел", "сер", "пушист", "гладкошерстн",
+		"длинношерстн", "маленьк", "крупн", "небольш", "упитанн", "ручн", "умн",
+		"стерилизованн", "кастрированн", "привит", "любим",
+	} {
+		add("adjective", sexAdjectiveWeight, SexM, st+"ый", st+"ий")
+		add("adjective", sexAdjectiveWeight, SexF, st+"ая", st+"яя")
+	}
+}
+
+func (sc *scan) detectSex() SexType {
+	explicit := sc.classify("sex", sc.rules.sex)
+	morph, conf, conflict := sc.morphologySex()
+	switch {
+	case explicit != "":
+		// People clarify the sex when the noun misleads ("пропала собака,
+		// мальчик"), so explicit words win; a disagreement only costs
+		// confidence.
+		if morph != SexUnknown && string(morph) != explicit {
+			sc.decide("sex", sc.verdicts["sex"].rule, 0.6)
+		}
+		return SexType(explicit)
+	case conflict:
+		sc.decide("sex", "morphology_conflict", 0)
+	case morph != SexUnknown:
+		sc.decide("sex", "morphology", conf)
+	}
+	return morph
+}
+
+// morphologySex tallies noun, verb and adjective cues, recording each, and
+// returns the winner with a confidence. Equal votes are a conflict and yield
+// SexUnknown.
+func (sc *scan) morphologySex() (sex SexType, conf float64, conflict bool) {
+	type hit struct {
+		cue        sexCue
+		start, end int
+	}
+	var hits []hit
+	epicene := false
+	for _, loc := range sc.indexAll(reSexWord) {
+		w := strings.ReplaceAll(strings.ToLower(sc.s[loc[0]:loc[1]]), "ё", "е")
+		c, ok := sexCues[w]
+		if !ok {
+			continue
+		}
+		if c.kind == "epicene" {
+			epicene = true
+			continue
+		}
+		hits = append(hits, hit{c, loc[0], loc[1]})
+	}
+
+	votes := map[SexType]float64{}
+	nouns := map[SexType]bool{}
+	for _, h := range hits {
+		if epicene && h.cue.kind != "noun" {
+			continue // agrees with "собака"/"щенок", not with the animal
+		}
+		sc.record("sex", h.cue.kind+"_"+string(h.cue.sex), h.start, h.end)
+		votes[h.cue.sex] += h.cue.weight
+		if h.cue.kind == "noun" {
+			nouns[h.cue.sex] = true
+		}
+	}
+
+	m, f := votes[SexM], votes[SexF]
+	if m == f {
+		return SexUnknown, 0, m > 0
+	}
+	win, lose := m, f
+	sex = SexM
+	if f > m {
+		win, lose, sex = f, m, SexF
+	}
+	// A lone adjective or verb is a weak hint; a noun, or several agreeing
+	// words, a good one. Contradicting cues lower the confidence.
+	conf = 0.4 + 0.1*min(win, 3)
+	if nouns[sex] {
+		conf += 0.1
+	}
+	conf *= (win - lose) / win
+	return sex, conf, false
+}
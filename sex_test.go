@@ -0,0 +1,43 @@
+package lostdogs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectSex(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		want SexType
+		rule string
+	}{
+		{name: "explicit", text: "Пропала собака, кобель, 3 года", want: SexM, rule: "male"},
+		{name: "explicit beats noun", text: "Найдена кошка, оказалось мальчик", want: SexM, rule: "male"},
+		{name: "noun and verb", text: "Пропала кошка, трехцветная, очень пугливая", want: SexF, rule: "morphology"},
+		{name: "verb and noun", text: "Убежал кот из частного дома в Завьялово", want: SexM, rule: "morphology"},
+		{name: "verb only", text: "Убежала вчера вечером, откликается на Мусю", want: SexF, rule: "morphology"},
+		{name: "participle and adjective", text: "Найден у ТЦ, рыжий, ласковый, в ошейнике", want: SexM, rule: "morphology"},
+		{name: "poster's verb", text: "Я нашла котика у подъезда, ищем хозяев", want: SexM, rule: "morphology"},
+
+		{name: "epicene собака", text: "Пропала собака, рыжая, ласковая", want: SexUnknown},
+		{name: "epicene щенок", text: "Найден щенок возле школы, черный", want: SexUnknown},
+		{name: "epicene with sexed noun", text: "Пропала собака, пёс крупный, черный", want: SexM, rule: "morphology"},
+		{name: "conflict", text: "Убежал кот и пропала кошка, район Буммаш", want: SexUnknown, rule: "morphology_conflict"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, ex := ParseExplain(1, tc.text)
+			require.Equal(t, tc.want, p.Sex)
+			f := ex.Field("sex")
+			require.NotNil(t, f)
+			assert.Equal(t, tc.rule, f.Rule)
+			if tc.want != SexUnknown {
+				assert.Greater(t, f.Confidence, 0.0)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package lostdogs
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Size is how big the animal is. After color it is what finders and owners
+// describing the same dog agree on most.
+type Size struct {
+	Class       SizeClass // from size words, else from weight or height for dogs
+	WeightMinKg float64   // weight range in kg; WeightMaxKg is 0 when unknown
+	WeightMaxKg float64
+	HeightMinCm int // height at the withers in cm; HeightMaxCm is 0 when unknown
+	HeightMaxCm int
+	Build       Build // empty when not mentioned
+}
+
+type SizeClass string
+
+const (
+	SizeSmall  SizeClass = "small"
+	SizeMedium SizeClass = "medium"
+	SizeLarge  SizeClass = "large"
+	SizeGiant  SizeClass = "giant"
+)
+
+var sizeLabels = []string{string(SizeSmall), string(SizeMedium), string(SizeLarge), string(SizeGiant)}
+
+type Build string
+
+const (
+	BuildSlim     Build = "slim"
+	BuildStocky   Build = "stocky"
+	BuildFat      Build = "fat"
+	BuildMuscular Build = "muscular"
+)
+
+var buildLabels = []string{string(BuildSlim), string(BuildStocky), string(BuildFat), string(BuildMuscular)}
+
+// SizeSpec is the optional `size` section of a rule set.
+type SizeSpec struct {
+	Classes []NamedPatterns `yaml:"classes"`
+	Build   []NamedPatterns `yaml:"build"`
+}
+
+type sizeRules struct {
+	classes, build []namedRule
+}
+
+func (c *compiler) size(path string, spec SizeSpec) sizeRules {
+	return sizeRules{
+		classes: c.named(path+".classes", spec.Classes, sizeLabels),
+		build:   c.named(path+".build", spec.Build, buildLabels),
+	}
+}
+
+var (
+	// Weight: "20 кг", "15-20 кг", "около 300 грамм". Group 1 and 2 are the
+	// bounds, group 3 the unit.
+	reWeight = regexp.MustCompile(`(?i)(?:^|[^\p{L}\d.,])(\d+(?:[.,]\d+)?)(?:\s*[-–]\s*(\d+(?:[.,]\d+)?))?\s*(кг|килограмм\p{L}*|кило|гр|грамм\p{L}*)(?:[^\p{L}]|$)`)
+
+	// Height at the withers: "в холке 50 см", "рост 40-45 см", "55 см в
+	// холке". A bare "50 см" may be anything and is ignored.
+	reHeight = regexp.MustCompile(`(?i)(?:(?:в\s+холке|холка|рост\p{L}*|высот\p{L}*)[^\d\p{L}]*(?:(?:около|примерно|до|где-?то)\s+)?(\d+)(?:\s*[-–]\s*(\d+))?\s*(?:см|сантиметр\p{L}*)|(\d+)(?:\s*[-–]\s*(\d+))?\s*(?:см|сантиметр\p{L}*)\s+в\s+холке)`)
+
+	// Words right before a weight that make it not the animal's: "мешок корма
+	// 15 кг".
+	reNotBodyWeight = regexp.MustCompile(`(?i)(?:корм\p{L}*|мешок|мешка|пакет\p{L}*|наполнител\p{L}*|сумм\p{L}*)\s+(?:\p{L}+\s+)?$`)
+)
+
+// Dog size classes by the upper bound of weight and height.
+var (
+	sizeByWeightKg = []struct {
+		max   float64
+		class SizeClass
+	}{{10, SizeSmall}, {25, SizeMedium}, {45, SizeLarge}}
+	sizeByHeightCm = []struct {
+		max   int
+		class SizeClass
+	}{{35, SizeSmall}, {55, SizeMedium}, {70, SizeLarge}}
+)
+
+// extractSize finds the size words, weight, height and build of the animal.
+// Weight and height classify a dog when no size word does; the dog scale
+// says nothing about cats or parrots.
+func (sc *scan) extractSize(animal AnimalType) Size {
+	var sz Size
+	sr := sc.rules.size
+
+	first := -1
+	for _, r := range sr.classes {
+		if m := sc.submatch(r.re); m != nil {
+			sc.record("size", r.name, m[2], m[3])
+			if first < 0 || m[2] < first {
+				first, sz.Class = m[2], SizeClass(r.name)
+			}
+		}
+	}
+	if sz.Class != "" {
+		sc.decide("size", string(sz.Class), 0.8)
+	}
+
+	sz.WeightMinKg, sz.WeightMaxKg = sc.extractWeight()
+	sz.HeightMinCm, sz.HeightMaxCm = sc.extractHeight()
+
+	if sz.Class == "" && (animal == AnimalDog || animal == AnimalUnknown) {
+		switch {
+		case sz.WeightMaxKg > 0:
+			sz.Class = SizeGiant
+			for _, b := range sizeByWeightKg {
+				if sz.WeightMaxKg < b.max {
+					sz.Class = b.class
+					break
+				}
+			}
+			sc.decide("size", "weight", 0.6)
+		case sz.HeightMaxCm > 0:
+			sz.Class = SizeGiant
+			for _, b := range sizeByHeightCm {
+				if sz.HeightMaxCm < b.max {
+					sz.Class = b.class
+					break
+				}
+			}
+			sc.decide("size", "height", 0.6)
+		}
+	}
+
+	first = -1
+	for _, r := range sr.build {
+		if m := sc.submatch(r.re); m != nil {
+			sc.record("build", r.name, m[2], m[3])
+			if first < 0 || m[2] < first {
+				first, sz.Build = m[2], Build(r.name)
+			}
+		}
+	}
+	return sz
+}
+
+// extractWeight returns the first plausible weight range in kg.
+func (sc *scan) extractWeight() (lo, hi float64) {
+	for _, m := range sc.submatchAll(reWeight) {
+		if reNotBodyWeight.MatchString(sc.s[:m[2]]) {
+			continue
+		}
+		a, b, ok := parseRange(sc.s[m[2]:m[3]], group(sc.s, m, 4))
+		if !ok {
+			continue
+		}
+		if unit := strings.ToLower(sc.s[m[6]:m[7]]); strings.HasPrefix(unit, "гр") {
+			a, b = a/1000, b/1000
+		}
+		if b <= 0 || b > 1000 {
+			continue
+		}
+		sc.record("weight", "weight", m[2], m[7])
+		sc.decide("weight", "weight", 0.8)
+		return a, b
+	}
+	return 0, 0
+}
+
+// extractHeight returns the first plausible height range in cm.
+func (sc *scan) extractHeight() (lo, hi int) {
+	for _, m := range sc.submatchAll(reHeight) {
+		from, to := 2, 4 // the "в холке 50 см" form
+		if m[2] < 0 {
+			from, to = 6, 8 // "50 см в холке"
+		}
+		a, b, ok := parseRange(sc.s[m[from]:m[from+1]], group(sc.s, m, to))
+		if !ok || b < 5 || b > 250 {
+			continue
+		}
+		sc.record("height", "height", m[0], m[1])
+		sc.decide("height", "height", 0.8)
+		return int(math.Round(a)), int(math.Round(b))
+	}
+	return 0, 0
+}
+
+// group returns the submatch starting at index i of m, or "" when it did not
+// participate.
+func group(s string, m []int, i int) string {
+	if m[i] < 0 {
+		return ""
+	}
+	return s[m[i]:m[i+1]]
+}
+
+// parseRange parses a number or, when hi is not empty, a range. Decimal
+// commas are accepted.
+func parseRange(lo, hi string) (float64, float64, bool) {
+	a, err := strconv.ParseFloat(strings.ReplaceAll(lo, ",", "."), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if hi == "" {
+		return a, a, true
+	}
+	b, err := strconv.ParseFloat(strings.ReplaceAll(hi, ",", "."), 64)
+	if err != nil || b < a {
+		return 0, 0, false
+	}
+	return a, b, true
+}
+
+// weightText and heightText format ranges for explanations.
+func weightText(sz Size) string {
+	return rangeText(sz.WeightMinKg, sz.WeightMaxKg, "кг")
+}
+
+func heightText(sz Size) string {
+	return rangeText(float64(sz.HeightMinCm), float64(sz.HeightMaxCm), "см")
+}
+
+func rangeText(lo, hi float64, unit string) string {
+	if hi == 0 {
+		return ""
+	}
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	if lo == hi {
+		return f(hi) + " " + unit
+	}
+	return f(lo) + "-" + f(hi) + " " + unit
+}
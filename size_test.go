@@ -0,0 +1,56 @@
+package lostdogs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_Size(t *testing.T) {
+	cases := []struct {
+		text string
+		want Size
+	}{
+		{
+			text: "Пропала собака, около 20 кг, в холке 50 см, худая",
+			want: Size{Class: SizeMedium, WeightMinKg: 20, WeightMaxKg: 20, HeightMinCm: 50, HeightMaxCm: 50, Build: BuildSlim},
+		},
+		{
+			text: "Найден пёс крупный, рыжий, весит 35-40 кг",
+			want: Size{Class: SizeLarge, WeightMinKg: 35, WeightMaxKg: 40},
+		},
+		{
+			text: "Пропал маленький пёсик, весит 3,5 кг",
+			want: Size{Class: SizeSmall, WeightMinKg: 3.5, WeightMaxKg: 3.5},
+		},
+		{
+			text: "Потерялась собака 55 см в холке, коренастая",
+			want: Size{Class: SizeLarge, HeightMinCm: 55, HeightMaxCm: 55, Build: BuildStocky},
+		},
+		{
+			text: "Потерялась очень крупная собака, алабай",
+			want: Size{Class: SizeGiant},
+		},
+		{
+			text: "Пропал кот, 6 кг, толстый, рыжий",
+			want: Size{WeightMinKg: 6, WeightMaxKg: 6, Build: BuildFat}, // no dog scale for cats
+		},
+		{
+			text: "Найден котёнок, весит 300 грамм",
+			want: Size{WeightMinKg: 0.3, WeightMaxKg: 0.3},
+		},
+		{
+			text: "Большая просьба помочь, нужен мешок корма 15 кг, огромное спасибо",
+			want: Size{},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.text, func(t *testing.T) {
+			p, ex := ParseExplain(1, tc.text)
+			assert.Equal(t, tc.want, p.Size)
+			if tc.want.WeightMaxKg > 0 {
+				assert.NotEmpty(t, ex.Field("weight").Matches)
+			}
+		})
+	}
+}
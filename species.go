@@ -0,0 +1,34 @@
+package lostdogs
+
+import "math"
+
+// Species of animals other than cats and dogs come from the optional
+// `species` section of the rule set: a canonical name ("parrot", "ferret",
+// "goat") with its patterns. A post naming one is AnimalOther, and
+// Post.Species tells which, so such posts can be routed separately.
+
+// detectSpecies returns the species mentioned first and where, recording
+// every mention; "" and -1 when none is.
+func (sc *scan) detectSpecies() (string, int) {
+	name, at := "", -1
+	for _, r := range sc.rules.species {
+		m := sc.submatch(r.re)
+		if m == nil {
+			continue
+		}
+		sc.record("species", r.name, m[2], m[3])
+		if at < 0 || m[2] < at {
+			name, at = r.name, m[2]
+		}
+	}
+	return name, at
+}
+
+// firstHit is the start of the earliest hit, or MaxInt when there is none.
+func firstHit(hits []ruleHit) int {
+	first := math.MaxInt
+	for _, h := range hits {
+		first = min(first, h.start)
+	}
+	return first
+}
@@ -0,0 +1,205 @@
+package lostdogs
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Type detection in context. A keyword anywhere in a post is a poor guide
+// when the post talks about both losing and finding ("пропала, но нашлась",
+// "ищем хозяев найденной собаки") or denies one ("не потерялся, а ищет
+// хозяев"). Label rules therefore skip a hit right after a negation word, and
+// when both lost and found still have votes the hits are weighed:
+//
+//   - hits in the first sentence mentioning either are the headline and
+//     count double;
+//   - a clause opened by a contrast word ("но", "а", "однако") tells what
+//     happened next and counts triple, so "нашли, но пропала позже" is lost;
+//   - phrases only an owner writes ("помогите найти нашу", "кто видел") or
+//     only a finder ("ищем хозяев", "чья собака") add to their side.
+//
+// Equal weights go to the event mentioned first. A post with no type keyword
+// at all is typed by its speaker cues alone.
+
+const (
+	headlineWeight = 2.0
+	contrastWeight = 3.0
+	speakerWeight  = 2.0
+)
+
+// TypeContextSpec is the optional `type_context` section of a rule set.
+type TypeContextSpec struct {
+	Negation []string `yaml:"negation"` // words that negate the next word
+	Contrast []string `yaml:"contrast"` // words that open a contrasting clause
+	Owner    []string `yaml:"owner"`    // patterns only an owner writes
+	Finder   []string `yaml:"finder"`   // patterns only a finder writes
+}
+
+type typeContext struct {
+	negation map[string]bool
+	contrast map[string]bool
+	owner    *regexp.Regexp // nil when unset
+	finder   *regexp.Regexp // nil when unset
+}
+
+func (c *compiler) typeContext(path string, spec TypeContextSpec) typeContext {
+	words := func(p string, ws []string) map[string]bool {
+		m := map[string]bool{}
+		for i, w := range ws {
+			w = strings.ToLower(strings.TrimSpace(w))
+			if w == "" || strings.ContainsFunc(w, func(r rune) bool { return !unicode.IsLetter(r) }) {
+				c.errorf(fmt.Sprintf("%s[%d]", p, i), "%q is not a single word", ws[i])
+				continue
+			}
+			m[w] = true
+		}
+		return m
+	}
+	return typeContext{
+		negation: words(path+".negation", spec.Negation),
+		contrast: words(path+".contrast", spec.Contrast),
+		owner:    c.optional(path+".owner", spec.Owner),
+		finder:   c.optional(path+".finder", spec.Finder),
+	}
+}
+
+// clause is a stretch of the scan text between punctuation marks or before a
+// contrast word.
+type clause struct {
+	start    int
+	contrast bool // opened by a contrast word
+}
+
+// clauseAt returns the index and the clause containing byte offset pos,
+// splitting the scan text on first use.
+func (sc *scan) clauseAt(pos int) (int, clause) {
+	if sc.clauses == nil {
+		sc.clauses = sc.splitClauses()
+	}
+	i := sort.Search(len(sc.clauses), func(i int) bool { return sc.clauses[i].start > pos }) - 1
+	return i, sc.clauses[i]
+}
+
+func (sc *scan) splitClauses() []clause {
+	type cut struct {
+		pos      int
+		contrast bool
+	}
+	var cuts []cut
+	for i, r := range sc.s {
+		if strings.ContainsRune(".!?;:,", r) {
+			cuts = append(cuts, cut{pos: i + 1})
+		}
+	}
+	for _, t := range sc.tokens() {
+		if sc.rules.context.contrast[strings.ToLower(t.Text)] {
+			cuts = append(cuts, cut{pos: t.Start, contrast: true})
+		}
+	}
+	sort.SliceStable(cuts, func(i, j int) bool { return cuts[i].pos < cuts[j].pos })
+
+	out := []clause{{start: 0}}
+	for _, c := range cuts {
+		last := &out[len(out)-1]
+		if c.contrast && strings.TrimSpace(sc.s[last.start:c.pos]) == "" {
+			last.contrast = true // ", но" opens the clause the comma started
+			continue
+		}
+		out = append(out, clause{start: c.pos, contrast: c.contrast})
+	}
+	return out
+}
+
+// negated reports whether the word before byte offset start, within its
+// clause, is a negation ("не потерялся", "пока не найден").
+func (sc *scan) negated(start int) bool {
+	neg := sc.rules.context.negation
+	if len(neg) == 0 {
+		return false
+	}
+	toks := sc.tokens()
+	i := sort.Search(len(toks), func(i int) bool { return toks[i].End > start }) - 1
+	if i < 0 {
+		return false
+	}
+	_, cl := sc.clauseAt(start)
+	return toks[i].Start >= cl.start && neg[strings.ToLower(toks[i].Text)]
+}
+
+// sentence returns the index of the sentence containing byte offset pos.
+func (sc *scan) sentence(pos int) int {
+	return strings.Count(sc.s[:pos], ".") + strings.Count(sc.s[:pos], "!") + strings.Count(sc.s[:pos], "?")
+}
+
+// typeInContext decides lost versus found when both voted, or types a post
+// without type keywords by its speaker cues. It returns "" when context does
+// not apply and the usual priority should decide.
+func (sc *scan) typeInContext(b ballot) string {
+	lost, found := string(TypeLost), string(TypeFound)
+	switch {
+	case len(b.votes) == 0:
+		owner, finder := sc.speaker("owner"), sc.speaker("finder")
+		switch {
+		case owner > finder:
+			sc.decide("type", "owner", 0.5)
+			return lost
+		case finder > owner:
+			sc.decide("type", "finder", 0.5)
+			return found
+		}
+		return ""
+	case !b.votes[lost] || !b.votes[found]:
+		return ""
+	}
+
+	first := map[string]int{}
+	for _, l := range []string{lost, found} {
+		first[l] = len(sc.s)
+		for _, h := range b.hits[l] {
+			first[l] = min(first[l], h.start)
+		}
+	}
+	head := sc.sentence(min(first[lost], first[found]))
+
+	score := map[string]float64{
+		lost:  speakerWeight * float64(sc.speaker("owner")),
+		found: speakerWeight * float64(sc.speaker("finder")),
+	}
+	for _, l := range []string{lost, found} {
+		for _, h := range b.hits[l] {
+			w := 1.0
+			if h.weak {
+				w = 0.5
+			}
+			if sc.sentence(h.start) == head {
+				w *= headlineWeight
+			}
+			if i, cl := sc.clauseAt(h.start); i > 0 && cl.contrast {
+				w *= contrastWeight
+			}
+			score[l] += w
+		}
+	}
+
+	win, lose := lost, found
+	if score[found] > score[lost] || (score[found] == score[lost] && first[found] < first[lost]) {
+		win, lose = found, lost
+	}
+	sc.decide("type", "context", 0.5+0.3*(score[win]-score[lose])/(score[win]+score[lose]))
+	return win
+}
+
+// speaker counts and records the owner or finder cues in the scan text.
+func (sc *scan) speaker(who string) int {
+	re := sc.rules.context.owner
+	if who == "finder" {
+		re = sc.rules.context.finder
+	}
+	if re == nil {
+		return 0
+	}
+	return len(sc.findAll("type", who, re))
+}
@@ -0,0 +1,124 @@
+package lostdogs
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// Urgency is how soon a post needs a response. A found dog "сбита машиной"
+// or "в крови" cannot wait in the queue behind ordinary sightings.
+type Urgency string
+
+const (
+	UrgencyNormal   Urgency = "normal"
+	UrgencyHigh     Urgency = "high"     // injured, sick, needs a foster home now
+	UrgencyCritical Urgency = "critical" // hit by a car, bleeding, dying
+)
+
+var urgencyLabels = []string{string(UrgencyNormal), string(UrgencyHigh), string(UrgencyCritical)}
+
+// Rank orders urgency levels: 0 for normal, 2 for critical.
+func (u Urgency) Rank() int {
+	return max(slices.Index(urgencyLabels, string(u)), 0)
+}
+
+// UrgencySpec is the optional `urgency` section of a rule set.
+type UrgencySpec struct {
+	Health   []HealthPatterns `yaml:"health"`   // injuries and conditions
+	Critical []string         `yaml:"critical"` // phrases that make a post critical
+	High     []string         `yaml:"high"`     // phrases that make a post urgent
+}
+
+// HealthPatterns is an injury or condition flag and the urgency it implies.
+type HealthPatterns struct {
+	Name     string   `yaml:"name"`
+	Urgency  string   `yaml:"urgency"`
+	Patterns []string `yaml:"patterns"`
+}
+
+type urgencyRules struct {
+	health   []healthRule
+	critical *regexp.Regexp // whole words; nil when unset
+	high     *regexp.Regexp
+}
+
+type healthRule struct {
+	namedRule
+	urgency Urgency
+}
+
+func (c *compiler) urgency(path string, spec UrgencySpec) urgencyRules {
+	named := make([]NamedPatterns, len(spec.Health))
+	for i, h := range spec.Health {
+		named[i] = NamedPatterns{Name: h.Name, Patterns: h.Patterns}
+	}
+	ur := urgencyRules{
+		critical: c.optionalWords(path+".critical", spec.Critical),
+		high:     c.optionalWords(path+".high", spec.High),
+	}
+	for i, r := range c.named(path+".health", named, nil) {
+		u := Urgency(spec.Health[i].Urgency)
+		if u != UrgencyHigh && u != UrgencyCritical {
+			c.errorf(fmt.Sprintf("%s.health[%d].urgency", path, i), "unknown value %q (allowed: high, critical)", u)
+		}
+		ur.health = append(ur.health, healthRule{namedRule: r, urgency: u})
+	}
+	return ur
+}
+
+// optionalWords is words for a list that may be empty; nil when it is.
+func (c *compiler) optionalWords(path string, ps []string) *regexp.Regexp {
+	if len(ps) == 0 {
+		return nil
+	}
+	return c.words(path, ps)
+}
+
+// detectUrgency returns the post's urgency and its health flags in rule
+// order. The most urgent flag or phrase decides; negated mentions ("не
+// хромает") are skipped.
+func (sc *scan) detectUrgency() (Urgency, []string) {
+	ur := sc.rules.urgency
+	u, rule := UrgencyNormal, ""
+	raise := func(v Urgency, r string) {
+		if v.Rank() > u.Rank() {
+			u, rule = v, r
+		}
+	}
+	var health []string
+	for _, r := range ur.health {
+		if m := sc.firstAffirmed(r.re); m != nil {
+			sc.record("health", r.name, m[2], m[3])
+			health = append(health, r.name)
+			raise(r.urgency, r.name)
+		}
+	}
+	for _, ph := range []struct {
+		re *regexp.Regexp
+		u  Urgency
+	}{{ur.critical, UrgencyCritical}, {ur.high, UrgencyHigh}} {
+		if ph.re == nil {
+			continue
+		}
+		if m := sc.firstAffirmed(ph.re); m != nil {
+			sc.record("urgency", string(ph.u), m[2], m[3])
+			raise(ph.u, string(ph.u))
+		}
+	}
+	if rule != "" {
+		sc.decide("urgency", rule, 0.8)
+	}
+	return u, health
+}
+
+// firstAffirmed returns the submatch indexes of the leftmost match of a words
+// regexp whose mention is not negated, or nil.
+func (sc *scan) firstAffirmed(re *regexp.Regexp) []int {
+	for _, m := range sc.submatchAll(re) {
+		if !sc.negated(m[2]) {
+			return m
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package lostdogs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_Urgency(t *testing.T) {
+	cases := []struct {
+		text    string
+		urgency Urgency
+		health  []string
+	}{
+		{"Найдена собака, сбита машиной, лежит у дороги на Пушкинской", UrgencyCritical, []string{"hit_by_car"}},
+		{"Найден пёс, хромает на заднюю лапу, в крови", UrgencyCritical, []string{"bleeding", "limp"}},
+		{"Найдена кошка, очень истощённая, у подъезда", UrgencyHigh, []string{"emaciated"}},
+		{"Сбежал пёс, прихрамывает на заднюю лапу", UrgencyHigh, []string{"limp"}},
+		{"Нужна срочно передержка для найденной собаки", UrgencyHigh, nil},
+		{"Найден пёс, не хромает, здоров, ищем хозяина", UrgencyNormal, nil},
+		{"Пропала собака, рыжий кобель, район Буммаш", UrgencyNormal, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.text, func(t *testing.T) {
+			p, ex := ParseExplain(1, tc.text)
+			assert.Equal(t, tc.urgency, p.Urgency)
+			assert.Equal(t, tc.health, p.Health)
+			assert.NotContains(t, p.Appearance.Marks, "limp", "limping is a health flag only")
+			if tc.urgency != UrgencyNormal {
+				assert.NotEmpty(t, ex.Field("urgency").Rule)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package lostdogs
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Izhevsk is the local time of the monitored groups (Europe/Samara, UTC+4,
+// no DST since 2011). Relative expressions are resolved in this zone.
+var Izhevsk = time.FixedZone("Europe/Samara", 4*60*60)
+
+// WhenPrecision tells how exact a resolved Post.WhenFrom/WhenTo interval is.
+type WhenPrecision string
+
+const (
+	WhenMinute    WhenPrecision = "minute"      // "в 22:00"
+	WhenHour      WhenPrecision = "hour"        // "около 8 утра"
+	WhenPartOfDay WhenPrecision = "part_of_day" // "вечером", "в ночь на субботу"
+	WhenDay       WhenPrecision = "day"         // "вчера", "15 сентября"
+)
+
+var (
+	reDayMonth = regexp.MustCompile(`(?i)(?:^|[^\p{L}\d])((\d{1,2})\s+(января|февраля|марта|апреля|мая|июня|июля|августа|сентября|октября|ноября|декабря)(?:\s+(\d{4})(?:\s*г(?:ода|\.)?)?)?)`)
+	reRelDay   = regexp.MustCompile(`(?i)(?:^|[^\p{L}])(позавчера|вчера|сегодня)(?:[^\p{L}]|$)`)
+	reWeekday  = regexp.MustCompile(`(?i)(?:^|[^\p{L}])(во?\s+(понедельник|вторник|среду|четверг|пятницу|субботу|воскресенье))(?:[^\p{L}]|$)`)
+	reNight    = regexp.MustCompile(`(?i)(?:^|[^\p{L}])(в\s+ночь\s+(?:с\s+(?:понедельника|вторника|среды|четверга|пятницы|субботы|воскресенья)\s+)?на\s+(понедельник|вторник|среду|четверг|пятницу|субботу|воскресенье|сегодня|вчера))(?:[^\p{L}]|$)`)
+	reDaysAgo  = regexp.MustCompile(`(?i)(?:^|[^\p{L}\d])((?:(\d{1,2}|пару|два|две|три|четыре|пять)\s+)?(день|дн\p{L}+|недел\p{L}+)\s+назад)`)
+
+	reClock     = regexp.MustCompile(`(?:^|[^\d.:])(\d{1,2})[:.](\d{2})(?:[^\d:]|[.](?:\D|$)|$)`)
+	reHour      = regexp.MustCompile(`(?i)(?:^|[^\p{L}\d])((\d{1,2})\s*(?:час\p{L}*\s*(утра|дня|вечера|ночи)?|(утра|вечера|ночи)))(?:[^\p{L}]|$)`)
+	rePartOfDay = regexp.MustCompile(`(?i)(?:^|[^\p{L}])(утром|дн[её]м|вечером|ночью)(?:[^\p{L}]|$)`)
+)
+
+var weekdays = map[string]time.Weekday{
+	"понедельник": time.Monday, "вторник": time.Tuesday, "среду": time.Wednesday,
+	"четверг": time.Thursday, "пятницу": time.Friday, "субботу": time.Saturday,
+	"воскресенье": time.Sunday,
+}
+
+var months = map[string]time.Month{
+	"января": time.January, "февраля": time.February, "марта": time.March,
+	"апреля": time.April, "мая": time.May, "июня": time.June, "июля": time.July,
+	"августа": time.August, "сентября": time.September, "октября": time.October,
+	"ноября": time.November, "декабря": time.December,
+}
+
+var smallNumbers = map[string]int{"пару": 2, "два": 2, "две": 2, "три": 3, "четыре": 4, "пять": 5}
+
+// Parts of the day as [from, to) hours.
+var partsOfDay = map[string][2]int{"утром": {6, 12}, "днём": {12, 18}, "днем": {12, 18}, "вечером": {18, 24}, "ночью": {0, 6}}
+
+// dayRef is the day part of a When expression.
+type dayRef struct {
+	start, end int // byte span in the scan text
+	rule       string
+	conf       float64
+	day        time.Time // local midnight; zero when it can't be resolved
+	night      bool      // "в ночь на X": the night before day
+}
+
+// timeRef is the time-of-day part of a When expression.
+type timeRef struct {
+	start, end int
+	rule       string
+	from, to   time.Duration // offsets from midnight
+	prec       WhenPrecision
+	text       string // normalized, e.g. "22:00"
+}
+
+// extractWhen finds when the animal went missing or was seen. The returned
+// interval is resolved against posted (the VK post date); with a zero posted
+// only absolute dates resolve. The string is the phrase as written.
+func (sc *scan) extractWhen(posted time.Time) (string, time.Time, time.Time, WhenPrecision) {
+	var today time.Time
+	if !posted.IsZero() {
+		y, m, d := posted.In(Izhevsk).Date()
+		today = time.Date(y, m, d, 0, 0, 0, 0, Izhevsk)
+	}
+
+	dr := sc.findDay(today)
+	var tr *timeRef
+	if dr != nil {
+		tr = sc.findTime(dr.end, min(dr.end+30, len(sc.s)))
+		if tr == nil {
+			tr = sc.findTime(max(dr.start-30, 0), dr.start)
+		}
+	} else {
+		tr = sc.findTime(0, len(sc.s))
+	}
+	if dr == nil && tr == nil {
+		return "", time.Time{}, time.Time{}, ""
+	}
+
+	if dr != nil {
+		sc.record("when", dr.rule, dr.start, dr.end)
+	}
+	if tr != nil {
+		sc.record("when", tr.rule, tr.start, tr.end)
+	}
+	switch {
+	case dr != nil && dr.rule == "date" && tr != nil:
+		sc.decide("when", "date_time", 0.9)
+	case dr != nil && tr != nil:
+		sc.decide("when", dr.rule, dr.conf+0.1)
+	case dr != nil:
+		sc.decide("when", dr.rule, dr.conf)
+	default:
+		sc.decide("when", tr.rule, 0.5)
+	}
+
+	text := sc.whenText(dr, tr)
+	day := time.Time{}
+	if dr != nil {
+		day = dr.day
+	} else if !today.IsZero() {
+		// A bare time of day refers to the latest such moment before the post.
+		day = today
+		if day.Add(tr.from).After(posted) {
+			day = day.AddDate(0, 0, -1)
+		}
+	}
+	if day.IsZero() {
+		return text, time.Time{}, time.Time{}, ""
+	}
+
+	switch {
+	case tr != nil:
+		if dr != nil && dr.night && tr.from >= 12*time.Hour {
+			day = day.AddDate(0, 0, -1)
+		}
+		return text, day.Add(tr.from), day.Add(tr.to), tr.prec
+	case dr.night:
+		return text, day.Add(-2 * time.Hour), day.Add(6 * time.Hour), WhenPartOfDay
+	default:
+		return text, day, day.AddDate(0, 0, 1), WhenDay
+	}
+}
+
+// whenText keeps the historical "dd.mm.yyyy hh:mm" form for numeric dates and
+// the written phrase for everything else.
+func (sc *scan) whenText(dr *dayRef, tr *timeRef) string {
+	if dr != nil && dr.rule == "date" {
+		date := sc.s[dr.start:dr.end]
+		if tr != nil && tr.prec == WhenMinute {
+			return date + " " + tr.text
+		}
+		return date
+	}
+	start, end := len(sc.s), 0
+	if dr != nil {
+		start, end = dr.start, dr.end
+	}
+	if tr != nil {
+		start, end = min(start, tr.start), max(end, tr.end)
+	}
+	return normalizeSpace(sc.s[start:end])
+}
+
+// findDay returns the first day expression, trying the most specific forms
+// first. today is the local midnight of the post date, or zero.
+func (sc *scan) findDay(today time.Time) *dayRef {
+	s := sc.s
+	if loc := sc.index(reDate); loc != nil {
+		dr := &dayRef{start: loc[0], end: loc[1], rule: "date", conf: 0.8}
+		parts := strings.Split(s[loc[0]:loc[1]], ".")
+		d, _ := strconv.Atoi(parts[0])
+		m, _ := strconv.Atoi(parts[1])
+		y, _ := strconv.Atoi(parts[2])
+		if y < 100 {
+			y += 2000
+		}
+		dr.day = validDate(y, time.Month(m), d)
+		return dr
+	}
+	if m := sc.submatch(reDayMonth); m != nil {
+		dr := &dayRef{start: m[2], end: m[3], rule: "day_month", conf: 0.8}
+		d, _ := strconv.Atoi(s[m[4]:m[5]])
+		mon := months[strings.ToLower(s[m[6]:m[7]])]
+		switch {
+		case m[8] >= 0:
+			y, _ := strconv.Atoi(s[m[8]:m[9]])
+			dr.day = validDate(y, mon, d)
+		case !today.IsZero():
+			// No year: the latest such date not after the post.
+			dr.day = validDate(today.Year(), mon, d)
+			if dr.day.After(today) {
+				dr.day = validDate(today.Year()-1, mon, d)
+			}
+		}
+		return dr
+	}
+	if m := sc.submatch(reNight); m != nil {
+		dr := &dayRef{start: m[2], end: m[3], rule: "night", conf: 0.7, night: true}
+		dr.day = relativeDay(today, strings.ToLower(s[m[4]:m[5]]))
+		return dr
+	}
+	if m := sc.submatch(reRelDay); m != nil {
+		dr := &dayRef{start: m[2], end: m[3], rule: "relative_day", conf: 0.7}
+		dr.day = relativeDay(today, strings.ToLower(s[m[2]:m[3]]))
+		return dr
+	}
+	if m := sc.submatch(reWeekday); m != nil {
+		dr := &dayRef{start: m[2], end: m[3], rule: "weekday", conf: 0.6}
+		dr.day = relativeDay(today, strings.ToLower(s[m[4]:m[5]]))
+		return dr
+	}
+	if m := sc.submatch(reDaysAgo); m != nil {
+		dr := &dayRef{start: m[2], end: m[3], rule: "days_ago", conf: 0.6}
+		n := 1
+		if m[4] >= 0 {
+			w := strings.ToLower(s[m[4]:m[5]])
+			if v, err := strconv.Atoi(w); err == nil {
+				n = v
+			} else {
+				n = smallNumbers[w]
+			}
+		}
+		if strings.HasPrefix(strings.ToLower(s[m[6]:m[7]]), "недел") {
+			n *= 7
+		}
+		if !today.IsZero() {
+			dr.day = today.AddDate(0, 0, -n)
+		}
+		return dr
+	}
+	return nil
+}
+
+// relativeDay resolves сегодня/вчера/позавчера or a weekday (the most recent
+// one, today included) against today.
+func relativeDay(today time.Time, w string) time.Time {
+	if today.IsZero() {
+		return time.Time{}
+	}
+	switch w {
+	case "сегодня":
+		return today
+	case "вчера":
+		return today.AddDate(0, 0, -1)
+	case "позавчера":
+		return today.AddDate(0, 0, -2)
+	}
+	wd, ok := weekdays[w]
+	if !ok {
+		return time.Time{}
+	}
+	back := (int(today.Weekday()) - int(wd) + 7) % 7
+	return today.AddDate(0, 0, -back)
+}
+
+// afterPreposition reports whether s ends with a word that introduces a clock
+// time ("в 8 часов", "около 9 часов").
+func afterPreposition(s string) bool {
+	f := strings.Fields(strings.ToLower(s))
+	if len(f) == 0 {
+		return false
+	}
+	switch f[len(f)-1] {
+	case "в", "во", "около", "к", "после", "до":
+		return true
+	}
+	return false
+}
+
+func validDate(y int, m time.Month, d int) time.Time {
+	t := time.Date(y, m, d, 0, 0, 0, 0, Izhevsk)
+	if t.Day() != d || t.Month() != m {
+		return time.Time{} // 31.02 and the like
+	}
+	return t
+}
+
+// findTime returns the first time-of-day expression within s[from:to].
+func (sc *scan) findTime(from, to int) *timeRef {
+	for from < len(sc.s) && !utf8.RuneStart(sc.s[from]) {
+		from++
+	}
+	for to < len(sc.s) && !utf8.RuneStart(sc.s[to]) {
+		to++
+	}
+	if from >= to {
+		return nil
+	}
+	s := sc.s[from:to]
+	if m := reClock.FindStringSubmatchIndex(s); m != nil {
+		h, _ := strconv.Atoi(s[m[2]:m[3]])
+		mm, _ := strconv.Atoi(s[m[4]:m[5]])
+		if h < 24 && mm < 60 {
+			at := time.Duration(h)*time.Hour + time.Duration(mm)*time.Minute
+			return &timeRef{
+				start: from + m[2], end: from + m[5], rule: "time",
+				from: at, to: at + time.Minute, prec: WhenMinute,
+				text: s[m[2]:m[3]] + ":" + s[m[4]:m[5]],
+			}
+		}
+	}
+	for _, m := range reHour.FindAllStringSubmatchIndex(s, -1) {
+		if strings.HasPrefix(strings.TrimSpace(strings.ToLower(s[m[3]:])), "назад") {
+			continue // "2 часа назад"
+		}
+		h, _ := strconv.Atoi(s[m[4]:m[5]])
+		pod := ""
+		if m[6] >= 0 {
+			pod = s[m[6]:m[7]]
+		} else if m[8] >= 0 {
+			pod = s[m[8]:m[9]]
+		}
+		if pod == "" && !afterPreposition(s[:m[4]]) {
+			continue // "искали 2 часа" is a duration
+		}
+		switch strings.ToLower(pod) {
+		case "дня", "вечера":
+			if h < 12 {
+				h += 12
+			}
+		case "ночи":
+			if h == 12 {
+				h = 0
+			}
+		}
+		if h >= 24 {
+			continue
+		}
+		at := time.Duration(h) * time.Hour
+		return &timeRef{start: from + m[2], end: from + m[3], rule: "hour", from: at, to: at + time.Hour, prec: WhenHour}
+	}
+	if m := rePartOfDay.FindStringSubmatchIndex(s); m != nil {
+		r := partsOfDay[strings.ToLower(s[m[2]:m[3]])]
+		return &timeRef{
+			start: from + m[2], end: from + m[3], rule: "part_of_day",
+			from: time.Duration(r[0]) * time.Hour, to: time.Duration(r[1]) * time.Hour, prec: WhenPartOfDay,
+		}
+	}
+	return nil
+}
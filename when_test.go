@@ -0,0 +1,59 @@
+package lostdogs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAt_When(t *testing.T) {
+	// Saturday afternoon in Izhevsk.
+	posted := time.Date(2025, 9, 20, 15, 0, 0, 0, Izhevsk)
+	at := func(d, h, m int) time.Time { return time.Date(2025, 9, d, h, m, 0, 0, Izhevsk) }
+
+	cases := []struct {
+		text     string
+		when     string
+		from, to time.Time
+		prec     WhenPrecision
+	}{
+		{"Потерялась кошка вчера вечером у дома на Удмуртской", "вчера вечером", at(19, 18, 0), at(20, 0, 0), WhenPartOfDay},
+		{"Пропал пёс сегодня около 8 утра на Буммаше", "сегодня около 8 утра", at(20, 8, 0), at(20, 9, 0), WhenHour},
+		{"Сбежала собака в ночь с пятницы на субботу, Восточный поселок", "в ночь с пятницы на субботу", at(19, 22, 0), at(20, 6, 0), WhenPartOfDay},
+		{"Найден пёс 15 сентября в районе Металлурга", "15 сентября", at(15, 0, 0), at(16, 0, 0), WhenDay},
+		{"Потерялась кошка 26.08.2025 примерно в 22:00 на Воткинском шоссе", "26.08.2025 22:00",
+			time.Date(2025, 8, 26, 22, 0, 0, 0, Izhevsk), time.Date(2025, 8, 26, 22, 1, 0, 0, Izhevsk), WhenMinute},
+		{"Ушла из дома в среду, кошка трехцветная, помогите найти", "в среду", at(17, 0, 0), at(18, 0, 0), WhenDay},
+		{"Пропал кот три дня назад, район Ленинский", "три дня назад", at(17, 0, 0), at(18, 0, 0), WhenDay},
+		{"Искали кота 2 часа, потом нашли в 9 часов у гаражей", "9 часов", at(20, 9, 0), at(20, 10, 0), WhenHour},
+		{"Видели собаку в 19:30 у остановки Аврора", "19:30", at(19, 19, 30), at(19, 19, 31), WhenMinute},
+		{"Найден щенок 20 декабря, ищем хозяев, район Ленинский", "20 декабря",
+			time.Date(2024, 12, 20, 0, 0, 0, 0, Izhevsk), time.Date(2024, 12, 21, 0, 0, 0, 0, Izhevsk), WhenDay},
+		// Five days after the post: the event was a year earlier.
+		{"Пропала собака 25 сентября у вокзала", "25 сентября",
+			time.Date(2024, 9, 25, 0, 0, 0, 0, Izhevsk), time.Date(2024, 9, 26, 0, 0, 0, 0, Izhevsk), WhenDay},
+		{"Пропала собака 25 сентября в 22:00 у вокзала", "25 сентября в 22:00",
+			time.Date(2024, 9, 25, 22, 0, 0, 0, Izhevsk), time.Date(2024, 9, 25, 22, 1, 0, 0, Izhevsk), WhenMinute},
+	}
+	for _, tc := range cases {
+		t.Run(tc.text, func(t *testing.T) {
+			p := ParseAt(1, tc.text, posted)
+			assert.Equal(t, tc.when, p.When)
+			assert.True(t, tc.from.Equal(p.WhenFrom), "from: want %v, got %v", tc.from, p.WhenFrom)
+			assert.True(t, tc.to.Equal(p.WhenTo), "to: want %v, got %v", tc.to, p.WhenTo)
+			assert.Equal(t, tc.prec, p.WhenPrecision)
+		})
+	}
+}
+
+func TestParse_WhenWithoutPostDate(t *testing.T) {
+	p := Parse(1, "Потерялась кошка вчера вечером у дома на Удмуртской")
+	assert.Equal(t, "вчера вечером", p.When)
+	assert.True(t, p.WhenFrom.IsZero(), "relative dates need the post date")
+	assert.Empty(t, p.WhenPrecision)
+
+	p = Parse(2, "Найден пёс 15 сентября 2025 года в районе Металлурга")
+	assert.Equal(t, WhenDay, p.WhenPrecision)
+	assert.True(t, time.Date(2025, 9, 15, 0, 0, 0, 0, Izhevsk).Equal(p.WhenFrom))
+}